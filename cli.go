@@ -0,0 +1,449 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	"github.com/twsm000/lenslocked/models/database"
+	"github.com/twsm000/lenslocked/models/entities"
+	"github.com/twsm000/lenslocked/models/repositories"
+	"github.com/twsm000/lenslocked/models/services"
+	"github.com/twsm000/lenslocked/pkg/settings"
+)
+
+var (
+	ErrUnknownCommand   = errors.New("unknown command")
+	ErrInvalidArguments = errors.New("invalid arguments")
+)
+
+// cliSource is the audit source of the actions done with the CLI, which
+// have no actor
+var cliSource = entities.AuditSource{UserAgent: "lenslocked-cli"}
+
+// migrateCommands are the goose commands accepted by migrate
+var migrateCommands = []string{"up", "down", "status", "redo"}
+
+// command is a subcommand of the binary, it receives the arguments after
+// its name
+type command struct {
+	usage string
+	run   func(cli *CLI, ctx context.Context, args []string) error
+}
+
+// commands are keyed by their name, a group and a subcommand are separated
+// by a space, like "user create"
+var commands = map[string]command{
+	"serve": {
+		usage: "starts the web server, the default command",
+		run:   (*CLI).serve,
+	},
+	"migrate": {
+		usage: "up|down|status|redo, runs the database migrations",
+		run:   (*CLI).migrate,
+	},
+	"user create": {
+		usage: "-email E [-admin], creates an user with the password read from stdin",
+		run:   (*CLI).createUser,
+	},
+	"user disable": {
+		usage: "-id N | -email E, disables the user and signs it out",
+		run:   (*CLI).disableUser,
+	},
+	"user reset-password": {
+		usage: "-id N | -email E, sets the password read from stdin and signs the user out",
+		run:   (*CLI).resetUserPassword,
+	},
+	"user list": {
+		usage: "[-query Q] [-limit N] [-offset N], lists the users",
+		run:   (*CLI).listUsers,
+	},
+	"sessions purge": {
+		usage: "[-id N | -email E], signs out the user, or everyone when no user is given",
+		run:   (*CLI).purgeSessions,
+	},
+	"config check": {
+		usage: "validates the settings, reporting every problem",
+		run:   (*CLI).checkConfig,
+	},
+	"config show": {
+		usage: "prints the effective settings as JSON, with the secrets masked",
+		run:   (*CLI).showConfig,
+	},
+}
+
+// CLI runs the subcommands of the binary with the loaded settings
+type CLI struct {
+	Env    *EnvConfig
+	Logger *slog.Logger
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Run runs the command named by the first arguments, serve when there
+// are none. Possible errors:
+//   - ErrUnknownCommand
+//   - ErrInvalidArguments
+//   - the errors of the command
+func (cli *CLI) Run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+
+	name := args[0]
+	if len(args) > 1 {
+		if _, ok := commands[name+" "+args[1]]; ok {
+			name += " " + args[1]
+		}
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		cli.PrintUsage()
+		return fmt.Errorf("%w: %q", ErrUnknownCommand, strings.Join(args, " "))
+	}
+	return cmd.run(cli, ctx, args[len(strings.Fields(name)):])
+}
+
+// PrintUsage writes every command and its usage to Stderr
+func (cli *CLI) PrintUsage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(cli.Stderr, "Commands:")
+	w := tabwriter.NewWriter(cli.Stderr, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\t%s\n", name, commands[name].usage)
+	}
+	w.Flush()
+}
+
+func (cli *CLI) checkConfig(ctx context.Context, args []string) error {
+	if err := cli.parseFlags(flag.NewFlagSet("config check", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+	if err := cli.Env.Validate(); err != nil {
+		fmt.Fprintln(cli.Stdout, err)
+		return settings.ErrInvalidSettings
+	}
+	fmt.Fprintln(cli.Stdout, "Settings OK")
+	return nil
+}
+
+func (cli *CLI) showConfig(ctx context.Context, args []string) error {
+	if err := cli.parseFlags(flag.NewFlagSet("config show", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+	masked, err := MaskedSettings(cli.Env)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(cli.Stdout)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(masked)
+}
+
+func (cli *CLI) migrate(ctx context.Context, args []string) error {
+	if len(args) != 1 || !slices.Contains(migrateCommands, args[0]) {
+		return fmt.Errorf("%w: migrate %s", ErrInvalidArguments, strings.Join(migrateCommands, "|"))
+	}
+
+	db, err := database.NewConnection(cli.Env.DBConfig)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	goose.SetLogger(log.New(cli.Stdout, "", 0))
+	return database.RunMigrations(ctx, db, cli.Env.DBConfig, args[0])
+}
+
+func (cli *CLI) createUser(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("user create", flag.ContinueOnError)
+	email := fs.String("email", "", "E-mail of the user")
+	asAdmin := fs.Bool("admin", false, "Creates an administrator")
+	if err := cli.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *email == "" {
+		return fmt.Errorf("%w: -email is required", ErrInvalidArguments)
+	}
+	password, err := cli.readPassword()
+	if err != nil {
+		return err
+	}
+
+	return cli.withAdmin(ctx, func(admin *adminTools) error {
+		input := entities.UserCreatable{Password: password}
+		input.Email.Set(*email)
+		user, err := entities.NewCreatableUser(input)
+		if err != nil {
+			return err
+		}
+		if *asAdmin {
+			user.Role = entities.RoleAdmin
+		}
+		if err := admin.repos.Users.Create(ctx, user); err != nil {
+			return err
+		}
+		fmt.Fprintf(cli.Stdout, "Created %s %d %s\n", user.Role, user.ID, user.Email)
+		return nil
+	})
+}
+
+func (cli *CLI) disableUser(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("user disable", flag.ContinueOnError)
+	selector := userSelector(fs)
+	if err := cli.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if selector.isEmpty() {
+		return fmt.Errorf("%w: -id or -email is required", ErrInvalidArguments)
+	}
+
+	return cli.withAdmin(ctx, func(admin *adminTools) error {
+		user, err := admin.findUser(ctx, selector)
+		if err != nil {
+			return err
+		}
+		if err := admin.service.DisableUser(ctx, nil, cliSource, user.ID); err != nil {
+			return err
+		}
+		fmt.Fprintf(cli.Stdout, "Disabled user %d %s\n", user.ID, user.Email)
+		return nil
+	})
+}
+
+func (cli *CLI) resetUserPassword(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("user reset-password", flag.ContinueOnError)
+	selector := userSelector(fs)
+	if err := cli.parseFlags(fs, args); err != nil {
+		return err
+	}
+	if selector.isEmpty() {
+		return fmt.Errorf("%w: -id or -email is required", ErrInvalidArguments)
+	}
+	password, err := cli.readPassword()
+	if err != nil {
+		return err
+	}
+
+	return cli.withAdmin(ctx, func(admin *adminTools) error {
+		user, err := admin.findUser(ctx, selector)
+		if err != nil {
+			return err
+		}
+		if err := admin.service.SetUserPassword(ctx, nil, cliSource, user.ID, password); err != nil {
+			return err
+		}
+		fmt.Fprintf(cli.Stdout, "Password set for user %d %s\n", user.ID, user.Email)
+		return nil
+	})
+}
+
+func (cli *CLI) listUsers(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("user list", flag.ContinueOnError)
+	query := fs.String("query", "", "Filters by e-mail or username")
+	limit := fs.Int("limit", 50, "Maximum of users listed")
+	offset := fs.Int("offset", 0, "Users skipped")
+	if err := cli.parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	return cli.withAdmin(ctx, func(admin *adminTools) error {
+		users, total, err := admin.service.SearchUsers(ctx, repositories.UserFilter{
+			Query:  *query,
+			Limit:  *limit,
+			Offset: *offset,
+		})
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(cli.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tEMAIL\tUSERNAME\tROLE\tCREATED\tDISABLED")
+		for _, user := range users {
+			disabled := "-"
+			if user.DisabledAt != nil {
+				disabled = user.DisabledAt.UTC().Format(time.RFC3339)
+			}
+			username := user.Profile.Username.String()
+			if username == "" {
+				username = "-"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n",
+				user.ID,
+				user.Email,
+				username,
+				user.Role,
+				user.CreatedAt.UTC().Format(time.RFC3339),
+				disabled,
+			)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintf(cli.Stdout, "%d of %d users\n", len(users), total)
+		return nil
+	})
+}
+
+func (cli *CLI) purgeSessions(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("sessions purge", flag.ContinueOnError)
+	selector := userSelector(fs)
+	if err := cli.parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	return cli.withAdmin(ctx, func(admin *adminTools) error {
+		if selector.isEmpty() {
+			deleted, err := admin.service.SignOutEveryone(ctx, nil, cliSource)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cli.Stdout, "Revoked %d sessions\n", deleted)
+			return nil
+		}
+
+		user, err := admin.findUser(ctx, selector)
+		if err != nil {
+			return err
+		}
+		if err := admin.service.SignOutUser(ctx, nil, cliSource, user.ID); err != nil {
+			return err
+		}
+		fmt.Fprintf(cli.Stdout, "Signed out user %d %s\n", user.ID, user.Email)
+		return nil
+	})
+}
+
+// parseFlags parses the flags of a command, rejecting extra arguments
+func (cli *CLI) parseFlags(fs *flag.FlagSet, args []string) error {
+	fs.SetOutput(cli.Stderr)
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidArguments, err)
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return fmt.Errorf("%w: unexpected %q", ErrInvalidArguments, fs.Args())
+	}
+	return nil
+}
+
+// readPassword reads the password from the first line of Stdin, so it is
+// not kept in the shell history
+func (cli *CLI) readPassword() (entities.RawPassword, error) {
+	scanner := bufio.NewScanner(cli.Stdin)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("%w: the password must be given on stdin", ErrInvalidArguments)
+	}
+	return entities.RawPassword(strings.TrimRight(scanner.Text(), "\r")), nil
+}
+
+// adminTools are the repositories and the admin service used by the
+// commands changing users
+type adminTools struct {
+	repos   *Repositories
+	service services.Admin
+}
+
+// withAdmin connects to the database, failing when the migrations are not
+// applied, and runs fn with the admin tools
+func (cli *CLI) withAdmin(ctx context.Context, fn func(admin *adminTools) error) error {
+	db, err := database.NewConnection(cli.Env.DBConfig)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	// the version is read with the goose dialect, which the server sets
+	// while migrating
+	if err := goose.SetDialect(cli.Env.DBConfig.Dialect()); err != nil {
+		return err
+	}
+	if err := database.CheckVersion(ctx, db, cli.Env.DBConfig.Migrations()); err != nil {
+		return err
+	}
+
+	repos, err := NewRepositories(db, cli.Env.DBConfig, cli.Logger)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := repos.Close(); err != nil {
+			cli.Logger.Error("Failed to close the repositories", "error", err)
+		}
+	}()
+
+	return fn(&adminTools{
+		repos:   repos,
+		service: newAdminService(cli.Env, repos, cli.Logger),
+	})
+}
+
+// findUser possible errors:
+//   - repositories.ErrUserNotFound
+func (admin *adminTools) findUser(ctx context.Context, selector *selector) (*entities.User, error) {
+	if selector.id != 0 {
+		user, err := admin.repos.Users.FindByID(ctx, selector.id)
+		if err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+
+	var email entities.Email
+	email.Set(selector.email)
+	user, err := admin.repos.Users.FindByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// selector identifies an user by the -id or -email flags
+type selector struct {
+	id    uint64
+	email string
+}
+
+func userSelector(fs *flag.FlagSet) *selector {
+	var s selector
+	fs.Uint64Var(&s.id, "id", 0, "ID of the user")
+	fs.StringVar(&s.email, "email", "", "E-mail of the user")
+	return &s
+}
+
+func (s *selector) isEmpty() bool {
+	return s.id == 0 && s.email == ""
+}
+
+// openDB connects to the database and applies the pending migrations
+func openDB(env *EnvConfig) (*sql.DB, error) {
+	db, err := database.NewConnection(env.DBConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := database.MigrateFS(db, env.DBConfig, ""); err != nil {
+		return nil, errors.Join(err, db.Close())
+	}
+	return db, nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twsm000/lenslocked/models/database"
+	"github.com/twsm000/lenslocked/models/repositories"
+	"github.com/twsm000/lenslocked/pkg/settings"
+)
+
+func newTestCLI(t *testing.T) *CLI {
+	t.Helper()
+	env := DefaultEnvConfig()
+	env.CSRF.Key = strings.Repeat("k", 32)
+	env.DBConfig.Driver = database.DriverSQLite
+	env.DBConfig.Path = filepath.Join(t.TempDir(), "lenslocked.db")
+	env.SMTPConfig.Host = "localhost"
+	return &CLI{
+		Env:    &env,
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Stderr: io.Discard,
+	}
+}
+
+// run runs the command with stdin as input and returns its output
+func run(t *testing.T, cli *CLI, stdin string, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	cli.Stdin = strings.NewReader(stdin)
+	cli.Stdout = &out
+	err := cli.Run(context.Background(), args)
+	return out.String(), err
+}
+
+func TestCLIUnknownCommand(t *testing.T) {
+	cli := newTestCLI(t)
+	_, err := run(t, cli, "", "user", "delete")
+	assert.ErrorIs(t, err, ErrUnknownCommand)
+	_, err = run(t, cli, "", "migrate", "sideways")
+	assert.ErrorIs(t, err, ErrInvalidArguments)
+	_, err = run(t, cli, "", "user", "disable")
+	assert.ErrorIs(t, err, ErrInvalidArguments)
+	_, err = run(t, cli, "", "config", "check", "extra")
+	assert.ErrorIs(t, err, ErrInvalidArguments)
+}
+
+func TestCLIConfigCheck(t *testing.T) {
+	cli := newTestCLI(t)
+	out, err := run(t, cli, "", "config", "check")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Settings OK")
+
+	cli.Env.CSRF.Key = "short"
+	cli.Env.Server.Address = "8080"
+	out, err = run(t, cli, "", "config", "check")
+	assert.ErrorIs(t, err, settings.ErrInvalidSettings)
+	assert.Contains(t, out, "csrf.key: invalid CSRF key: 5 bytes, expected 32")
+	assert.Contains(t, out, `server.address: invalid address: "8080"`)
+}
+
+func TestCLIConfigShow(t *testing.T) {
+	cli := newTestCLI(t)
+	out, err := run(t, cli, "", "config", "show")
+	require.NoError(t, err)
+	assert.Contains(t, out, `"driver": "sqlite"`)
+	assert.Contains(t, out, `"key": "[REDACTED]"`)
+	assert.NotContains(t, out, cli.Env.CSRF.Key)
+}
+
+func TestCLIUsers(t *testing.T) {
+	cli := newTestCLI(t)
+
+	_, err := run(t, cli, "", "user", "list")
+	assert.ErrorIs(t, err, database.ErrMigrationsPending)
+
+	out, err := run(t, cli, "", "migrate", "up")
+	require.NoError(t, err)
+	assert.Contains(t, out, "00006")
+
+	out, err = run(t, cli, "s3cret-pass\n", "user", "create", "-email", "admin@example.com", "-admin")
+	require.NoError(t, err)
+	assert.Equal(t, "Created admin 1 admin@example.com\n", out)
+	_, err = run(t, cli, "s3cret-pass\n", "user", "create", "-email", "bob@example.com")
+	require.NoError(t, err)
+	_, err = run(t, cli, "", "user", "create", "-email", "eve@example.com")
+	assert.ErrorIs(t, err, ErrInvalidArguments)
+	_, err = run(t, cli, "s3cret-pass\n", "user", "create", "-email", "bob@example.com")
+	assert.ErrorIs(t, err, repositories.ErrDuplicateUserEmailNotAllowed)
+
+	out, err = run(t, cli, "", "user", "list", "-query", "bob")
+	require.NoError(t, err)
+	assert.Contains(t, out, "bob@example.com")
+	assert.NotContains(t, out, "admin@example.com")
+	assert.Contains(t, out, "1 of 1 users")
+
+	out, err = run(t, cli, "", "user", "disable", "-email", "bob@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "Disabled user 2 bob@example.com\n", out)
+	_, err = run(t, cli, "", "user", "disable", "-id", "99")
+	assert.ErrorIs(t, err, repositories.ErrUserNotFound)
+
+	out, err = run(t, cli, "new-pass\n", "user", "reset-password", "-id", "1")
+	require.NoError(t, err)
+	assert.Equal(t, "Password set for user 1 admin@example.com\n", out)
+
+	out, err = run(t, cli, "", "sessions", "purge")
+	require.NoError(t, err)
+	assert.Equal(t, "Revoked 0 sessions\n", out)
+	out, err = run(t, cli, "", "sessions", "purge", "-id", "1")
+	require.NoError(t, err)
+	assert.Equal(t, "Signed out user 1 admin@example.com\n", out)
+
+	out, err = run(t, cli, "", "migrate", "status")
+	require.NoError(t, err)
+	assert.Contains(t, out, "00001_create_table_users.sql")
+}
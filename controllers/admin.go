@@ -0,0 +1,373 @@
+package controllers
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/twsm000/lenslocked/models/contextutil"
+	"github.com/twsm000/lenslocked/models/entities"
+	"github.com/twsm000/lenslocked/models/httpll"
+	"github.com/twsm000/lenslocked/models/repositories"
+	"github.com/twsm000/lenslocked/models/services"
+	"github.com/twsm000/lenslocked/pkg/result"
+)
+
+const (
+	AdminPath = "/admin"
+
+	adminUsersPerPage       = 25
+	adminAuditEventsPerPage = 50
+	adminRecentAuditEvents  = 10
+)
+
+type AdminUser struct {
+	ID         uint64     `json:"id"`
+	Email      string     `json:"email"`
+	Username   string     `json:"username,omitempty"`
+	Name       string     `json:"name,omitempty"`
+	Role       string     `json:"role"`
+	CreatedAt  time.Time  `json:"created_at"`
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+}
+
+func newAdminUser(user *entities.User) AdminUser {
+	return AdminUser{
+		ID:         user.ID,
+		Email:      user.Email.String(),
+		Username:   user.Profile.Username.String(),
+		Name:       user.Profile.Name(),
+		Role:       string(user.Role),
+		CreatedAt:  user.CreatedAt,
+		DisabledAt: user.DisabledAt,
+	}
+}
+
+type AdminSession struct {
+	ID        uint64     `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+type AdminAuditEvent struct {
+	ID         uint64            `json:"id"`
+	CreatedAt  time.Time         `json:"created_at"`
+	ActorID    *uint64           `json:"actor_id,omitempty"`
+	Action     string            `json:"action"`
+	TargetType string            `json:"target_type,omitempty"`
+	TargetID   *uint64           `json:"target_id,omitempty"`
+	IP         string            `json:"ip,omitempty"`
+	UserAgent  string            `json:"user_agent,omitempty"`
+	RequestID  string            `json:"request_id,omitempty"`
+	Details    map[string]string `json:"details,omitempty"`
+}
+
+func newAdminAuditEvents(events []entities.AuditEvent) []AdminAuditEvent {
+	result := make([]AdminAuditEvent, 0, len(events))
+	for _, event := range events {
+		result = append(result, AdminAuditEvent{
+			ID:         event.ID,
+			CreatedAt:  event.CreatedAt,
+			ActorID:    event.ActorID,
+			Action:     string(event.Action),
+			TargetType: event.TargetType,
+			TargetID:   event.TargetID,
+			IP:         event.Source.IP,
+			UserAgent:  event.Source.UserAgent,
+			RequestID:  event.Source.RequestID,
+			Details:    event.Details,
+		})
+	}
+	return result
+}
+
+// Pagination links the pages of a list, Prev and Next are empty at
+// the first and last pages
+type Pagination struct {
+	Page  int    `json:"page"`
+	Pages int    `json:"pages"`
+	Total int    `json:"total"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+}
+
+// pageParam returns the page query parameter, the first page when invalid
+func pageParam(r *http.Request) int {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		return 1
+	}
+	return page
+}
+
+func newPagination(r *http.Request, page, perPage, total int) Pagination {
+	p := Pagination{
+		Page:  page,
+		Pages: max(1, int(math.Ceil(float64(total)/float64(perPage)))),
+		Total: total,
+	}
+	link := func(page int) string {
+		query := r.URL.Query()
+		query.Set("page", strconv.Itoa(page))
+		return (&url.URL{Path: r.URL.Path, RawQuery: query.Encode()}).String()
+	}
+	if p.Page > 1 {
+		p.Prev = link(min(p.Page-1, p.Pages))
+	}
+	if p.Page < p.Pages {
+		p.Next = link(p.Page + 1)
+	}
+	return p
+}
+
+type AdminDashboardPageData struct {
+	Users                 int               `json:"users"`
+	Admins                int               `json:"admins"`
+	DisabledUsers         int               `json:"disabled_users"`
+	NewUsersLastWeek      int               `json:"new_users_last_week"`
+	Sessions              int               `json:"sessions"`
+	PendingPasswordResets int               `json:"pending_password_resets"`
+	RecentEvents          []AdminAuditEvent `json:"recent_events"`
+}
+
+type AdminUsersPageData struct {
+	Query      string      `json:"query"`
+	Users      []AdminUser `json:"users"`
+	Pagination Pagination  `json:"pagination"`
+}
+
+type AdminUserPageData struct {
+	User     AdminUser      `json:"user"`
+	Sessions []AdminSession `json:"sessions"`
+}
+
+// AdminAuditFilter holds the audit log filters as received in the query
+type AdminAuditFilter struct {
+	Action string `json:"action,omitempty"`
+	UserID string `json:"user,omitempty"`
+	IP     string `json:"ip,omitempty"`
+}
+
+type AdminAuditPageData struct {
+	Filter     AdminAuditFilter  `json:"filter"`
+	Actions    []string          `json:"actions"`
+	Events     []AdminAuditEvent `json:"events"`
+	Pagination Pagination        `json:"pagination"`
+}
+
+type Admin struct {
+	Errors    ErrorRenderer
+	Templates struct {
+		DashboardPage Template[AdminDashboardPageData]
+		UsersPage     Template[AdminUsersPageData]
+		UserPage      Template[AdminUserPageData]
+		AuditPage     Template[AdminAuditPageData]
+	}
+	AdminService services.Admin
+	EmailService *services.EmailService
+}
+
+func (ac *Admin) Dashboard(w http.ResponseWriter, r *http.Request) {
+	stats, err := ac.AdminService.Stats(r.Context())
+	if err != nil {
+		contextutil.Logger(r.Context()).Error("Failed to collect stats", "error", err)
+		ac.Errors.Render(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	events, _, err := ac.AdminService.AuditEvents(r.Context(), repositories.AuditFilter{Limit: adminRecentAuditEvents})
+	if err != nil {
+		contextutil.Logger(r.Context()).Error("Failed to list audit events", "error", err)
+		ac.Errors.Render(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	ac.Templates.DashboardPage.Execute(w, r, AdminDashboardPageData{
+		Users:                 stats.Users,
+		Admins:                stats.Admins,
+		DisabledUsers:         stats.DisabledUsers,
+		NewUsersLastWeek:      stats.NewUsersLastWeek,
+		Sessions:              stats.Sessions,
+		PendingPasswordResets: stats.PendingPasswordResets,
+		RecentEvents:          newAdminAuditEvents(events),
+	})
+}
+
+func (ac *Admin) Users(w http.ResponseWriter, r *http.Request) {
+	page := pageParam(r)
+	filter := repositories.UserFilter{
+		Query:  r.URL.Query().Get("q"),
+		Limit:  adminUsersPerPage,
+		Offset: (page - 1) * adminUsersPerPage,
+	}
+	users, total, err := ac.AdminService.SearchUsers(r.Context(), filter)
+	if err != nil {
+		contextutil.Logger(r.Context()).Error("Failed to search users", "error", err)
+		ac.Errors.Render(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	data := AdminUsersPageData{
+		Query:      filter.Query,
+		Users:      make([]AdminUser, 0, len(users)),
+		Pagination: newPagination(r, page, adminUsersPerPage, total),
+	}
+	for i := range users {
+		data.Users = append(data.Users, newAdminUser(&users[i]))
+	}
+	ac.Templates.UsersPage.Execute(w, r, data)
+}
+
+func (ac *Admin) User(w http.ResponseWriter, r *http.Request) {
+	id, ok := ac.userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	user, sessions, err := ac.AdminService.User(r.Context(), id)
+	if err != nil {
+		ac.handleError(w, r, err)
+		return
+	}
+
+	data := AdminUserPageData{
+		User:     newAdminUser(user),
+		Sessions: make([]AdminSession, 0, len(sessions)),
+	}
+	for _, session := range sessions {
+		data.Sessions = append(data.Sessions, AdminSession{
+			ID:        session.ID,
+			CreatedAt: session.CreatedAt,
+			UpdatedAt: session.UpdatedAt,
+		})
+	}
+	ac.Templates.UserPage.Execute(w, r, data)
+}
+
+func (ac *Admin) SignOutUser(w http.ResponseWriter, r *http.Request) {
+	ac.userAction(w, r, ac.AdminService.SignOutUser, "flash.admin.user_signed_out")
+}
+
+func (ac *Admin) DisableUser(w http.ResponseWriter, r *http.Request) {
+	ac.userAction(w, r, ac.AdminService.DisableUser, "flash.admin.user_disabled")
+}
+
+func (ac *Admin) EnableUser(w http.ResponseWriter, r *http.Request) {
+	ac.userAction(w, r, ac.AdminService.EnableUser, "flash.admin.user_enabled")
+}
+
+func (ac *Admin) ResetUserPassword(w http.ResponseWriter, r *http.Request) {
+	resetPassword := func(ctx context.Context, actor *entities.User, source entities.AuditSource, id uint64) entities.Error {
+		user, pr, err := ac.AdminService.ResetUserPassword(ctx, actor, source, id)
+		if err != nil {
+			return err
+		}
+
+		// the email is read by the user, not by the admin
+		localizer := result.ExtractValue(contextutil.GetLocalizer(ctx)).ForLocale("")
+		if err := ac.EmailService.ForgotPassword(ctx, user.Email.String(), resetPasswordURL(pr), emailLocalizer(user, localizer)); err != nil {
+			return entities.NewError(err)
+		}
+		return nil
+	}
+	ac.userAction(w, r, resetPassword, "flash.admin.password_reset_sent")
+}
+
+func (ac *Admin) AuditLog(w http.ResponseWriter, r *http.Request) {
+	page := pageParam(r)
+	query := r.URL.Query()
+	data := AdminAuditPageData{
+		Filter: AdminAuditFilter{
+			Action: query.Get("action"),
+			UserID: query.Get("user"),
+			IP:     query.Get("ip"),
+		},
+		Actions: make([]string, 0, len(entities.AuditActions)),
+	}
+	for _, action := range entities.AuditActions {
+		data.Actions = append(data.Actions, string(action))
+	}
+
+	filter := repositories.AuditFilter{
+		Action: entities.AuditAction(data.Filter.Action),
+		IP:     data.Filter.IP,
+		Limit:  adminAuditEventsPerPage,
+		Offset: (page - 1) * adminAuditEventsPerPage,
+	}
+	if data.Filter.UserID != "" {
+		userID, err := strconv.ParseUint(data.Filter.UserID, 10, 64)
+		if err != nil {
+			ac.Templates.AuditPage.Execute(w, r, data, entities.NewClientError("error.admin.invalid_user_filter", err))
+			return
+		}
+		filter.UserID = userID
+	}
+
+	events, total, err := ac.AdminService.AuditEvents(r.Context(), filter)
+	if err != nil {
+		contextutil.Logger(r.Context()).Error("Failed to list audit events", "error", err)
+		ac.Errors.Render(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	data.Events = newAdminAuditEvents(events)
+	data.Pagination = newPagination(r, page, adminAuditEventsPerPage, total)
+	ac.Templates.AuditPage.Execute(w, r, data)
+}
+
+// userAction runs the action of the admin in the request context on the
+// user of the id path parameter and redirects back to the user page
+func (ac *Admin) userAction(
+	w http.ResponseWriter,
+	r *http.Request,
+	action func(ctx context.Context, actor *entities.User, source entities.AuditSource, id uint64) entities.Error,
+	successFlash string) {
+	/***************************************************/
+	id, ok := ac.userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	actor, _ := contextutil.GetUser(r.Context())
+	if err := action(r.Context(), actor, httpll.NewAuditSource(r), id); err != nil {
+		if err.IsClientErr() && !err.Is(repositories.ErrUserNotFound) {
+			contextutil.Logger(r.Context()).Info("Admin action refused", "error", err)
+			flashError(w, err.ClientErr())
+			http.Redirect(w, r, adminUserPath(id), http.StatusFound)
+			return
+		}
+		ac.handleError(w, r, err)
+		return
+	}
+
+	contextutil.Logger(r.Context()).Info("Admin action done", "target_id", id)
+	flashSuccess(w, successFlash)
+	http.Redirect(w, r, adminUserPath(id), http.StatusFound)
+}
+
+func (ac *Admin) userIDParam(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		ac.Errors.Render(w, r, http.StatusNotFound)
+		return 0, false
+	}
+	return id, true
+}
+
+func (ac *Admin) handleError(w http.ResponseWriter, r *http.Request, err entities.Error) {
+	if err.Is(repositories.ErrUserNotFound) {
+		ac.Errors.Render(w, r, http.StatusNotFound)
+		return
+	}
+
+	contextutil.Logger(r.Context()).Error("Failed to handle admin request", "error", err)
+	ac.Errors.Render(w, r, http.StatusInternalServerError)
+}
+
+func adminUserPath(id uint64) string {
+	return AdminPath + "/users/" + strconv.FormatUint(id, 10)
+}
@@ -0,0 +1,95 @@
+// Package api serves the JSON API of the users and sessions.
+//
+// TODO: the galleries do not exist in the application yet. Their CRUD, the
+// upload of their images and the pagination of their lists are added here
+// with the gallery services, described in Endpoints like the others.
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/twsm000/lenslocked/models/contextutil"
+	"github.com/twsm000/lenslocked/models/services"
+)
+
+const (
+	// Prefix is the path where the current version of the API is mounted
+	Prefix = "/api/v1"
+)
+
+// API serves the JSON endpoints sharing the same services used by the
+// HTML controllers. Clients authenticate with the session token returned
+// on sign in, sent in the Authorization header as a Bearer token.
+type API struct {
+	UserService    services.User
+	SessionService services.Session
+	AuditLogger    services.AuditLogger
+	// MaxBodyBytes limits the JSON bodies, DefaultMaxBodyBytes when zero.
+	// The API is not mounted under the body limit of the pages, which
+	// answers with HTML.
+	MaxBodyBytes int64
+}
+
+// NewRouter returns the router with every API endpoint registered, to be
+// mounted at Prefix.
+func NewRouter(a *API) chi.Router {
+	router := chi.NewRouter()
+	router.Use(a.SetUserFromBearerToken)
+	router.NotFound(a.statusHandler(http.StatusNotFound))
+	router.MethodNotAllowed(a.statusHandler(http.StatusMethodNotAllowed))
+
+	router.Post("/users", a.CreateUser)
+	router.Post("/sessions", a.CreateSession)
+	router.Group(func(r chi.Router) {
+		r.Use(a.RequireUser)
+		r.Get("/users/me", a.CurrentUser)
+		r.Delete("/sessions/current", a.DeleteCurrentSession)
+	})
+
+	return router
+}
+
+// bearerToken extracts the token from the Authorization header
+func bearerToken(r *http.Request) (string, bool) {
+	const scheme = "bearer "
+	auth := r.Header.Get("Authorization")
+	if len(auth) <= len(scheme) || !strings.EqualFold(auth[:len(scheme)], scheme) {
+		return "", false
+	}
+	return strings.TrimSpace(auth[len(scheme):]), true
+}
+
+func (a *API) SetUserFromBearerToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := bearerToken(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user, err := a.SessionService.FindUserByToken(r.Context(), token)
+		if err != nil {
+			contextutil.Logger(r.Context()).Warn("Invalid bearer token", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := contextutil.WithUser(r.Context(), user)
+		ctx = contextutil.WithLogger(ctx, contextutil.Logger(ctx).With("user_id", user.ID))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (a *API) RequireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := contextutil.GetUser(r.Context()); !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="lenslocked"`)
+			a.writeError(w, r, NewStatusError(http.StatusUnauthorized))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/twsm000/lenslocked/models/contextutil"
+	"github.com/twsm000/lenslocked/models/entities"
+	"github.com/twsm000/lenslocked/models/httpll"
+	"github.com/twsm000/lenslocked/models/repositories"
+	"github.com/twsm000/lenslocked/models/services"
+	"github.com/twsm000/lenslocked/pkg/result"
+)
+
+const (
+	CodeBadRequest          = "bad_request"
+	CodeInvalidJSON         = "invalid_json"
+	CodeValidation          = "validation_failed"
+	CodeConflict            = "conflict"
+	CodeUnauthorized        = "unauthorized"
+	CodeInvalidCredentials  = "invalid_credentials"
+	CodeForbidden           = "forbidden"
+	CodeNotFound            = "not_found"
+	CodeMethodNotAllowed    = "method_not_allowed"
+	CodeRequestTooLarge     = "request_too_large"
+	CodeInternalServerError = "internal_server_error"
+)
+
+// Error is the body of every failed response, wrapped by ErrorResponse
+type Error struct {
+	Status    int               `json:"status"`
+	Code      string            `json:"code"`
+	Message   string            `json:"message"`
+	Fields    map[string]string `json:"fields,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+}
+
+type ErrorResponse struct {
+	Error Error `json:"error"`
+}
+
+// NewError maps the domain errors to the client representation.
+// Only messages from client errors are exposed, any other error
+// results in an internal server error.
+func NewError(err error) Error {
+	var entityErr entities.Error
+	if !errors.As(err, &entityErr) {
+		entityErr = entities.NewError(err)
+	}
+
+	apiErr := Error{
+		Status:  http.StatusBadRequest,
+		Code:    CodeBadRequest,
+		Message: entityErr.ClientErr(),
+	}
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case entityErr.As(&maxBytesErr):
+		apiErr.Status = http.StatusRequestEntityTooLarge
+		apiErr.Code = CodeRequestTooLarge
+		apiErr.Message = "error.request_too_large.message"
+	case entityErr.Is(ErrInvalidJSONBody):
+		apiErr.Code = CodeInvalidJSON
+		apiErr.Message = "error.api.invalid_json"
+	case entityErr.Is(services.ErrInvalidAuthCredentials):
+		apiErr.Status = http.StatusUnauthorized
+		apiErr.Code = CodeInvalidCredentials
+		// the nested errors tell if the e-mail has an account
+		apiErr.Message = "error.auth.invalid_credentials"
+	case entityErr.Is(services.ErrAccountDisabled):
+		apiErr.Status = http.StatusForbidden
+		apiErr.Code = CodeForbidden
+	case entityErr.Is(repositories.ErrDuplicateUserEmailNotAllowed):
+		apiErr.Status = http.StatusConflict
+		apiErr.Code = CodeConflict
+		apiErr.Fields = map[string]string{"email": apiErr.Message}
+	case entityErr.Is(entities.ErrInvalidUserEmail):
+		apiErr.Status = http.StatusUnprocessableEntity
+		apiErr.Code = CodeValidation
+		apiErr.Fields = map[string]string{"email": apiErr.Message}
+	case entityErr.Is(entities.ErrInvalidPassword):
+		apiErr.Status = http.StatusUnprocessableEntity
+		apiErr.Code = CodeValidation
+		apiErr.Fields = map[string]string{"password": apiErr.Message}
+	case entityErr.Is(repositories.ErrUserNotFound):
+		apiErr.Status = http.StatusNotFound
+		apiErr.Code = CodeNotFound
+	case !entityErr.IsClientErr():
+		return NewStatusError(http.StatusInternalServerError)
+	}
+
+	if apiErr.Message == "" {
+		_, apiErr.Message, _ = httpll.StatusMessageKeys(apiErr.Status)
+	}
+	return apiErr
+}
+
+// NewStatusError returns an Error with the message key of the status code,
+// translated by writeError
+func NewStatusError(status int) Error {
+	code := CodeBadRequest
+	switch status {
+	case http.StatusUnauthorized:
+		code = CodeUnauthorized
+	case http.StatusNotFound:
+		code = CodeNotFound
+	case http.StatusMethodNotAllowed:
+		code = CodeMethodNotAllowed
+	case http.StatusInternalServerError:
+		code = CodeInternalServerError
+	}
+
+	_, message, _ := httpll.StatusMessageKeys(status)
+	return Error{
+		Status:  status,
+		Code:    code,
+		Message: message,
+	}
+}
+
+// writeError translates the messages, which are message keys, to the
+// client locale and writes the error
+func (a *API) writeError(w http.ResponseWriter, r *http.Request, apiErr Error) {
+	localizer := result.ExtractValue(contextutil.GetLocalizer(r.Context()))
+	apiErr.Message = localizer.TLines(apiErr.Message)
+	if apiErr.Fields != nil {
+		fields := make(map[string]string, len(apiErr.Fields))
+		for field, message := range apiErr.Fields {
+			fields[field] = localizer.TLines(message)
+		}
+		apiErr.Fields = fields
+	}
+	apiErr.RequestID = middleware.GetReqID(r.Context())
+	a.writeJSON(w, r, apiErr.Status, ErrorResponse{Error: apiErr})
+}
+
+// handleError logs the error and writes its client representation
+func (a *API) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	contextutil.Logger(r.Context()).Error("API request failed", "error", err)
+	a.writeError(w, r, NewError(err))
+}
+
+func (a *API) statusHandler(status int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a.writeError(w, r, NewStatusError(status))
+	}
+}
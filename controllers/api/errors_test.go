@@ -0,0 +1,136 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twsm000/lenslocked/locales"
+	"github.com/twsm000/lenslocked/models/contextutil"
+	"github.com/twsm000/lenslocked/models/entities"
+	"github.com/twsm000/lenslocked/models/repositories"
+	"github.com/twsm000/lenslocked/models/services"
+	"github.com/twsm000/lenslocked/pkg/i18n"
+)
+
+func TestNewError(t *testing.T) {
+	testCases := []struct {
+		desc    string
+		err     error
+		status  int
+		code    string
+		message string
+		fields  map[string]string
+	}{
+		{
+			desc:    "InvalidJSONBody",
+			err:     fmt.Errorf("%w: %w", ErrInvalidJSONBody, errors.New("unexpected EOF")),
+			status:  http.StatusBadRequest,
+			code:    CodeInvalidJSON,
+			message: "error.api.invalid_json",
+		},
+		{
+			desc:    "BodyTooLarge",
+			err:     fmt.Errorf("%w: %w", ErrInvalidJSONBody, &http.MaxBytesError{Limit: DefaultMaxBodyBytes}),
+			status:  http.StatusRequestEntityTooLarge,
+			code:    CodeRequestTooLarge,
+			message: "error.request_too_large.message",
+		},
+		{
+			desc: "InvalidCredentials",
+			err: entities.NewClientError("error.auth.invalid_credentials", services.ErrInvalidAuthCredentials,
+				entities.NewClientError("error.user.email_not_found", repositories.ErrUserNotFound)),
+			status:  http.StatusUnauthorized,
+			code:    CodeInvalidCredentials,
+			message: "error.auth.invalid_credentials",
+		},
+		{
+			desc:    "AccountDisabled",
+			err:     entities.NewClientError("error.auth.account_disabled", services.ErrAccountDisabled),
+			status:  http.StatusForbidden,
+			code:    CodeForbidden,
+			message: "error.auth.account_disabled",
+		},
+		{
+			desc:    "DuplicateEmail",
+			err:     entities.NewClientError("Email taken", repositories.ErrFailedToCreateUser, repositories.ErrDuplicateUserEmailNotAllowed),
+			status:  http.StatusConflict,
+			code:    CodeConflict,
+			message: "Email taken",
+			fields:  map[string]string{"email": "Email taken"},
+		},
+		{
+			desc:    "InvalidEmail",
+			err:     entities.NewClientError("Email cannot be empty", entities.ErrInvalidUserEmail),
+			status:  http.StatusUnprocessableEntity,
+			code:    CodeValidation,
+			message: "Email cannot be empty",
+			fields:  map[string]string{"email": "Email cannot be empty"},
+		},
+		{
+			desc:    "UnknownClientError",
+			err:     entities.NewClientError("Something is wrong", errors.New("error")),
+			status:  http.StatusBadRequest,
+			code:    CodeBadRequest,
+			message: "Something is wrong",
+		},
+		{
+			desc:    "InternalErrorIsNotExposed",
+			err:     entities.NewError(repositories.ErrFailedToCreateSession, errors.New("connection refused")),
+			status:  http.StatusInternalServerError,
+			code:    CodeInternalServerError,
+			message: "error.internal_server_error.message",
+		},
+		{
+			desc:    "PlainError",
+			err:     errors.New("connection refused"),
+			status:  http.StatusInternalServerError,
+			code:    CodeInternalServerError,
+			message: "error.internal_server_error.message",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			apiErr := NewError(tC.err)
+			assert.Equal(t, tC.status, apiErr.Status)
+			assert.Equal(t, tC.code, apiErr.Code)
+			assert.Equal(t, tC.message, apiErr.Message)
+			assert.Equal(t, tC.fields, apiErr.Fields)
+		})
+	}
+}
+
+func TestNewStatusErrorIsTranslated(t *testing.T) {
+	bundle, err := i18n.LoadFS(locales.FS, locales.Fallback)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/me", nil)
+	req = req.WithContext(contextutil.WithLocalizer(req.Context(), bundle.Localizer("pt-BR")))
+	rec := httptest.NewRecorder()
+	(&API{}).writeError(rec, req, NewStatusError(http.StatusUnauthorized))
+
+	var body ErrorResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, CodeUnauthorized, body.Error.Code)
+	assert.Equal(t, "Entre na sua conta para continuar.", body.Error.Message)
+}
+
+func TestBodyOverMaxBodyBytes(t *testing.T) {
+	router := NewRouter(&API{MaxBodyBytes: 16})
+	body := `{"email": "alice@example.com", "password": "secret-password"}`
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var response ErrorResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Equal(t, CodeRequestTooLarge, response.Error.Code)
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/twsm000/lenslocked/models/contextutil"
+)
+
+const (
+	// DefaultMaxBodyBytes is the maximum amount of bytes accepted on JSON
+	// bodies when API.MaxBodyBytes is not set
+	DefaultMaxBodyBytes int64 = 1 << 20
+)
+
+var (
+	ErrInvalidJSONBody = errors.New("invalid json body")
+)
+
+// Response is the envelope of every successful response
+type Response[T any] struct {
+	Data T `json:"data"`
+}
+
+// decodeJSON possible errors:
+//   - ErrInvalidJSONBody
+func (a *API) decodeJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	limit := a.MaxBodyBytes
+	if limit <= 0 {
+		limit = DefaultMaxBodyBytes
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidJSONBody, err)
+	}
+	return nil
+}
+
+func (a *API) writeJSON(w http.ResponseWriter, r *http.Request, status int, data any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		contextutil.Logger(r.Context()).Error("Failed to send data to ResponseWriter", "error", err)
+	}
+}
+
+func (a *API) writeData(w http.ResponseWriter, r *http.Request, status int, data any) {
+	a.writeJSON(w, r, status, Response[any]{Data: data})
+}
@@ -0,0 +1,316 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/twsm000/lenslocked/models/contextutil"
+)
+
+const (
+	// SpecPath is where the OpenAPI document is served
+	SpecPath = "/api/openapi.json"
+
+	bearerAuthScheme = "bearerAuth"
+)
+
+// Endpoint describes an API route. The OpenAPI document is generated from
+// these descriptions and the Go types used by the handlers, and the tests
+// ensure every route registered by NewRouter is described here.
+type Endpoint struct {
+	Method      string
+	Path        string
+	Summary     string
+	Tag         string
+	RequireUser bool
+	Request     any
+	Status      int
+	Response    any
+	Errors      []int
+}
+
+var Endpoints = []Endpoint{
+	{
+		Method:   http.MethodPost,
+		Path:     "/users",
+		Summary:  "Create an user account and sign in",
+		Tag:      "users",
+		Request:  CredentialsRequest{},
+		Status:   http.StatusCreated,
+		Response: SessionResponse{},
+		Errors:   []int{http.StatusBadRequest, http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity},
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/users/me",
+		Summary:     "Get the authenticated user",
+		Tag:         "users",
+		RequireUser: true,
+		Status:      http.StatusOK,
+		Response:    UserResponse{},
+	},
+	{
+		Method:   http.MethodPost,
+		Path:     "/sessions",
+		Summary:  "Sign in and return a session token",
+		Tag:      "sessions",
+		Request:  CredentialsRequest{},
+		Status:   http.StatusCreated,
+		Response: SessionResponse{},
+		Errors:   []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusRequestEntityTooLarge},
+	},
+	{
+		Method:      http.MethodDelete,
+		Path:        "/sessions/current",
+		Summary:     "Sign out, revoking the current session token",
+		Tag:         "sessions",
+		RequireUser: true,
+		Status:      http.StatusNoContent,
+	},
+}
+
+// OpenAPI is the subset of the OpenAPI 3 document used by the API
+type OpenAPI struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Servers    []Server                        `json:"servers"`
+	Paths      map[string]map[string]Operation `json:"paths"`
+	Components Components                      `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+type Operation struct {
+	OperationID string                  `json:"operationId"`
+	Summary     string                  `json:"summary"`
+	Tags        []string                `json:"tags,omitempty"`
+	Security    []map[string][]string   `json:"security,omitempty"`
+	RequestBody *RequestBody            `json:"requestBody,omitempty"`
+	Responses   map[string]ResponseSpec `json:"responses"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type ResponseSpec struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+var (
+	specOnce sync.Once
+	specData []byte
+	specErr  error
+)
+
+// SpecHandler serves the OpenAPI document
+func (a *API) SpecHandler(w http.ResponseWriter, r *http.Request) {
+	specOnce.Do(func() {
+		specData, specErr = json.MarshalIndent(NewSpec(), "", "  ")
+	})
+	if specErr != nil {
+		a.handleError(w, r, specErr)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if _, err := w.Write(specData); err != nil {
+		contextutil.Logger(r.Context()).Error("Failed to send data to ResponseWriter", "error", err)
+	}
+}
+
+// NewSpec generates the OpenAPI document from the Endpoints
+func NewSpec() *OpenAPI {
+	gen := schemaGenerator{schemas: make(map[string]*Schema)}
+	errorSchema := gen.schemaOf(reflect.TypeOf(ErrorResponse{}))
+	spec := OpenAPI{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:   "Lenslocked API",
+			Version: "v1",
+		},
+		Servers: []Server{{URL: Prefix}},
+		Paths:   make(map[string]map[string]Operation),
+		Components: Components{
+			Schemas: gen.schemas,
+			SecuritySchemes: map[string]SecurityScheme{
+				bearerAuthScheme: {Type: "http", Scheme: "bearer"},
+			},
+		},
+	}
+
+	for _, endpoint := range Endpoints {
+		op := Operation{
+			OperationID: operationID(endpoint.Method, endpoint.Path),
+			Summary:     endpoint.Summary,
+			Responses:   make(map[string]ResponseSpec),
+		}
+		if endpoint.Tag != "" {
+			op.Tags = []string{endpoint.Tag}
+		}
+		if endpoint.RequireUser {
+			op.Security = []map[string][]string{{bearerAuthScheme: {}}}
+		}
+		if endpoint.Request != nil {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  jsonContent(gen.schemaOf(reflect.TypeOf(endpoint.Request))),
+			}
+		}
+
+		success := ResponseSpec{Description: http.StatusText(endpoint.Status)}
+		if endpoint.Response != nil {
+			success.Content = jsonContent(&Schema{
+				Type:       "object",
+				Properties: map[string]*Schema{"data": gen.schemaOf(reflect.TypeOf(endpoint.Response))},
+				Required:   []string{"data"},
+			})
+		}
+		op.Responses[strconv.Itoa(endpoint.Status)] = success
+
+		errorStatus := endpoint.Errors
+		if endpoint.RequireUser {
+			errorStatus = append(errorStatus, http.StatusUnauthorized)
+		}
+		errorStatus = append(errorStatus, http.StatusInternalServerError)
+		for _, status := range errorStatus {
+			op.Responses[strconv.Itoa(status)] = ResponseSpec{
+				Description: http.StatusText(status),
+				Content:     jsonContent(errorSchema),
+			}
+		}
+
+		if spec.Paths[endpoint.Path] == nil {
+			spec.Paths[endpoint.Path] = make(map[string]Operation)
+		}
+		spec.Paths[endpoint.Path][strings.ToLower(endpoint.Method)] = op
+	}
+
+	return &spec
+}
+
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: schema}}
+}
+
+// operationID converts "POST /sessions/current" into "postSessionsCurrent"
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '-' || r == '_'
+	}) {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// schemaGenerator converts Go types into schemas, registering the named
+// structs as components.
+type schemaGenerator struct {
+	schemas map[string]*Schema
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (g schemaGenerator) schemaOf(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Pointer:
+		schema := *g.schemaOf(t.Elem())
+		schema.Nullable = true
+		return &schema
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		return g.structSchema(t)
+	}
+	panic(fmt.Sprintf("openapi: unsupported type %s", t))
+}
+
+func (g schemaGenerator) structSchema(t reflect.Type) *Schema {
+	ref := &Schema{Ref: "#/components/schemas/" + t.Name()}
+	if _, ok := g.schemas[t.Name()]; ok {
+		return ref
+	}
+
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.schemas[t.Name()] = schema
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = g.schemaOf(field.Type)
+		if !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Pointer {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return ref
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// registeredRoutes returns "METHOD /path" for every route of NewRouter
+func registeredRoutes(t *testing.T) map[string]bool {
+	routes := make(map[string]bool)
+	err := chi.Walk(NewRouter(&API{}), func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if route != "/" {
+			route = strings.TrimSuffix(route, "/")
+		}
+		routes[method+" "+route] = true
+		return nil
+	})
+	require.NoError(t, err)
+	return routes
+}
+
+func TestSpecDescribesEveryRegisteredRoute(t *testing.T) {
+	spec := NewSpec()
+	for route := range registeredRoutes(t) {
+		method, path, _ := strings.Cut(route, " ")
+		_, ok := spec.Paths[path][strings.ToLower(method)]
+		assert.Truef(t, ok, "route %q is registered but missing from the OpenAPI spec", route)
+	}
+}
+
+func TestSpecOnlyDescribesRegisteredRoutes(t *testing.T) {
+	routes := registeredRoutes(t)
+	for _, endpoint := range Endpoints {
+		route := endpoint.Method + " " + endpoint.Path
+		assert.Truef(t, routes[route], "route %q is in the OpenAPI spec but not registered", route)
+	}
+}
+
+func TestSpecReferencesExistingSchemas(t *testing.T) {
+	spec := NewSpec()
+	data, err := json.Marshal(spec)
+	require.NoError(t, err)
+
+	var refs []string
+	var collect func(v any)
+	collect = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for key, value := range v {
+				if ref, ok := value.(string); ok && key == "$ref" {
+					refs = append(refs, ref)
+				}
+				collect(value)
+			}
+		case []any:
+			for _, value := range v {
+				collect(value)
+			}
+		}
+	}
+	var doc any
+	require.NoError(t, json.Unmarshal(data, &doc))
+	collect(doc)
+
+	require.NotEmpty(t, refs)
+	for _, ref := range refs {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		assert.Containsf(t, spec.Components.Schemas, name, "schema %q is referenced but not defined", ref)
+	}
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/twsm000/lenslocked/models/contextutil"
+	"github.com/twsm000/lenslocked/models/entities"
+	"github.com/twsm000/lenslocked/models/httpll"
+)
+
+type CredentialsRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type UserResponse struct {
+	ID        uint64     `json:"id"`
+	Email     string     `json:"email"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+func NewUserResponse(user *entities.User) UserResponse {
+	return UserResponse{
+		ID:        user.ID,
+		Email:     user.Email.String(),
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+}
+
+type SessionResponse struct {
+	Token string       `json:"token"`
+	User  UserResponse `json:"user"`
+}
+
+func (a *API) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var input CredentialsRequest
+	if err := a.decodeJSON(w, r, &input); err != nil {
+		a.handleError(w, r, err)
+		return
+	}
+
+	var userInput entities.UserCreatable
+	userInput.Email.Set(input.Email)
+	userInput.Password.Set(input.Password)
+	user, err := a.UserService.Create(r.Context(), userInput)
+	if err != nil {
+		a.handleError(w, r, err)
+		return
+	}
+
+	contextutil.Logger(r.Context()).Info("User created", "user", user)
+	a.createSession(w, r, user)
+}
+
+func (a *API) CreateSession(w http.ResponseWriter, r *http.Request) {
+	var input CredentialsRequest
+	if err := a.decodeJSON(w, r, &input); err != nil {
+		a.handleError(w, r, err)
+		return
+	}
+
+	var credentials entities.UserAuthenticable
+	credentials.Email.Set(input.Email)
+	credentials.Password.Set(input.Password)
+	credentials.Source = httpll.NewAuditSource(r)
+	user, err := a.UserService.Authenticate(r.Context(), credentials)
+	if err != nil {
+		a.handleError(w, r, err)
+		return
+	}
+
+	contextutil.Logger(r.Context()).Info("User authenticated", "user", user)
+	a.createSession(w, r, user)
+}
+
+func (a *API) createSession(w http.ResponseWriter, r *http.Request, user *entities.User) {
+	session, err := a.SessionService.Create(r.Context(), user.ID)
+	if err != nil {
+		a.handleError(w, r, err)
+		return
+	}
+
+	contextutil.Logger(r.Context()).Info("Session created", "session", session)
+	a.writeData(w, r, http.StatusCreated, SessionResponse{
+		Token: session.Token.Value(),
+		User:  NewUserResponse(user),
+	})
+}
+
+func (a *API) CurrentUser(w http.ResponseWriter, r *http.Request) {
+	user, _ := contextutil.GetUser(r.Context())
+	a.writeData(w, r, http.StatusOK, NewUserResponse(user))
+}
+
+func (a *API) DeleteCurrentSession(w http.ResponseWriter, r *http.Request) {
+	token, _ := bearerToken(r)
+	if err := a.SessionService.DeleteByToken(r.Context(), token); err != nil {
+		a.handleError(w, r, err)
+		return
+	}
+
+	user, _ := contextutil.GetUser(r.Context())
+	a.AuditLogger.TryRecord(r.Context(), entities.NewUserAuditEvent(user, httpll.NewAuditSource(r), entities.AuditSignedOut, user.ID))
+
+	w.WriteHeader(http.StatusNoContent)
+}
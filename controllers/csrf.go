@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/securecookie"
+)
+
+const (
+	// CookieCSRF is the cookie holding the signed CSRF token, the default
+	// of gorilla/csrf
+	CookieCSRF = "_gorilla_csrf"
+	// CSRFMaxAge is the amount of seconds a CSRF cookie lives, the default
+	// of gorilla/csrf
+	CSRFMaxAge = 12 * 60 * 60
+)
+
+// newCSRFCodec returns the codec gorilla/csrf signs its cookie with
+func newCSRFCodec(key []byte) *securecookie.SecureCookie {
+	codec := securecookie.New(key, nil)
+	codec.SetSerializer(securecookie.JSONEncoder{})
+	codec.MaxAge(CSRFMaxAge)
+	return codec
+}
+
+// RotateCSRFKeys accepts the CSRF cookies signed by the previous keys,
+// after a key rotation, by signing them again with the current key, the
+// first one, before gorilla/csrf reads them. Without previous keys, it
+// does nothing.
+func RotateCSRFKeys(keys ...[]byte) func(http.Handler) http.Handler {
+	if len(keys) < 2 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	current := newCSRFCodec(keys[0])
+	previous := make([]securecookie.Codec, 0, len(keys)-1)
+	for _, key := range keys[1:] {
+		previous = append(previous, newCSRFCodec(key))
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cookie, err := r.Cookie(CookieCSRF)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var token []byte
+			if current.Decode(CookieCSRF, cookie.Value, &token) == nil ||
+				securecookie.DecodeMulti(CookieCSRF, cookie.Value, &token, previous...) != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			value, err := current.Encode(CookieCSRF, token)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			r = r.Clone(r.Context())
+			cookies := r.Cookies()
+			r.Header.Del("Cookie")
+			for _, c := range cookies {
+				if c.Name == CookieCSRF {
+					c.Value = value
+				}
+				r.AddCookie(c)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
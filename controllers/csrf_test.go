@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/csrf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// protect serves a form on GET and accepts it on POST behind the CSRF
+// protection signed by the keys
+func protect(keys ...[]byte) http.Handler {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(csrf.Token(r)))
+	})
+	protected := csrf.Protect(keys[0], csrf.CookieName(CookieCSRF), csrf.MaxAge(CSRFMaxAge))(handler)
+	return RotateCSRFKeys(keys...)(protected)
+}
+
+func TestRotateCSRFKeys(t *testing.T) {
+	oldKey := bytes.Repeat([]byte("o"), 32)
+	newKey := bytes.Repeat([]byte("n"), 32)
+
+	// a form rendered before the rotation
+	rec := httptest.NewRecorder()
+	protect(oldKey).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	token := rec.Body.String()
+
+	post := func(handler http.Handler) int {
+		form := url.Values{"gorilla.csrf.Token": {token}}
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.AddCookie(&http.Cookie{Name: "other", Value: "kept"})
+		req.AddCookie(cookies[0])
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, post(protect(oldKey)))
+	assert.Equal(t, http.StatusForbidden, post(protect(newKey)), "rejected without the previous key")
+	assert.Equal(t, http.StatusOK, post(protect(newKey, oldKey)))
+	assert.Equal(t, http.StatusForbidden, post(protect(newKey, bytes.Repeat([]byte("x"), 32))))
+}
@@ -1,7 +1,44 @@
 package controllers
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/twsm000/lenslocked/models/contextutil"
+)
 
 var (
 	ErrUntracked = errors.New("untracked error")
 )
+
+type ErrorMiddleware struct {
+	Errors ErrorRenderer
+}
+
+// Recover renders the internal server error page when the next handler
+// panics before writing the response. A response already started is left
+// as is, since its status was sent.
+func (em ErrorMiddleware) Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		defer func() {
+			rvr := recover()
+			if rvr == nil {
+				return
+			}
+			if rvr == http.ErrAbortHandler {
+				// let the server abort the response silently
+				panic(rvr)
+			}
+
+			contextutil.Logger(r.Context()).Error("Panic", "panic", rvr, "stack", string(debug.Stack()))
+			if r.Header.Get("Connection") != "Upgrade" && ww.Status() == 0 {
+				em.Errors.Render(w, r, http.StatusInternalServerError)
+			}
+		}()
+
+		next.ServeHTTP(ww, r)
+	})
+}
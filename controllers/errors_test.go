@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecover(t *testing.T) {
+	em := ErrorMiddleware{Errors: statusRenderer{}}
+
+	testCases := []struct {
+		desc    string
+		handler http.HandlerFunc
+		status  int
+		body    string
+	}{
+		{
+			desc:    "nothing written",
+			handler: func(w http.ResponseWriter, r *http.Request) { panic("boom") },
+			status:  http.StatusInternalServerError,
+		},
+		{
+			desc: "header written",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+				panic("boom")
+			},
+			status: http.StatusAccepted,
+		},
+		{
+			desc: "body written",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				io.WriteString(w, "partial")
+				panic("boom")
+			},
+			status: http.StatusOK,
+			body:   "partial",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			em.Recover(tC.handler).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, tC.status, rec.Code)
+			assert.Equal(t, tC.body, rec.Body.String())
+		})
+	}
+}
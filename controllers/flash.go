@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/twsm000/lenslocked/models/contextutil"
+	"github.com/twsm000/lenslocked/models/httpll"
+)
+
+const (
+	CookieFlash = "flash"
+
+	// flashMaxAge is the amount of seconds a flash cookie lives if it is never read
+	flashMaxAge = 60
+)
+
+// setFlash stores the flashes to be displayed by the next rendered page.
+// The messages are message keys, translated when the page is rendered.
+func setFlash(w http.ResponseWriter, flashes ...httpll.Flash) {
+	value, err := httpll.EncodeFlashes(flashes)
+	if err != nil {
+		return
+	}
+	cookie := createCookie(CookieFlash, value)
+	cookie.MaxAge = flashMaxAge
+	http.SetCookie(w, cookie)
+}
+
+func flashSuccess(w http.ResponseWriter, message string) {
+	setFlash(w, httpll.Flash{Level: httpll.FlashSuccess, Message: message})
+}
+
+func flashInfo(w http.ResponseWriter, message string) {
+	setFlash(w, httpll.Flash{Level: httpll.FlashInfo, Message: message})
+}
+
+func flashError(w http.ResponseWriter, message string) {
+	setFlash(w, httpll.Flash{Level: httpll.FlashError, Message: message})
+}
+
+type FlashMiddleware struct{}
+
+// ReadFlashes moves the flash messages from the cookie to the request context
+// and clears the cookie so they are displayed only once.
+func (fm FlashMiddleware) ReadFlashes(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(CookieFlash)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		http.SetCookie(w, deleteCookie(CookieFlash))
+		flashes, err := httpll.DecodeFlashes(cookie.Value)
+		if err != nil {
+			contextutil.Logger(r.Context()).Warn("Invalid flash cookie", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(contextutil.WithFlashes(r.Context(), flashes)))
+	})
+}
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/twsm000/lenslocked/models/contextutil"
+	"github.com/twsm000/lenslocked/models/httpll"
+)
+
+const (
+	// formMaxMemory is the amount of bytes of a multipart form kept in
+	// memory, the remaining of its files is stored in temporary files
+	formMaxMemory = 1 << 20
+)
+
+// BodyLimitMiddleware limits the size of the request bodies, so a client
+// cannot exhaust the memory or the disk with a huge form
+type BodyLimitMiddleware struct {
+	Errors ErrorRenderer
+	// MaxBytes is the limit of every request body
+	MaxBytes int64
+	// Paths are the limits of the bodies sent to these paths instead of
+	// MaxBytes, like the file uploads. They are matched against the routed
+	// path, so "/path.json" has the limit of "/path".
+	Paths map[string]int64
+}
+
+// LimitBody answers 413 Request Entity Too Large when the body is over the
+// limit. The forms are parsed here, so a body sent without a length and
+// found over the limit while reading it is told apart from an invalid
+// form, like the one without the CSRF token. It must run after JSONSuffix
+// and before anything parsing the forms, like the CSRF protection.
+func (bm BodyLimitMiddleware) LimitBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := bm.MaxBytes
+		if pathLimit, ok := bm.Paths[httpll.RoutePath(r)]; ok {
+			limit = pathLimit
+		}
+
+		if r.ContentLength > limit {
+			bm.tooLarge(w, r, limit)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		// the other errors are left to the handlers, which parse the form
+		// again and get them. ParseMultipartForm alone hides the errors of
+		// the urlencoded forms.
+		err := r.ParseForm()
+		if err == nil {
+			err = r.ParseMultipartForm(formMaxMemory)
+		}
+		if IsBodyTooLarge(err) {
+			bm.tooLarge(w, r, limit)
+			return
+		}
+		if r.MultipartForm != nil {
+			defer r.MultipartForm.RemoveAll()
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (bm BodyLimitMiddleware) tooLarge(w http.ResponseWriter, r *http.Request, limit int64) {
+	contextutil.Logger(r.Context()).Warn("Request body too large",
+		"content_length", r.ContentLength, "limit", limit)
+	// the body is not read to the end, so the connection is not reused
+	w.Header().Set("Connection", "close")
+	bm.Errors.Render(w, r, http.StatusRequestEntityTooLarge)
+}
+
+// IsBodyTooLarge reports if err comes from reading a request body over the
+// limit set by the BodyLimitMiddleware
+func IsBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/twsm000/lenslocked/models/httpll"
+)
+
+type statusRenderer struct{}
+
+func (statusRenderer) Render(w http.ResponseWriter, r *http.Request, status int) {
+	w.WriteHeader(status)
+}
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	limits := BodyLimitMiddleware{
+		Errors:   statusRenderer{},
+		MaxBytes: 8,
+		Paths:    map[string]int64{"/upload": 16},
+	}
+	handler := limits.LimitBody(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			assert.True(t, IsBodyTooLarge(err))
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+		}
+	}))
+
+	for _, tc := range []struct {
+		path   string
+		size   int
+		length bool
+		want   int
+	}{
+		{"/signin", 8, true, http.StatusOK},
+		{"/signin", 9, true, http.StatusRequestEntityTooLarge},
+		{"/signin", 9, false, http.StatusRequestEntityTooLarge},
+		{"/upload", 16, true, http.StatusOK},
+		{"/upload", 17, true, http.StatusRequestEntityTooLarge},
+		{"/upload", 17, false, http.StatusRequestEntityTooLarge},
+	} {
+		req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(strings.Repeat("a", tc.size)))
+		if !tc.length {
+			// sent chunked, the size is known only while reading it
+			req.ContentLength = -1
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, tc.want, rec.Code, "%s %d bytes", tc.path, tc.size)
+	}
+}
+
+func TestBodyLimitMiddlewareForms(t *testing.T) {
+	limits := BodyLimitMiddleware{
+		Errors:   statusRenderer{},
+		MaxBytes: 16,
+		Paths:    map[string]int64{"/upload": 64},
+	}
+	router := chi.NewRouter()
+	router.Use(httpll.JSONSuffix)
+	router.Use(limits.LimitBody)
+	// like the CSRF protection, which reads the form before the handlers
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.PostFormValue("token") == "" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	router.Post("/signin", ok)
+	router.Post("/upload", ok)
+
+	for _, tc := range []struct {
+		path string
+		size int
+		want int
+	}{
+		{"/signin", 10, http.StatusOK},
+		{"/signin", 40, http.StatusRequestEntityTooLarge},
+		{"/upload", 40, http.StatusOK},
+		{"/upload.json", 40, http.StatusOK},
+		{"/upload.json", 80, http.StatusRequestEntityTooLarge},
+	} {
+		body := "token=" + strings.Repeat("a", tc.size-len("token="))
+		req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		// sent chunked, only reading the form finds it over the limit
+		req.ContentLength = -1
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		assert.Equal(t, tc.want, rec.Code, "%s %d bytes", tc.path, tc.size)
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("token", "x")
+	file, _ := form.CreateFormFile("avatar", "avatar.png")
+	file.Write(bytes.Repeat([]byte("a"), 64))
+	form.Close()
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.ContentLength = -1
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code, "multipart upload")
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/twsm000/lenslocked/models/contextutil"
+	"github.com/twsm000/lenslocked/models/entities"
+	"github.com/twsm000/lenslocked/models/services"
+	"github.com/twsm000/lenslocked/pkg/i18n"
+)
+
+const (
+	CookieLocale = "locale"
+
+	// localeMaxAge is the amount of seconds the locale preference is kept
+	localeMaxAge = 365 * 24 * 60 * 60
+)
+
+type LocaleMiddleware struct {
+	Bundle *i18n.Bundle
+}
+
+// SetLocalizer stores into the request context the localizer of the locale
+// preferred by the client, from the locale cookie or the Accept-Language header.
+func (lm LocaleMiddleware) SetLocalizer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var preferred string
+		if cookie, err := r.Cookie(CookieLocale); err == nil {
+			preferred = cookie.Value
+		}
+
+		w.Header().Add("Vary", "Accept-Language")
+		next.ServeHTTP(w, lm.localize(w, r, preferred))
+	})
+}
+
+// SetUserLocalizer replaces the localizer by the one of the locale saved by
+// the signed in user when the client has no locale cookie, like on a new
+// device. It must run after the user is stored into the request context.
+func (lm LocaleMiddleware) SetUserLocalizer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := contextutil.GetUser(r.Context())
+		if _, err := r.Cookie(CookieLocale); err == nil || !ok || user.Locale == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, lm.localize(w, r, user.Locale))
+	})
+}
+
+// localize returns the request with the localizer of the preferred locale,
+// or of the Accept-Language header when it is not supported
+func (lm LocaleMiddleware) localize(w http.ResponseWriter, r *http.Request, preferred string) *http.Request {
+	locale := lm.Bundle.Match(preferred, r.Header.Get("Accept-Language"))
+	w.Header().Set("Content-Language", locale)
+	ctx := contextutil.WithLocalizer(r.Context(), lm.Bundle.Localizer(locale))
+	return r.WithContext(ctx)
+}
+
+type Locale struct {
+	Bundle      *i18n.Bundle
+	UserService services.User
+}
+
+// SetLocale stores the locale chosen by the client and redirects back
+// to the page it came from. The locale of a signed in user is saved too,
+// for the other devices and the e-mails.
+func (lc *Locale) SetLocale(w http.ResponseWriter, r *http.Request) {
+	locale := r.PostFormValue("locale")
+	if slices.Contains(lc.Bundle.Locales(), locale) {
+		cookie := createCookie(CookieLocale, locale)
+		cookie.MaxAge = localeMaxAge
+		http.SetCookie(w, cookie)
+
+		// the cookie keeps the choice on this device when it is not saved
+		if user, ok := contextutil.GetUser(r.Context()); ok {
+			if err := lc.UserService.UpdateLocale(r.Context(), user, locale); err != nil {
+				contextutil.Logger(r.Context()).Warn("Failed to save the locale", "error", err)
+			}
+		}
+	}
+	http.Redirect(w, r, refererPath(r), http.StatusFound)
+}
+
+// emailLocalizer returns the localizer of the e-mails sent to the user, in
+// the locale saved by the user or else in the one of fallback. The e-mails
+// are read away from the requests, so the locale cookie does not apply.
+func emailLocalizer(user *entities.User, fallback *i18n.Localizer) *i18n.Localizer {
+	if user.Locale == "" {
+		return fallback
+	}
+	return fallback.ForLocale(user.Locale)
+}
+
+// refererPath returns the path of the Referer header when it is from the
+// same host, otherwise the home page. It avoids redirecting to other sites.
+func refererPath(r *http.Request) string {
+	referer, err := url.Parse(r.Referer())
+	if err != nil || referer.Host != r.Host || !strings.HasPrefix(referer.Path, "/") ||
+		strings.HasPrefix(referer.Path, "//") {
+		return "/"
+	}
+
+	target := url.URL{Path: referer.Path, RawQuery: referer.RawQuery}
+	return target.String()
+}
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twsm000/lenslocked/locales"
+	"github.com/twsm000/lenslocked/models/contextutil"
+	"github.com/twsm000/lenslocked/models/entities"
+	"github.com/twsm000/lenslocked/models/repositories/memoryrepo"
+	"github.com/twsm000/lenslocked/models/services"
+	"github.com/twsm000/lenslocked/pkg/i18n"
+	"github.com/twsm000/lenslocked/pkg/result"
+)
+
+func TestSetUserLocalizer(t *testing.T) {
+	bundle, err := i18n.LoadFS(locales.FS, locales.Fallback)
+	require.NoError(t, err)
+	lm := LocaleMiddleware{Bundle: bundle}
+	handler := lm.SetLocalizer(lm.SetUserLocalizer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, result.ExtractValue(contextutil.GetLocalizer(r.Context())).Locale())
+	})))
+
+	testCases := []struct {
+		desc     string
+		user     *entities.User
+		cookie   string
+		expected string
+	}{
+		{desc: "anonymous", expected: "en"},
+		{desc: "no locale saved", user: &entities.User{}, expected: "en"},
+		{desc: "locale saved", user: &entities.User{Locale: "pt-BR"}, expected: "pt-BR"},
+		{desc: "the cookie of the device first", user: &entities.User{Locale: "pt-BR"}, cookie: "en", expected: "en"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept-Language", "en")
+			if tC.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: CookieLocale, Value: tC.cookie})
+			}
+			if tC.user != nil {
+				r = r.WithContext(contextutil.WithUser(r.Context(), tC.user))
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, r)
+			assert.Equal(t, tC.expected, rec.Body.String())
+			assert.Equal(t, tC.expected, rec.Header().Get("Content-Language"))
+		})
+	}
+}
+
+func TestSetLocaleSavesTheLocaleOfTheUser(t *testing.T) {
+	bundle, err := i18n.LoadFS(locales.FS, locales.Fallback)
+	require.NoError(t, err)
+	store := memoryrepo.NewStore()
+	users := memoryrepo.NewUserRepository(store)
+	auditLogger := services.NewAuditLogger(memoryrepo.NewAuditRepository(store), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	lc := Locale{Bundle: bundle, UserService: services.NewUser(users, auditLogger)}
+
+	user := &entities.User{Password: entities.Hash("hash")}
+	user.Email.Set("alice@example.com")
+	require.NoError(t, users.Create(context.Background(), user))
+
+	form := url.Values{"locale": {"pt-BR"}}
+	r := httptest.NewRequest(http.MethodPost, "/locale", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r = r.WithContext(contextutil.WithUser(r.Context(), user))
+	rec := httptest.NewRecorder()
+	lc.SetLocale(rec, r)
+
+	assert.Equal(t, http.StatusFound, rec.Code)
+	stored, findErr := users.FindByID(context.Background(), user.ID)
+	require.NoError(t, findErr)
+	assert.Equal(t, "pt-BR", stored.Locale)
+}
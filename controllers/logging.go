@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/twsm000/lenslocked/models/contextutil"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type LogMiddleware struct {
+	Logger *slog.Logger
+}
+
+// LogRequests stores a logger carrying the request ID, the trace ID and the
+// route pattern in the request context and logs every request once it is
+// served. It must be used after middleware.RequestID and tracing.Middleware.
+func (lm LogMiddleware) LogRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		logger := slog.New(routeHandler{
+			Handler: lm.Logger.Handler(),
+			rctx:    chi.RouteContext(r.Context()),
+		}).With(slog.String("request_id", middleware.GetReqID(r.Context())))
+		if sc := trace.SpanContextFromContext(r.Context()); sc.HasTraceID() {
+			logger = logger.With(slog.String("trace_id", sc.TraceID().String()))
+		}
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(contextutil.WithLogger(r.Context(), logger)))
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		level := slog.LevelInfo
+		if status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		logger.LogAttrs(r.Context(), level, "Request served",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Int("bytes", ww.BytesWritten()),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("remote_addr", r.RemoteAddr),
+		)
+	})
+}
+
+// routeHandler adds the route pattern to the records when they are logged,
+// since chi only knows the whole pattern once the request is routed
+type routeHandler struct {
+	slog.Handler
+	rctx *chi.Context
+}
+
+func (rh routeHandler) Handle(ctx context.Context, record slog.Record) error {
+	if rh.rctx != nil {
+		record.AddAttrs(slog.String("route", rh.rctx.RoutePattern()))
+	}
+	return rh.Handler.Handle(ctx, record)
+}
+
+func (rh routeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return routeHandler{Handler: rh.Handler.WithAttrs(attrs), rctx: rh.rctx}
+}
+
+func (rh routeHandler) WithGroup(name string) slog.Handler {
+	return routeHandler{Handler: rh.Handler.WithGroup(name), rctx: rh.rctx}
+}
@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twsm000/lenslocked/models/contextutil"
+)
+
+func TestLogRequests(t *testing.T) {
+	var buf bytes.Buffer
+	lm := LogMiddleware{Logger: slog.New(slog.NewJSONHandler(&buf, nil))}
+
+	router := chi.NewRouter()
+	router.Use(middleware.RequestID)
+	router.Use(lm.LogRequests)
+	router.Route("/users/{id}", func(r chi.Router) {
+		r.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			contextutil.Logger(r.Context()).With("user_id", 7).Info("handled")
+			w.WriteHeader(http.StatusTeapot)
+		})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42/", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-1")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	decoder := json.NewDecoder(&buf)
+	var handled, served map[string]any
+	require.NoError(t, decoder.Decode(&handled))
+	require.NoError(t, decoder.Decode(&served))
+
+	assert.Equal(t, "handled", handled["msg"])
+	assert.Equal(t, "req-1", handled["request_id"])
+	assert.Equal(t, "/users/{id}", handled["route"])
+	assert.EqualValues(t, 7, handled["user_id"])
+
+	assert.Equal(t, "Request served", served["msg"])
+	assert.Equal(t, "req-1", served["request_id"])
+	assert.Equal(t, "/users/{id}", served["route"])
+	assert.EqualValues(t, http.StatusTeapot, served["status"])
+}
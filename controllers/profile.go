@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/twsm000/lenslocked/models/contextutil"
+	"github.com/twsm000/lenslocked/models/entities"
+	"github.com/twsm000/lenslocked/models/repositories"
+	"github.com/twsm000/lenslocked/models/services"
+)
+
+const (
+	AvatarsPath = "/avatars/"
+)
+
+type ProfileSettingsPageData struct {
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+	Bio         string `json:"bio"`
+	AvatarURL   string `json:"avatar_url,omitempty"`
+}
+
+type PublicProfilePageData struct {
+	Username    string    `json:"username"`
+	Name        string    `json:"name"`
+	Bio         string    `json:"bio"`
+	AvatarURL   string    `json:"avatar_url,omitempty"`
+	MemberSince time.Time `json:"member_since"`
+}
+
+// AvatarURL returns the path the avatar is served from, empty when the
+// user has no avatar
+func AvatarURL(profile entities.Profile) string {
+	if profile.Avatar == "" {
+		return ""
+	}
+	return AvatarsPath + url.PathEscape(profile.Avatar)
+}
+
+type Profile struct {
+	Errors    ErrorRenderer
+	Templates struct {
+		SettingsPage Template[ProfileSettingsPageData]
+		PublicPage   Template[PublicProfilePageData]
+	}
+	ProfileService services.Profile
+	Avatars        fs.FS
+}
+
+func (pc *Profile) SettingsPageHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := contextutil.GetUser(r.Context())
+	if !ok {
+		contextutil.Logger(r.Context()).Error("Required user was not found in the current context")
+		http.Redirect(w, r, "/signin", http.StatusFound)
+		return
+	}
+
+	pc.Templates.SettingsPage.Execute(w, r, newProfileSettingsPageData(user.Profile))
+}
+
+func (pc *Profile) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	user, ok := contextutil.GetUser(r.Context())
+	if !ok {
+		contextutil.Logger(r.Context()).Error("Required user was not found in the current context")
+		http.Redirect(w, r, "/signin", http.StatusFound)
+		return
+	}
+
+	err := r.ParseMultipartForm(formMaxMemory)
+	if err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		contextutil.Logger(r.Context()).Warn("Failed to parse profile settings form", "error", err)
+		if IsBodyTooLarge(err) {
+			pc.Errors.Render(w, r, http.StatusRequestEntityTooLarge)
+			return
+		}
+		pc.Errors.Render(w, r, http.StatusBadRequest)
+		return
+	}
+
+	var input entities.ProfileUpdatable
+	input.Username.Set(r.PostFormValue("username"))
+	input.DisplayName = r.PostFormValue("display_name")
+	input.Bio = r.PostFormValue("bio")
+	pageData := ProfileSettingsPageData{
+		Username:    r.PostFormValue("username"),
+		DisplayName: input.DisplayName,
+		Bio:         input.Bio,
+		AvatarURL:   AvatarURL(user.Profile),
+	}
+
+	// the avatar is read before saving, so an invalid one saves nothing
+	var avatar io.Reader
+	avatarFile, _, err := r.FormFile("avatar")
+	if err == nil {
+		defer avatarFile.Close()
+		avatar = avatarFile
+	} else if !errors.Is(err, http.ErrMissingFile) && !errors.Is(err, http.ErrNotMultipart) {
+		contextutil.Logger(r.Context()).Warn("Failed to read avatar upload", "error", err)
+		pc.Errors.Render(w, r, http.StatusBadRequest)
+		return
+	}
+
+	if err := pc.ProfileService.Update(r.Context(), user, input, avatar); err != nil {
+		contextutil.Logger(r.Context()).Warn("Failed to update profile", "error", err)
+		if err.IsClientErr() {
+			pc.Templates.SettingsPage.Execute(w, r, pageData, err)
+			return
+		}
+
+		pc.Errors.Render(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	contextutil.Logger(r.Context()).Info("User profile updated", "user", user)
+	flashSuccess(w, "flash.profile_updated")
+	http.Redirect(w, r, "/users/me/settings", http.StatusFound)
+}
+
+func (pc *Profile) PublicProfile(w http.ResponseWriter, r *http.Request) {
+	user, err := pc.ProfileService.FindByUsername(r.Context(), chi.URLParam(r, "username"))
+	if err != nil {
+		if err.Is(repositories.ErrUserNotFound) {
+			pc.Errors.Render(w, r, http.StatusNotFound)
+			return
+		}
+
+		contextutil.Logger(r.Context()).Error("Failed to find profile", "error", err)
+		pc.Errors.Render(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	pc.Templates.PublicPage.Execute(w, r, PublicProfilePageData{
+		Username:    user.Profile.Username.String(),
+		Name:        user.Profile.Name(),
+		Bio:         user.Profile.Bio,
+		AvatarURL:   AvatarURL(user.Profile),
+		MemberSince: user.CreatedAt,
+	})
+}
+
+// Avatar serves the avatar images. The file names change on every upload,
+// so they can be cached forever.
+func (pc *Profile) Avatar(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	switch path.Ext(name) {
+	case ".jpg", ".png":
+	default:
+		pc.Errors.Render(w, r, http.StatusNotFound)
+		return
+	}
+	if _, err := fs.Stat(pc.Avatars, name); err != nil {
+		pc.Errors.Render(w, r, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	http.ServeFileFS(w, r, pc.Avatars, name)
+}
+
+func newProfileSettingsPageData(profile entities.Profile) ProfileSettingsPageData {
+	return ProfileSettingsPageData{
+		Username:    profile.Username.String(),
+		DisplayName: profile.DisplayName,
+		Bio:         profile.Bio,
+		AvatarURL:   AvatarURL(profile),
+	}
+}
@@ -3,6 +3,9 @@ package controllers
 import (
 	"html/template"
 	"net/http"
+
+	"github.com/twsm000/lenslocked/models/contextutil"
+	"github.com/twsm000/lenslocked/pkg/result"
 )
 
 func StaticTemplateHandler(tmpl Template[any]) http.HandlerFunc {
@@ -11,26 +14,28 @@ func StaticTemplateHandler(tmpl Template[any]) http.HandlerFunc {
 	}
 }
 
-func FAQ(tmpl Template[any]) http.HandlerFunc {
-	questions := []struct {
-		Question string
-		Answer   template.HTML
-	}{
-		{
-			Question: "Is there a free version?",
-			Answer:   "Yes! We offer a free trial for 30 days on any paid plans.",
-		},
-		{
-			Question: "What are your support hours?",
-			Answer:   "We have support staff answering 24/7, though response times may be a bit slower on weekends",
-		},
-		{
-			Question: "How I contact support?",
-			Answer:   `Email us - <a href="mailto:support@lenslocked.com">support@lenslocked.com</a>`,
-		},
-	}
+type Question struct {
+	Question string        `json:"question"`
+	Answer   template.HTML `json:"answer"`
+}
+
+// faqKeys are the message key prefixes of each question and answer
+var faqKeys = []string{
+	"faq.free_version",
+	"faq.support_hours",
+	"faq.contact_support",
+}
 
+func FAQ(tmpl Template[[]Question]) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		localizer := result.ExtractValue(contextutil.GetLocalizer(r.Context()))
+		questions := make([]Question, 0, len(faqKeys))
+		for _, key := range faqKeys {
+			questions = append(questions, Question{
+				Question: localizer.T(key + ".question"),
+				Answer:   template.HTML(localizer.T(key + ".answer")),
+			})
+		}
 		tmpl.Execute(w, r, questions)
 	}
 }
@@ -9,3 +9,8 @@ import (
 type Template[T any] interface {
 	Execute(w http.ResponseWriter, r *http.Request, data T, errors ...entities.ClientError)
 }
+
+// ErrorRenderer writes an error response with the given status code
+type ErrorRenderer interface {
+	Render(w http.ResponseWriter, r *http.Request, status int)
+}
@@ -2,39 +2,71 @@ package controllers
 
 import (
 	"fmt"
-	"log"
+	"math"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/twsm000/lenslocked/models/contextutil"
 	"github.com/twsm000/lenslocked/models/entities"
 	"github.com/twsm000/lenslocked/models/httpll"
 	"github.com/twsm000/lenslocked/models/repositories"
 	"github.com/twsm000/lenslocked/models/services"
+	"github.com/twsm000/lenslocked/pkg/result"
+)
+
+const (
+	securityActivityEvents = 50
 )
 
 type SignUpPageData struct {
-	Email string
+	Email string `json:"email"`
 }
 
 type SignInPageData struct {
-	Email string
+	Email string `json:"email"`
+}
+
+type ForgotPasswordPageData struct {
+	Email string `json:"email"`
+}
+
+type CheckPasswordSentPageData struct {
+	Email          string `json:"email"`
+	ExpiresInHours int    `json:"expires_in_hours"`
+}
+
+type ResetPasswordPageData struct {
+	Token string `json:"token"`
+}
+
+// SecurityEvent is an audit event shown to the user it concerns
+type SecurityEvent struct {
+	CreatedAt time.Time `json:"created_at"`
+	Action    string    `json:"action"`
+	IP        string    `json:"ip,omitempty"`
+	UserAgent string    `json:"user_agent,omitempty"`
+}
+
+type SecurityActivityPageData struct {
+	Events []SecurityEvent `json:"events"`
 }
 
 type User struct {
-	LogInfo   *log.Logger
-	LogError  *log.Logger
+	Errors    ErrorRenderer
 	Templates struct {
 		SignUpPage            Template[SignUpPageData]
 		SignInPage            Template[SignInPageData]
-		ForgotPasswordPage    Template[any]
-		CheckPasswordSentPage Template[any]
-		ResetPasswordPage     Template[any]
+		ForgotPasswordPage    Template[ForgotPasswordPageData]
+		CheckPasswordSentPage Template[CheckPasswordSentPageData]
+		ResetPasswordPage     Template[ResetPasswordPageData]
+		SecurityActivityPage  Template[SecurityActivityPageData]
 	}
 	UserService          services.User
 	SessionService       services.Session
 	PasswordResetService services.PasswordReset
 	EmailService         *services.EmailService
+	AuditLogger          services.AuditLogger
 }
 
 func (uc *User) SignUpPageHandler(w http.ResponseWriter, r *http.Request) {
@@ -46,19 +78,11 @@ func (uc *User) SignInPageHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (uc *User) ForgotPasswordPageHandler(w http.ResponseWriter, r *http.Request) {
-	var data struct {
-		Email string
-	}
-	data.Email = r.FormValue("email")
-	uc.Templates.ForgotPasswordPage.Execute(w, r, data)
+	uc.Templates.ForgotPasswordPage.Execute(w, r, ForgotPasswordPageData{r.FormValue("email")})
 }
 
 func (uc *User) ResetPasswordPageHandler(w http.ResponseWriter, r *http.Request) {
-	var data struct {
-		Token string
-	}
-	data.Token = r.FormValue("token")
-	uc.Templates.ResetPasswordPage.Execute(w, r, data)
+	uc.Templates.ResetPasswordPage.Execute(w, r, ResetPasswordPageData{r.FormValue("token")})
 }
 
 func (uc *User) Create(w http.ResponseWriter, r *http.Request) {
@@ -67,33 +91,34 @@ func (uc *User) Create(w http.ResponseWriter, r *http.Request) {
 	userInput.Password.Set(r.PostFormValue("password"))
 	signUpPageData := SignUpPageData{Email: r.PostFormValue("email")}
 
-	user, err := uc.UserService.Create(userInput)
+	user, err := uc.UserService.Create(r.Context(), userInput)
 	if err != nil {
-		uc.LogError.Println(err)
+		contextutil.Logger(r.Context()).Error("Failed to create user", "error", err)
 		if !err.IsClientErr() {
-			err = entities.NewClientError("Sorry, something went wrong when creating your user account", err)
+			err = entities.NewClientError("error.user.create_failed", err)
 		}
 		uc.Templates.SignUpPage.Execute(w, r, signUpPageData, err)
 		return
 	}
 
-	uc.LogInfo.Println("User created:", user)
-	session, err := uc.SessionService.Create(user.ID)
+	contextutil.Logger(r.Context()).Info("User created", "user", user)
+	session, err := uc.SessionService.Create(r.Context(), user.ID)
 	if err != nil {
-		uc.LogError.Println(err)
+		contextutil.Logger(r.Context()).Error("Failed to create session", "error", err)
 		if err.IsClientErr() {
 			if err.Is(repositories.ErrUserNotFound) {
-				err = entities.NewClientError("Something went wrong while creating your user, please try again!", err)
+				err = entities.NewClientError("error.user.create_session_failed", err)
 			}
 			uc.Templates.SignUpPage.Execute(w, r, signUpPageData, err)
 			return
 		}
 
-		httpll.Redirect500Page(w, r)
+		uc.Errors.Render(w, r, http.StatusInternalServerError)
 		return
 	}
 
-	uc.LogInfo.Println("Session created:", session)
+	contextutil.Logger(r.Context()).Info("Session created", "session", session)
+	flashSuccess(w, "flash.welcome")
 	uc.createSessionCookieAndRedirect(w, r, session)
 }
 
@@ -101,37 +126,39 @@ func (uc *User) Authenticate(w http.ResponseWriter, r *http.Request) {
 	var authCredentials entities.UserAuthenticable
 	authCredentials.Email.Set(r.PostFormValue("email"))
 	authCredentials.Password.Set(r.PostFormValue("password"))
+	authCredentials.Source = httpll.NewAuditSource(r)
 	signInPageData := SignInPageData{Email: r.PostFormValue("email")}
 
-	user, err := uc.UserService.Authenticate(authCredentials)
+	user, err := uc.UserService.Authenticate(r.Context(), authCredentials)
 	if err != nil {
-		uc.LogError.Println(err)
+		contextutil.Logger(r.Context()).Warn("Failed to authenticate user", "error", err)
 		if err.IsClientErr() {
 			uc.Templates.SignInPage.Execute(w, r, signInPageData, err)
 			return
 		}
 
-		httpll.Redirect500Page(w, r)
+		uc.Errors.Render(w, r, http.StatusInternalServerError)
 		return
 	}
 
-	uc.LogInfo.Println("User authenticated:", user)
-	session, err := uc.SessionService.Create(user.ID)
+	contextutil.Logger(r.Context()).Info("User authenticated", "user", user)
+	session, err := uc.SessionService.Create(r.Context(), user.ID)
 	if err != nil {
-		uc.LogError.Println(err)
+		contextutil.Logger(r.Context()).Error("Failed to create session", "error", err)
 		if err.IsClientErr() {
 			if err.Is(repositories.ErrUserNotFound) {
-				err = entities.NewClientError("Something went wrong while authenticating your user, please try again!", err)
+				err = entities.NewClientError("error.user.authenticate_session_failed", err)
 			}
 			uc.Templates.SignInPage.Execute(w, r, signInPageData, err)
 			return
 		}
 
-		httpll.Redirect500Page(w, r)
+		uc.Errors.Render(w, r, http.StatusInternalServerError)
 		return
 	}
 
-	uc.LogInfo.Println("Session created:", session)
+	contextutil.Logger(r.Context()).Info("Session created", "session", session)
+	flashSuccess(w, "flash.welcome_back")
 	uc.createSessionCookieAndRedirect(w, r, session)
 }
 
@@ -142,83 +169,79 @@ func (uc *User) SignOut(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = uc.SessionService.DeleteByToken(cookie.Value)
+	err = uc.SessionService.DeleteByToken(r.Context(), cookie.Value)
 	if err != nil {
-		uc.LogError.Println(err)
-		httpll.Redirect500Page(w, r)
+		contextutil.Logger(r.Context()).Error("Failed to delete session", "error", err)
+		uc.Errors.Render(w, r, http.StatusInternalServerError)
 		return
 	}
 
+	if user, ok := contextutil.GetUser(r.Context()); ok {
+		uc.AuditLogger.TryRecord(r.Context(), entities.NewUserAuditEvent(user, httpll.NewAuditSource(r), entities.AuditSignedOut, user.ID))
+	}
 	http.SetCookie(w, deleteCookie(CookieSession))
+	flashSuccess(w, "flash.signed_out")
 	http.Redirect(w, r, "/signin", http.StatusFound)
 }
 
 func (uc *User) ResetPassword(w http.ResponseWriter, r *http.Request) {
 	var email entities.Email
 	email.Set(r.PostFormValue("email"))
-	pr, err := uc.PasswordResetService.Create(email)
+	user, pr, err := uc.PasswordResetService.Create(r.Context(), email)
 	if err != nil {
 		// TODO: handle all the cases
-		uc.LogError.Println(err)
-		httpll.SendStatusInternalServerError(w, r)
+		contextutil.Logger(r.Context()).Error("Failed to create password reset", "error", err)
+		uc.Errors.Render(w, r, http.StatusInternalServerError)
 		return
 	}
 
-	// TODO: generate reset url from the correct domain
-	url := url.Values{
-		"token": {
-			pr.Token.Value(),
-		},
-	}
-	resetURL := fmt.Sprintf("http:localhost:8080/resetpass?%s", url.Encode())
-	err = uc.EmailService.ForgotPassword(email.String(), resetURL)
+	// the request is anonymous, unless a signed in user asked for it
+	actor, _ := contextutil.GetUser(r.Context())
+	uc.AuditLogger.TryRecord(r.Context(), entities.NewUserAuditEvent(actor, httpll.NewAuditSource(r), entities.AuditPasswordResetRequested, pr.UserID))
+
+	// the one asking is likely the owner, so the locale of the request is
+	// used when the user saved none
+	localizer := emailLocalizer(user, result.ExtractValue(contextutil.GetLocalizer(r.Context())))
+	err = uc.EmailService.ForgotPassword(r.Context(), email.String(), resetPasswordURL(pr), localizer)
 	if err != nil {
 		// TODO: handle all the cases
-		uc.LogError.Println(err)
-		httpll.SendStatusInternalServerError(w, r)
+		contextutil.Logger(r.Context()).Error("Failed to send password reset e-mail", "error", err)
+		uc.Errors.Render(w, r, http.StatusInternalServerError)
 		return
 	}
 
-	uc.Templates.CheckPasswordSentPage.Execute(w, r, struct{ Email string }{Email: email.String()})
+	uc.Templates.CheckPasswordSentPage.Execute(w, r, CheckPasswordSentPageData{
+		Email:          email.String(),
+		ExpiresInHours: int(math.Ceil(time.Until(pr.ExpiresAt).Hours())),
+	})
 }
 
 func (uc *User) UpdatePassword(w http.ResponseWriter, r *http.Request) {
 	var stoken entities.SessionToken
 	stoken.SetFromHex(r.PostFormValue("token"))
-	user, err := uc.PasswordResetService.Consume(stoken)
-	if err != nil {
-		// TODO: handle all the cases
-		uc.LogError.Println(err)
-		httpll.SendStatusInternalServerError(w, r)
-		return
-	}
-
 	var rawPassword entities.RawPassword
 	rawPassword.Set(r.PostFormValue("password"))
-	if err := uc.UserService.UpdatePassword(user, rawPassword); err != nil {
+	user, session, err := uc.PasswordResetService.ResetPassword(r.Context(), stoken, rawPassword)
+	if err != nil {
 		// TODO: handle all the cases
-		uc.LogError.Println(err)
-		httpll.SendStatusInternalServerError(w, r)
+		contextutil.Logger(r.Context()).Error("Failed to reset password", "error", err)
+		uc.Errors.Render(w, r, http.StatusInternalServerError)
 		return
 	}
 
-	uc.LogInfo.Println("User password updated:", user)
-	session, err := uc.SessionService.Create(user.ID)
-	if err != nil {
-		// TODO: validate other error types
-		uc.LogError.Println(err)
-		http.Redirect(w, r, "/signin", http.StatusFound)
-		return
-	}
+	source := httpll.NewAuditSource(r)
+	uc.AuditLogger.TryRecord(r.Context(), entities.NewUserAuditEvent(user, source, entities.AuditPasswordResetConsumed, user.ID))
+	uc.AuditLogger.TryRecord(r.Context(), entities.NewUserAuditEvent(user, source, entities.AuditPasswordChanged, user.ID))
 
-	uc.LogInfo.Println("Session created:", session)
+	contextutil.Logger(r.Context()).Info("User password updated", "user", user, "session", session)
+	flashSuccess(w, "flash.password_updated")
 	uc.createSessionCookieAndRedirect(w, r, session)
 }
 
 func (uc *User) UserInfo(w http.ResponseWriter, r *http.Request) {
 	user, ok := contextutil.GetUser(r.Context())
 	if !ok {
-		uc.LogError.Println("Required user was not found in the current context.")
+		contextutil.Logger(r.Context()).Error("Required user was not found in the current context")
 		http.Redirect(w, r, "/signup", http.StatusFound)
 		return
 	}
@@ -227,14 +250,54 @@ func (uc *User) UserInfo(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "Header: %+v\n", r.Header)
 }
 
+// SecurityActivity lists the latest audit events of the signed in user
+func (uc *User) SecurityActivity(w http.ResponseWriter, r *http.Request) {
+	user, _ := contextutil.GetUser(r.Context())
+	events, _, err := uc.AuditLogger.List(r.Context(), repositories.AuditFilter{
+		UserID: user.ID,
+		Limit:  securityActivityEvents,
+	})
+	if err != nil {
+		contextutil.Logger(r.Context()).Error("Failed to list security activity", "error", err)
+		uc.Errors.Render(w, r, http.StatusInternalServerError)
+		return
+	}
+
+	data := SecurityActivityPageData{
+		Events: make([]SecurityEvent, 0, len(events)),
+	}
+	for _, event := range events {
+		data.Events = append(data.Events, SecurityEvent{
+			CreatedAt: event.CreatedAt,
+			Action:    string(event.Action),
+			IP:        event.Source.IP,
+			UserAgent: event.Source.UserAgent,
+		})
+	}
+	uc.Templates.SecurityActivityPage.Execute(w, r, data)
+}
+
+// resetPasswordURL returns the link sent to the user to reset the password
+func resetPasswordURL(pr *entities.PasswordReset) string {
+	// TODO: generate reset url from the correct domain
+	query := url.Values{
+		"token": {
+			pr.Token.Value(),
+		},
+	}
+	return fmt.Sprintf("http:localhost:8080/resetpass?%s", query.Encode())
+}
+
+// createSessionCookieAndRedirect signs the user in and redirects to the home
+// page, which renders the layout and so the flashes set before
 func (uc *User) createSessionCookieAndRedirect(w http.ResponseWriter, r *http.Request, session *entities.Session) {
 	cookie := createSessionCookie(session)
 	http.SetCookie(w, cookie)
-	http.Redirect(w, r, "/users/me", http.StatusFound)
+	http.Redirect(w, r, "/", http.StatusFound)
 }
 
 type UserMiddleware struct {
-	LogWarn        *log.Logger
+	Errors         ErrorRenderer
 	SessionService services.Session
 }
 
@@ -246,20 +309,23 @@ func (um UserMiddleware) SetUserToRequestContext(next http.Handler) http.Handler
 			return
 		}
 
-		user, err := um.SessionService.FindUserByToken(cookie.Value)
+		user, err := um.SessionService.FindUserByToken(r.Context(), cookie.Value)
 		if err != nil {
 			next.ServeHTTP(w, r)
 			return
 		}
 
-		next.ServeHTTP(w, r.WithContext(contextutil.WithUser(r.Context(), user)))
+		ctx := contextutil.WithUser(r.Context(), user)
+		ctx = contextutil.WithLogger(ctx, contextutil.Logger(ctx).With("user_id", user.ID))
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
 func (um UserMiddleware) RequireUser(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if _, ok := contextutil.GetUser(r.Context()); !ok {
-			um.LogWarn.Println("user not found in the current request context")
+			contextutil.Logger(r.Context()).Warn("User not found in the current request context")
+			flashInfo(w, "flash.sign_in_required")
 			http.Redirect(w, r, "/signin", http.StatusFound)
 			return
 		}
@@ -267,3 +333,25 @@ func (um UserMiddleware) RequireUser(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// RequirePermission renders the forbidden page when the user in the request
+// context is not granted the permission. It must be used after RequireUser.
+func (um UserMiddleware) RequirePermission(permission entities.Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := contextutil.GetUser(r.Context())
+			if !ok {
+				contextutil.Logger(r.Context()).Warn("User not found in the current request context")
+				um.Errors.Render(w, r, http.StatusForbidden)
+				return
+			}
+			if !user.Can(permission) {
+				contextutil.Logger(r.Context()).Warn("User is not granted the permission", "permission", permission)
+				um.Errors.Render(w, r, http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
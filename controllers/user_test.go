@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twsm000/lenslocked/models/entities"
+)
+
+func TestCreateSessionCookieAndRedirect(t *testing.T) {
+	rec := httptest.NewRecorder()
+	flashSuccess(rec, "flash.welcome")
+	var uc User
+	uc.createSessionCookieAndRedirect(rec, httptest.NewRequest(http.MethodPost, "/signin", nil), &entities.Session{})
+
+	assert.Equal(t, http.StatusFound, rec.Code)
+	// the page renders the layout, which displays the flash
+	assert.Equal(t, "/", rec.Header().Get("Location"))
+	var names []string
+	for _, cookie := range rec.Result().Cookies() {
+		names = append(names, cookie.Name)
+	}
+	assert.ElementsMatch(t, []string{CookieFlash, CookieSession}, names)
+}
@@ -0,0 +1,12 @@
+package locales
+
+import "embed"
+
+// Fallback is the locale used when the client locale is not supported
+// and for the messages missing in the other catalogs.
+const Fallback = "en"
+
+var (
+	//go:embed *.json
+	FS embed.FS
+)
@@ -0,0 +1,27 @@
+package locales
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twsm000/lenslocked/pkg/i18n"
+)
+
+func TestCatalogsHaveTheSameKeys(t *testing.T) {
+	bundle, err := i18n.LoadFS(FS, Fallback)
+	require.NoError(t, err)
+
+	fallback, err := bundle.Catalog(Fallback)
+	require.NoError(t, err)
+	for _, locale := range bundle.Locales() {
+		catalog, err := bundle.Catalog(locale)
+		require.NoError(t, err)
+		for key := range fallback {
+			assert.Contains(t, catalog, key, "locale %s", locale)
+		}
+		for key := range catalog {
+			assert.Contains(t, fallback, key, "locale %s", locale)
+		}
+	}
+}
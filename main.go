@@ -3,205 +3,447 @@ package main
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"math"
 	"net/http"
 	"os"
 	"os/signal"
-	"strconv"
 	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/twsm000/lenslocked/controllers"
+	"github.com/twsm000/lenslocked/controllers/api"
+	"github.com/twsm000/lenslocked/locales"
 	"github.com/twsm000/lenslocked/models/database"
-	"github.com/twsm000/lenslocked/models/database/postgres"
 	"github.com/twsm000/lenslocked/models/entities"
-	"github.com/twsm000/lenslocked/models/repositories/postgresrepo"
+	"github.com/twsm000/lenslocked/models/httpll"
 	"github.com/twsm000/lenslocked/models/services"
-	"github.com/twsm000/lenslocked/models/sql/postgres/migrations"
+	"github.com/twsm000/lenslocked/pkg/health"
+	"github.com/twsm000/lenslocked/pkg/https"
+	"github.com/twsm000/lenslocked/pkg/i18n"
+	"github.com/twsm000/lenslocked/pkg/images"
+	"github.com/twsm000/lenslocked/pkg/logging"
+	"github.com/twsm000/lenslocked/pkg/metrics"
 	"github.com/twsm000/lenslocked/pkg/result"
+	"github.com/twsm000/lenslocked/pkg/tracing"
 	"github.com/twsm000/lenslocked/templates"
 	"github.com/twsm000/lenslocked/views"
 
 	"github.com/gorilla/csrf"
 	_ "github.com/jackc/pgx/v4/stdlib"
-)
-
-var (
-	logError *log.Logger = log.New(os.Stderr, "ERROR: ", log.LstdFlags|log.Llongfile)
-	logInfo  *log.Logger = log.New(os.Stdout, "INFO: ", log.LstdFlags|log.Llongfile)
-	logWarn  *log.Logger = log.New(os.Stdout, "WARN: ", log.LstdFlags|log.Llongfile)
+	_ "modernc.org/sqlite"
 )
 
 func main() {
-	envFilePath := flag.String("env-file", "", "Environment file settings")
+	envFilePath := flag.String("env-file", os.Getenv(EnvPrefix+"_ENV_FILE"), "Environment file settings, JSON with comments")
+	devMode := flag.Bool("dev", false, "Development mode, overrides the env file setting")
+	var overrides []string
+	flag.Func("set", "Overrides a setting, like database.port=5433, repeatable", func(s string) error {
+		overrides = append(overrides, s)
+		return nil
+	})
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [command]\n", os.Args[0])
+		flag.PrintDefaults()
+		(&CLI{Stderr: flag.CommandLine.Output()}).PrintUsage()
+	}
 	flag.Parse()
 
-	env := result.MustGet(LoadEnvSettings(*envFilePath, "pgx"))
-	if len(env.CSRF.Key) != 32 {
-		log.Println("CSRF.Key needs to be 32 bytes")
-		flag.PrintDefaults()
-		os.Exit(1)
+	env := result.MustGet(LoadEnvSettings(*envFilePath, os.LookupEnv, overrides...))
+	env.Dev = env.Dev || *devMode
+	// only the server logs to stdout, the other commands print their
+	// results there
+	logOutput := io.Writer(os.Stderr)
+	if flag.NArg() == 0 || flag.Arg(0) == "serve" {
+		logOutput = os.Stdout
 	}
-	logInfo.Printf("EnvSettings: %s\n", result.MustGet(json.MarshalIndent(&env, "", "  ")))
+	logger, err := logging.New(logOutput, env.Log)
+	TryTerminate(err)
+	// the log package, used by the dependencies, writes to the logger too
+	slog.SetDefault(logger)
+
+	cli := &CLI{
+		Env:    env,
+		Logger: logger,
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+	TryTerminate(cli.Run(context.Background(), flag.Args()))
+}
+
+func (cli *CLI) serve(ctx context.Context, args []string) error {
+	if err := cli.parseFlags(flag.NewFlagSet("serve", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+	env, logger := cli.Env, cli.Logger
+	if err := env.Validate(); err != nil {
+		return err
+	}
+	logger.Info("Settings loaded", "settings", env)
+
+	shutdownTracing, err := tracing.Setup(ctx, env.Tracing, os.Stdout)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.Error("Failed to flush the traces", "error", err)
+		}
+	}()
 
-	db := result.MustGet(database.NewConnection(env.DBConfig))
+	db, err := openDB(env)
+	if err != nil {
+		return err
+	}
 	defer func() {
-		logInfo.Println("Closing database...")
+		logger.Info("Closing database...")
 		if err := db.Close(); err != nil {
-			logError.Println("Database close error:", err)
+			logger.Error("Database close error", "error", err)
 		}
 	}()
-	TryTerminate(postgres.MigrateFS(db, "", migrations.FS))
 
-	router, closer := NewRouter(db, env)
+	appMetrics := metrics.New()
+	if err := appMetrics.RegisterDB(db, env.DBConfig.Database); err != nil {
+		return err
+	}
+
+	checker := &health.Checker{Timeout: time.Duration(env.Health.Timeout)}
+	router, closer := NewRouter(db, env, logger, appMetrics, checker)
 	defer func() {
-		logInfo.Println("Closing resources...")
+		logger.Info("Closing resources...")
 		if err := closer.Close(); err != nil {
-			logError.Println(err)
+			logger.Error("Failed to close resources", "error", err)
 		}
 	}()
-	server := http.Server{
-		Addr:    env.Server.Address,
-		Handler: router,
+	servers := []*http.Server{newServer(env.Server, env.Server.Address, router)}
+	if tlsConfig := env.Server.TLS; tlsConfig.Enabled() {
+		certs, err := https.NewCertReloader(tlsConfig.CertFile, tlsConfig.KeyFile)
+		if err != nil {
+			return err
+		}
+		reload := make(chan os.Signal, 1)
+		signal.Notify(reload, syscall.SIGHUP)
+		defer signal.Stop(reload)
+		watchCtx, stopWatching := context.WithCancel(ctx)
+		defer stopWatching()
+		go certs.Watch(watchCtx, time.Duration(tlsConfig.ReloadInterval), reload, logger)
+
+		servers[0].TLSConfig = https.Config(certs.GetCertificate)
+		if tlsConfig.RedirectAddress != "" {
+			servers = append(servers, newServer(env.Server, tlsConfig.RedirectAddress, https.Redirect(env.Server.Address)))
+		}
+	}
+	if env.Server.AdminAddress != "" {
+		servers = append(servers, newServer(env.Server, env.Server.AdminAddress, NewAdminRouter(appMetrics)))
 	}
-	Run(&server)
+	Run(logger, checker, time.Duration(env.Health.DrainDelay), time.Duration(env.Server.ShutdownTimeout), servers...)
+	return nil
 }
 
-func ApplyHTML(page ...string) []string {
-	return append([]string{"layout.tailwind.html", "footer.html"}, page...)
+// newServer returns the server of the address with the timeouts and the
+// limits of the settings
+func newServer(config Server, address string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              address,
+		Handler:           handler,
+		ReadHeaderTimeout: time.Duration(config.ReadHeaderTimeout),
+		ReadTimeout:       time.Duration(config.ReadTimeout),
+		WriteTimeout:      time.Duration(config.WriteTimeout),
+		IdleTimeout:       time.Duration(config.IdleTimeout),
+		MaxHeaderBytes:    config.MaxHeaderBytes,
+	}
 }
 
-func NewRouter(DB *sql.DB, env *EnvConfig) (http.Handler, io.Closer) {
-	homeTmpl := result.MustGet(views.ParseFSTemplate[any](
-		logError, templates.FS, ApplyHTML("home.html")...))
-	contactTmpl := result.MustGet(views.ParseFSTemplate[any](
-		logError, templates.FS, ApplyHTML("contact.html")...))
-	faqTmpl := result.MustGet(views.ParseFSTemplate[any](
-		logError, templates.FS, ApplyHTML("faq.html")...))
-	signupTmpl := result.MustGet(views.ParseFSTemplate[controllers.SignUpPageData](
-		logError, templates.FS, ApplyHTML("signup.html")...))
-	signinTmpl := result.MustGet(views.ParseFSTemplate[controllers.SignInPageData](
-		logError, templates.FS, ApplyHTML("signin.html")...))
-	forgotPasswordTmpl := result.MustGet(views.ParseFSTemplate[any](
-		logError, templates.FS, ApplyHTML("forgot_password.html")...))
-	checkPasswordSentTmpl := result.MustGet(views.ParseFSTemplate[any](
-		logError, templates.FS, ApplyHTML("check_password_sent.html")...))
-	resetPasswordTmpl := result.MustGet(views.ParseFSTemplate[any](
-		logError, templates.FS, ApplyHTML("reset_password.html")...))
-	IntrnSrvErrTmpl := result.MustGet(views.ParseFSTemplate[any](
-		logError, templates.FS, ApplyHTML("500.html")...))
-
-	userRepo := result.MustGet(postgresrepo.NewUserRepository(DB))
-	userService := services.NewUser(userRepo)
-	sessionRepo := result.MustGet(postgresrepo.NewSessionRepository(DB, logError, logInfo, logWarn))
-	sessionService := services.NewSession(env.Session.TokenSize, sessionRepo)
-	passwordResetRepo := result.MustGet(postgresrepo.NewPasswordResetRepository(DB, logError, logInfo, logWarn))
-	passwordResetService := services.NewPasswordReset(
-		env.Session.TokenSize,
-		entities.DefaultPasswordResetDuration, // TODO: load this value from env file
-		passwordResetRepo,
-		userRepo,
-		logError,
-	)
+// NewAdminRouter serves the operational endpoints on the admin address
+func NewAdminRouter(appMetrics *metrics.Metrics) http.Handler {
+	router := chi.NewRouter()
+	router.Handle("/metrics", appMetrics.Handler())
+	return router
+}
+
+func NewRouter(
+	DB *sql.DB,
+	env *EnvConfig,
+	logger *slog.Logger,
+	appMetrics *metrics.Metrics,
+	checker *health.Checker) (http.Handler, io.Closer) {
+	/***************************************************/
+	tmplSource := views.EmbeddedSource(templates.FS)
+	if env.Dev {
+		logger.Warn("Development mode: loading templates from disk", "dir", templates.Dir)
+		tmplSource = views.DirSource(templates.Dir)
+	}
+	bundle := result.MustGet(i18n.LoadFS(locales.FS, locales.Fallback))
+	registry := result.MustGet(NewPageRegistry(tmplSource))
+	errorPage := registry.ErrorPage()
+	homeTmpl := HomePage.MustLookup(registry)
+	contactTmpl := ContactPage.MustLookup(registry)
+	faqTmpl := FAQPage.MustLookup(registry)
+	signupTmpl := SignUpPage.MustLookup(registry)
+	signinTmpl := SignInPage.MustLookup(registry)
+	forgotPasswordTmpl := ForgotPasswordPage.MustLookup(registry)
+	checkPasswordSentTmpl := CheckPasswordSentPage.MustLookup(registry)
+	resetPasswordTmpl := ResetPasswordPage.MustLookup(registry)
+	securityActivityTmpl := SecurityActivityPage.MustLookup(registry)
+	profileSettingsTmpl := ProfileSettingsPage.MustLookup(registry)
+	publicProfileTmpl := PublicProfilePage.MustLookup(registry)
+	adminDashboardTmpl := AdminDashboardPage.MustLookup(registry)
+	adminUsersTmpl := AdminUsersPage.MustLookup(registry)
+	adminUserTmpl := AdminUserPage.MustLookup(registry)
+	adminAuditTmpl := AdminAuditPage.MustLookup(registry)
+
+	repos := result.MustGet(NewRepositories(DB, env.DBConfig, logger))
+	auditLogger := services.NewAuditLogger(repos.Audit, logger)
+	userService := services.NewUser(repos.Users, auditLogger)
+	sessionService := services.NewSession(env.Session.TokenSize, repos.Sessions)
+	passwordResetService := newPasswordResetService(env, repos, logger)
 	emailService := services.NewEmailService(env.SMTPConfig)
+	emailService.Observer = appMetrics
+	avatarStore := result.MustGet(images.NewDirStore(env.Storage.AvatarsDir))
+	profileService := services.NewProfile(repos.Users, avatarStore, logger)
+	adminService := newAdminService(env, repos, logger)
 
 	userController := controllers.User{
-		LogInfo:              logInfo,
-		LogError:             logError,
+		Errors:               errorPage,
 		UserService:          userService,
 		SessionService:       sessionService,
 		PasswordResetService: passwordResetService,
 		EmailService:         emailService,
+		AuditLogger:          auditLogger,
 	}
 	userController.Templates.SignUpPage = signupTmpl
 	userController.Templates.SignInPage = signinTmpl
 	userController.Templates.ForgotPasswordPage = forgotPasswordTmpl
 	userController.Templates.CheckPasswordSentPage = checkPasswordSentTmpl
 	userController.Templates.ResetPasswordPage = resetPasswordTmpl
+	userController.Templates.SecurityActivityPage = securityActivityTmpl
+
+	profileController := controllers.Profile{
+		Errors:         errorPage,
+		ProfileService: profileService,
+		Avatars:        avatarStore,
+	}
+	profileController.Templates.SettingsPage = profileSettingsTmpl
+	profileController.Templates.PublicPage = publicProfileTmpl
+
+	adminController := controllers.Admin{
+		Errors:       errorPage,
+		AdminService: adminService,
+		EmailService: emailService,
+	}
+	adminController.Templates.DashboardPage = adminDashboardTmpl
+	adminController.Templates.UsersPage = adminUsersTmpl
+	adminController.Templates.UserPage = adminUserTmpl
+	adminController.Templates.AuditPage = adminAuditTmpl
 
-	csrfMiddleware := csrf.Protect([]byte(env.CSRF.Key), csrf.Secure(env.CSRF.Secure))
+	// the cookies are sent over HTTPS only when it is served, or when a
+	// proxy terminates the TLS
+	secureCookies := env.CSRF.Secure || env.Server.TLS.Enabled()
+	csrfKeys := result.MustGet(env.CSRF.Keys())
+	csrfMiddleware := csrf.Protect(
+		csrfKeys[0],
+		csrf.CookieName(controllers.CookieCSRF),
+		csrf.MaxAge(controllers.CSRFMaxAge),
+		csrf.Secure(secureCookies),
+		csrf.ErrorHandler(errorPage.Handler(http.StatusForbidden)),
+	)
 	userMiddleware := controllers.UserMiddleware{
-		LogWarn:        logWarn,
+		Errors:         errorPage,
 		SessionService: sessionService,
 	}
+	flashMiddleware := controllers.FlashMiddleware{}
+	localeMiddleware := controllers.LocaleMiddleware{
+		Bundle: bundle,
+	}
+	localeController := controllers.Locale{
+		Bundle:      bundle,
+		UserService: userService,
+	}
+	errorMiddleware := controllers.ErrorMiddleware{
+		Errors: errorPage,
+	}
+	logMiddleware := controllers.LogMiddleware{
+		Logger: logger,
+	}
+	bodyLimitMiddleware := controllers.BodyLimitMiddleware{
+		Errors:   errorPage,
+		MaxBytes: env.Server.MaxBodyBytes,
+		Paths: map[string]int64{
+			"/users/me/settings": env.Server.MaxUploadBytes,
+		},
+	}
 
-	router := chi.NewRouter()
-	router.Use(middleware.Recoverer)
-	router.Use(middleware.Logger)
-	router.Use(csrfMiddleware)
-	router.Use(userMiddleware.SetUserToRequestContext)
-
-	router.Get("/", AsHTML(controllers.StaticTemplateHandler(homeTmpl)))
-	router.Get("/contact", AsHTML(controllers.StaticTemplateHandler(contactTmpl)))
-	router.Get("/faq", AsHTML(controllers.FAQ(faqTmpl)))
-	router.Get("/signup", AsHTML(userController.SignUpPageHandler))
-	router.Get("/signin", AsHTML(userController.SignInPageHandler))
-	router.Get("/forgotpass", AsHTML(userController.ForgotPasswordPageHandler))
-	router.Get("/resetpass", AsHTML(userController.ResetPasswordPageHandler))
-	router.Post("/signin", AsHTML(userController.Authenticate))
-	router.Post("/signout", AsHTML(userController.SignOut))
-	router.Post("/resetpass", AsHTML(userController.ResetPassword))
-	router.Post("/updatepass", AsHTML(userController.UpdatePassword))
-	router.NotFound(AsHTML(func(w http.ResponseWriter, r *http.Request) {
-		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
-	}))
-	router.Get("/500", AsHTML(func(w http.ResponseWriter, r *http.Request) {
-		cookie, err := r.Cookie("redirect")
-		if err != nil || result.ExtractValue(strconv.Atoi(cookie.Value)) != http.StatusInternalServerError {
-			if err == nil {
-				logInfo.Printf("Cookie: %+v", cookie)
-			}
-			logError.Println("GET /500 EXPECT NOT FOUND")
-			http.Redirect(w, r, "/", http.StatusSeeOther)
-			return
-		}
+	apiController := &api.API{
+		UserService:    userService,
+		SessionService: sessionService,
+		AuditLogger:    auditLogger,
+		MaxBodyBytes:   env.Server.MaxBodyBytes,
+	}
+
+	htmlRouter := chi.NewRouter()
+	htmlRouter.Use(httpll.JSONSuffix)
+	// before the CSRF protection, which parses the forms
+	htmlRouter.Use(bodyLimitMiddleware.LimitBody)
+	htmlRouter.Use(controllers.RotateCSRFKeys(csrfKeys...))
+	htmlRouter.Use(csrfMiddleware)
+	htmlRouter.Use(flashMiddleware.ReadFlashes)
+	htmlRouter.Use(userMiddleware.SetUserToRequestContext)
+	htmlRouter.Use(localeMiddleware.SetUserLocalizer)
+	htmlRouter.NotFound(errorPage.Handler(http.StatusNotFound))
+	htmlRouter.MethodNotAllowed(errorPage.Handler(http.StatusMethodNotAllowed))
 
-		w.WriteHeader(http.StatusInternalServerError)
-		IntrnSrvErrTmpl.Execute(w, r, nil)
-	}))
+	htmlRouter.Get("/", AsHTML(controllers.StaticTemplateHandler(homeTmpl)))
+	htmlRouter.Get("/contact", AsHTML(controllers.StaticTemplateHandler(contactTmpl)))
+	htmlRouter.Get("/faq", AsHTML(controllers.FAQ(faqTmpl)))
+	htmlRouter.Get("/signup", AsHTML(userController.SignUpPageHandler))
+	htmlRouter.Get("/signin", AsHTML(userController.SignInPageHandler))
+	htmlRouter.Get("/forgotpass", AsHTML(userController.ForgotPasswordPageHandler))
+	htmlRouter.Get("/resetpass", AsHTML(userController.ResetPasswordPageHandler))
+	htmlRouter.Post("/signin", AsHTML(userController.Authenticate))
+	htmlRouter.Post("/signout", AsHTML(userController.SignOut))
+	htmlRouter.Post("/resetpass", AsHTML(userController.ResetPassword))
+	htmlRouter.Post("/updatepass", AsHTML(userController.UpdatePassword))
+	htmlRouter.Post("/locale", localeController.SetLocale)
+	htmlRouter.Get("/u/{username}", AsHTML(profileController.PublicProfile))
+	htmlRouter.Get(controllers.AvatarsPath+"{name}", profileController.Avatar)
 
-	router.Route("/users", func(r chi.Router) {
+	htmlRouter.Route("/users", func(r chi.Router) {
 		r.Post("/", userController.Create)
 
 		r.Route("/me", func(r chi.Router) {
 			r.Use(userMiddleware.RequireUser)
 			r.Get("/", userController.UserInfo)
+			r.Get("/settings", AsHTML(profileController.SettingsPageHandler))
+			r.Post("/settings", AsHTML(profileController.UpdateSettings))
+			r.Get("/security", AsHTML(userController.SecurityActivity))
 		})
 	})
 
-	closer := func() error {
-		return errors.Join(
-			userRepo.Close(),
-			sessionRepo.Close(),
-		)
+	htmlRouter.Route(controllers.AdminPath, func(r chi.Router) {
+		r.Use(userMiddleware.RequireUser)
+		r.Use(userMiddleware.RequirePermission(entities.PermissionViewAdmin))
+		r.Get("/", AsHTML(adminController.Dashboard))
+		r.Get("/users", AsHTML(adminController.Users))
+		r.Get("/users/{id}", AsHTML(adminController.User))
+
+		r.Group(func(r chi.Router) {
+			r.Use(userMiddleware.RequirePermission(entities.PermissionManageUsers))
+			r.Post("/users/{id}/signout", adminController.SignOutUser)
+			r.Post("/users/{id}/disable", adminController.DisableUser)
+			r.Post("/users/{id}/enable", adminController.EnableUser)
+			r.Post("/users/{id}/resetpass", adminController.ResetUserPassword)
+		})
+
+		r.With(userMiddleware.RequirePermission(entities.PermissionViewAuditLog)).
+			Get("/audit", AsHTML(adminController.AuditLog))
+	})
+
+	router := chi.NewRouter()
+	if env.Server.TLS.Enabled() {
+		router.Use(https.HSTS(time.Duration(env.Server.TLS.HSTSMaxAge), env.Server.TLS.HSTSIncludeSubdomains))
+	}
+	if secureCookies {
+		router.Use(https.SecureCookies)
+	}
+	router.Use(middleware.RequestID)
+	router.Use(tracing.Middleware)
+	router.Use(logMiddleware.LogRequests)
+	router.Use(appMetrics.Middleware)
+	router.Use(localeMiddleware.SetLocalizer)
+	router.Use(errorMiddleware.Recover)
+
+	// the API authenticates with bearer tokens instead of cookies, so it is
+	// not protected against CSRF
+	router.Get("/healthz", checker.Liveness)
+	router.Get("/readyz", checker.Readiness)
+	router.Mount(api.Prefix, api.NewRouter(apiController))
+	router.Get(api.SpecPath, apiController.SpecHandler)
+	router.Mount("/", htmlRouter)
+
+	// a slow database must not hold the scrape
+	metricsTimeout := time.Duration(env.Health.Timeout)
+	if metricsTimeout == 0 {
+		metricsTimeout = health.DefaultTimeout
+	}
+	TryTerminate(errors.Join(
+		appMetrics.RegisterGauge("sessions", "Active sessions.", func() float64 {
+			ctx, cancel := context.WithTimeout(context.Background(), metricsTimeout)
+			defer cancel()
+			sessions, err := repos.Sessions.Count(ctx)
+			if err != nil {
+				logger.Warn("Failed to collect the sessions metric", "error", err)
+				return math.NaN()
+			}
+			return float64(sessions)
+		}),
+		appMetrics.RegisterGauge("images_processing", "Images being processed.", func() float64 {
+			return float64(images.Processing())
+		}),
+	))
+
+	checker.Add("database", DB.PingContext)
+	checker.Add("migrations", func(ctx context.Context) error {
+		return database.CheckVersion(ctx, DB, env.DBConfig.Migrations())
+	})
+	checker.Add("storage", func(ctx context.Context) error {
+		return avatarStore.CheckWritable()
+	})
+	if env.Health.CheckSMTP {
+		checker.AddOptional("smtp", emailService.Ping)
 	}
 
-	return router, CloserFunc(closer)
+	return router, repos
 }
 
-func Run(server *http.Server) {
-	go func() {
-		logInfo.Printf("Starting server at port: %s\n", server.Addr)
-		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
-			logError.Println("Failed to close http server:", err)
-		}
-	}()
+// Run serves every server, over TLS when it has a TLSConfig, until the
+// process is interrupted, then fails the readiness checks for drainDelay
+// and shuts the servers down gracefully, waiting shutdownTimeout at most
+// for the requests in flight
+func Run(
+	logger *slog.Logger,
+	checker *health.Checker,
+	drainDelay time.Duration,
+	shutdownTimeout time.Duration,
+	servers ...*http.Server) {
+	/***************************************************/
+	for _, server := range servers {
+		go func() {
+			logger.Info("Starting server", "address", server.Addr, "tls", server.TLSConfig != nil)
+			var err error
+			if server.TLSConfig != nil {
+				// the certificate is given by the TLSConfig
+				err = server.ListenAndServeTLS("", "")
+			} else {
+				err = server.ListenAndServe()
+			}
+			if !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("Failed to close http server", "error", err, "address", server.Addr)
+			}
+		}()
+	}
 
 	gracefullyShutdown := make(chan os.Signal, 1)
 	signal.Notify(gracefullyShutdown, syscall.SIGINT, syscall.SIGTERM)
 	<-gracefullyShutdown
-	logInfo.Println("Closing http server gracefully.")
-	shutdownServerCtx, closeServer := context.WithTimeout(context.Background(), 10*time.Second)
+	checker.Shutdown()
+	if drainDelay > 0 {
+		logger.Info("Draining connections before the shutdown", "delay", drainDelay)
+		time.Sleep(drainDelay)
+	}
+	logger.Info("Closing http server gracefully")
+	shutdownServerCtx, closeServer := context.WithTimeout(context.Background(), shutdownTimeout)
 	defer closeServer()
-	if err := server.Shutdown(shutdownServerCtx); err != nil {
-		logError.Println("Failed to shutdown gracefully http server:", err)
+	for _, server := range servers {
+		if err := server.Shutdown(shutdownServerCtx); err != nil {
+			logger.Error("Failed to shutdown gracefully http server", "error", err, "address", server.Addr)
+		}
 	}
 	fmt.Println("Bye...")
 }
@@ -214,14 +456,9 @@ func AsHTML(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-type CloserFunc func() error
-
-func (cf CloserFunc) Close() error {
-	return cf()
-}
-
 func TryTerminate(err error) {
 	if err != nil {
-		logError.Fatalln(err)
+		slog.Error("Terminating", "error", err)
+		os.Exit(1)
 	}
 }
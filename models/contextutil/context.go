@@ -2,14 +2,20 @@ package contextutil
 
 import (
 	"context"
+	"log/slog"
 
 	"github.com/twsm000/lenslocked/models/entities"
+	"github.com/twsm000/lenslocked/models/httpll"
+	"github.com/twsm000/lenslocked/pkg/i18n"
 )
 
 type ctxKey string
 
 const (
-	userKey ctxKey = "user"
+	userKey      ctxKey = "user"
+	flashesKey   ctxKey = "flashes"
+	localizerKey ctxKey = "localizer"
+	loggerKey    ctxKey = "logger"
 )
 
 // WithUser return a new context with user stored into it
@@ -23,6 +29,48 @@ func GetUser(ctx context.Context) (user *entities.User, ok bool) {
 	return
 }
 
+// WithFlashes return a new context with the flash messages stored into it
+func WithFlashes(ctx context.Context, flashes []httpll.Flash) context.Context {
+	return context.WithValue(ctx, flashesKey, flashes)
+}
+
+// GetFlashes extract the flash messages from the context
+func GetFlashes(ctx context.Context) (flashes []httpll.Flash, ok bool) {
+	flashes, ok = WithValueAs[[]httpll.Flash](ctx, flashesKey)
+	return
+}
+
+// WithLocalizer return a new context with the client localizer stored into it
+func WithLocalizer(ctx context.Context, localizer *i18n.Localizer) context.Context {
+	return context.WithValue(ctx, localizerKey, localizer)
+}
+
+// GetLocalizer extract the client localizer from the context
+func GetLocalizer(ctx context.Context) (localizer *i18n.Localizer, ok bool) {
+	localizer, ok = WithValueAs[*i18n.Localizer](ctx, localizerKey)
+	return
+}
+
+// WithLogger return a new context with the request scoped logger stored into it
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+// GetLogger extract the request scoped logger from the context
+func GetLogger(ctx context.Context) (logger *slog.Logger, ok bool) {
+	logger, ok = WithValueAs[*slog.Logger](ctx, loggerKey)
+	return
+}
+
+// Logger returns the request scoped logger, or the default logger when the
+// context has none
+func Logger(ctx context.Context) *slog.Logger {
+	if logger, ok := GetLogger(ctx); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
 // WithValueAs extract the value from the context with typesafe cast
 func WithValueAs[T any](ctx context.Context, key any) (t T, ok bool) {
 	t, ok = ctx.Value(key).(T)
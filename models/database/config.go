@@ -1,6 +1,183 @@
 package database
 
-type Config interface {
-	DriverName() string
-	DataSourceName() string
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+
+	"github.com/pressly/goose/v3"
+	"github.com/twsm000/lenslocked/models/sql/postgres/migrations"
+	sqlitemigrations "github.com/twsm000/lenslocked/models/sql/sqlite/migrations"
+	"github.com/twsm000/lenslocked/pkg/jsontime"
+	"github.com/twsm000/lenslocked/pkg/logging"
+	"github.com/twsm000/lenslocked/pkg/settings"
+)
+
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+var (
+	ErrInvalidDriver  = errors.New("invalid database driver")
+	ErrInvalidSSLMode = errors.New("invalid database ssl mode")
+)
+
+// sslModes are the sslmode values accepted by PostgreSQL
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// Config is the database the application connects to, on PostgreSQL or
+// SQLite
+type Config struct {
+	// Driver is postgres or sqlite. Empty is the same as postgres.
+	Driver   string `json:"driver"`
+	Host     string `json:"host"`
+	Port     uint16 `json:"port"`
+	User     string `json:"user"`
+	Password string `json:"password"`
+	// PasswordFile is read into the Password, like a mounted secret
+	PasswordFile string `json:"password_file"`
+	Database     string `json:"database"`
+	SSLMode      string `json:"ssl_mode"`
+	// Path is the database file of the sqlite driver, created when missing
+	Path string `json:"path"`
+	// QueryTimeout bounds every statement executed by the repositories,
+	// zero leaves them bound by the request only
+	QueryTimeout jsontime.Duration `json:"query_timeout"`
+}
+
+// LogValue logs the config with the password redacted
+func (c Config) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("driver", c.Driver),
+		slog.String("host", c.Host),
+		slog.Int("port", int(c.Port)),
+		slog.String("user", c.User),
+		slog.Any("password", logging.Secret(c.Password)),
+		slog.String("password_file", c.PasswordFile),
+		slog.String("database", c.Database),
+		slog.String("ssl_mode", c.SSLMode),
+		slog.String("path", c.Path),
+		slog.String("query_timeout", c.QueryTimeout.String()),
+	)
+}
+
+// Validate checks the settings of the selected driver, returning every
+// problem in a *settings.ValidationError. Possible errors:
+//   - settings.ErrInvalidSettings {ErrInvalidDriver, ErrInvalidSSLMode,
+//     settings.ErrRequired, settings.ErrInvalidPort}
+func (c Config) Validate() error {
+	var p settings.Problems
+	if c.Driver != "" {
+		p.OneOf("driver", c.Driver, ErrInvalidDriver, DriverPostgres, DriverSQLite)
+	}
+	if c.IsSQLite() {
+		p.Required("path", c.Path)
+	} else {
+		p.Required("host", c.Host)
+		p.Port("port", int(c.Port))
+		p.Required("user", c.User)
+		p.Required("database", c.Database)
+		if c.SSLMode != "" {
+			p.OneOf("ssl_mode", c.SSLMode, ErrInvalidSSLMode, sslModes...)
+		}
+	}
+	if c.QueryTimeout < 0 {
+		p.Addf("query_timeout", "must not be negative, 0s disables it")
+	}
+	return p.Err()
+}
+
+// IsSQLite reports if the sqlite driver is selected
+func (c Config) IsSQLite() bool {
+	return c.Driver == DriverSQLite
+}
+
+// DriverName returns the database/sql driver of the Driver: pgx for
+// postgres and sqlite, registered by modernc.org/sqlite, for sqlite
+func (c Config) DriverName() string {
+	switch c.Driver {
+	case "", DriverPostgres:
+		return "pgx"
+	default:
+		return c.Driver
+	}
+}
+
+// DataSourceName returns the connection string of the driver. The SQLite
+// database enforces the foreign keys, waits for the locks held by other
+// connections and takes the write lock when a transaction begins, since
+// upgrading a read transaction fails without waiting.
+func (c Config) DataSourceName() string {
+	if c.IsSQLite() {
+		return "file:" + c.Path +
+			"?_pragma=foreign_keys(1)" +
+			"&_pragma=busy_timeout(5000)" +
+			"&_pragma=journal_mode(WAL)" +
+			"&_time_format=sqlite" +
+			"&_txlock=immediate"
+	}
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		c.Host,
+		c.Port,
+		c.User,
+		c.Password,
+		c.Database,
+		c.SSLMode,
+	)
+}
+
+// Dialect returns the goose dialect of the driver
+func (c Config) Dialect() string {
+	if c.IsSQLite() {
+		return "sqlite3"
+	}
+	return "postgres"
+}
+
+// Migrations returns the migrations of the driver
+func (c Config) Migrations() fs.FS {
+	if c.IsSQLite() {
+		return sqlitemigrations.FS
+	}
+	return migrations.FS
+}
+
+// Migrate applies the pending migrations found in dir, written in the
+// goose dialect
+func Migrate(db *sql.DB, dialect, dir string) error {
+	if err := goose.SetDialect(dialect); err != nil {
+		return err
+	}
+
+	if err := goose.Up(db, dir); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// MigrateFS applies the pending migrations of the driver selected by
+// config, found in dir of its Migrations
+func MigrateFS(db *sql.DB, config Config, dir string) error {
+	if dir == "" {
+		dir = "."
+	}
+	goose.SetBaseFS(config.Migrations())
+	defer goose.SetBaseFS(nil) // undo the fs change
+	return Migrate(db, config.Dialect(), dir)
+}
+
+// RunMigrations runs the goose command, as up, down, status or redo, on
+// the migrations of the driver selected by config
+func RunMigrations(ctx context.Context, db *sql.DB, config Config, command string) error {
+	if err := goose.SetDialect(config.Dialect()); err != nil {
+		return err
+	}
+	goose.SetBaseFS(config.Migrations())
+	defer goose.SetBaseFS(nil) // undo the fs change
+	return goose.RunContext(ctx, command, db, ".")
 }
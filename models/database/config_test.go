@@ -0,0 +1,26 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twsm000/lenslocked/pkg/jsontime"
+	"github.com/twsm000/lenslocked/pkg/settings"
+)
+
+func TestConfigValidate(t *testing.T) {
+	valid := Config{Host: "localhost", Port: 5432, User: "lenslocked", Database: "lenslocked"}
+	assert.NoError(t, valid.Validate())
+	assert.NoError(t, Config{Driver: DriverSQLite, Path: "data/lenslocked.db"}.Validate())
+
+	err := Config{Driver: "mysql", SSLMode: "on", QueryTimeout: jsontime.Duration(-1)}.Validate()
+	assert.ErrorIs(t, err, settings.ErrInvalidSettings)
+	assert.ErrorIs(t, err, ErrInvalidDriver)
+	assert.ErrorIs(t, err, ErrInvalidSSLMode)
+	assert.ErrorIs(t, err, settings.ErrRequired)
+	assert.ErrorIs(t, err, settings.ErrInvalidPort)
+	assert.ErrorContains(t, err, "query_timeout")
+
+	err = Config{Driver: DriverSQLite}.Validate()
+	assert.EqualError(t, err, "invalid settings:\n  - path: required")
+}
@@ -0,0 +1,52 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/pressly/goose/v3"
+)
+
+var (
+	ErrMigrationsPending = errors.New("migrations pending")
+)
+
+// LatestVersion returns the version of the newest migration found in fsys
+func LatestVersion(fsys fs.FS) (int64, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return 0, err
+	}
+
+	var latest int64
+	for _, name := range names {
+		version, err := goose.NumericComponent(name)
+		if err != nil {
+			return 0, fmt.Errorf("migration %s: %w", name, err)
+		}
+		latest = max(latest, version)
+	}
+	return latest, nil
+}
+
+// CheckVersion possible errors:
+//   - ErrMigrationsPending, when the database version is not the latest
+//     migration found in fsys
+func CheckVersion(ctx context.Context, db *sql.DB, fsys fs.FS) error {
+	latest, err := LatestVersion(fsys)
+	if err != nil {
+		return err
+	}
+
+	current, err := goose.GetDBVersionContext(ctx, db)
+	if err != nil {
+		return err
+	}
+	if current != latest {
+		return fmt.Errorf("%w: database at version %d, latest is %d", ErrMigrationsPending, current, latest)
+	}
+	return nil
+}
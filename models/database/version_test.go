@@ -0,0 +1,29 @@
+package database
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twsm000/lenslocked/models/sql/postgres/migrations"
+)
+
+func TestLatestVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"00001_users.sql":    {},
+		"00012_sessions.sql": {},
+		"00003_avatars.sql":  {},
+		"README.md":          {},
+	}
+	version, err := LatestVersion(fsys)
+	require.NoError(t, err)
+	assert.EqualValues(t, 12, version)
+
+	_, err = LatestVersion(fstest.MapFS{"initial.sql": {}})
+	assert.Error(t, err)
+
+	version, err = LatestVersion(migrations.FS)
+	require.NoError(t, err)
+	assert.Positive(t, version)
+}
@@ -0,0 +1,91 @@
+package entities
+
+import "time"
+
+const (
+	// MaxUserAgentLength bounds the user agent stored in the audit events
+	MaxUserAgentLength = 512
+)
+
+// AuditAction identifies what was done in an AuditEvent
+type AuditAction string
+
+const (
+	AuditSignInSucceeded        AuditAction = "auth.sign_in.succeeded"
+	AuditSignInFailed           AuditAction = "auth.sign_in.failed"
+	AuditSignedOut              AuditAction = "auth.signed_out"
+	AuditPasswordResetRequested AuditAction = "auth.password_reset.requested"
+	AuditPasswordResetConsumed  AuditAction = "auth.password_reset.consumed"
+	AuditPasswordChanged        AuditAction = "auth.password.changed"
+
+	AuditUserSignedOut     AuditAction = "admin.user.signed_out"
+	AuditUserDisabled      AuditAction = "admin.user.disabled"
+	AuditUserEnabled       AuditAction = "admin.user.enabled"
+	AuditUserPasswordReset AuditAction = "admin.user.password_reset"
+	AuditUserPasswordSet   AuditAction = "admin.user.password_set"
+	AuditSessionsPurged    AuditAction = "admin.sessions.purged"
+)
+
+// AuditActions lists every action, in the order they are offered in filters
+var AuditActions = []AuditAction{
+	AuditSignInSucceeded,
+	AuditSignInFailed,
+	AuditSignedOut,
+	AuditPasswordResetRequested,
+	AuditPasswordResetConsumed,
+	AuditPasswordChanged,
+	AuditUserSignedOut,
+	AuditUserDisabled,
+	AuditUserEnabled,
+	AuditUserPasswordReset,
+	AuditUserPasswordSet,
+	AuditSessionsPurged,
+}
+
+const (
+	AuditTargetUser = "user"
+)
+
+// AuditSource identifies the request an audited action came from
+type AuditSource struct {
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+// AuditEvent records an action done by an user, the actor, on a target
+type AuditEvent struct {
+	ID        uint64
+	CreatedAt time.Time
+	// ActorID is nil when the actor is unknown, as in failed sign-ins
+	ActorID    *uint64
+	Action     AuditAction
+	TargetType string
+	TargetID   *uint64
+	Source     AuditSource
+	Details    map[string]string
+}
+
+// NewAuditEvent returns an event of the actor acting without a target.
+// The actor is nil when unknown.
+func NewAuditEvent(actor *User, source AuditSource, action AuditAction) *AuditEvent {
+	event := AuditEvent{
+		Action:  action,
+		Source:  source,
+		Details: map[string]string{},
+	}
+	if actor != nil {
+		actorID := actor.ID
+		event.ActorID = &actorID
+	}
+	return &event
+}
+
+// NewUserAuditEvent returns an event of the actor acting on the target
+// user. The actor is nil when unknown.
+func NewUserAuditEvent(actor *User, source AuditSource, action AuditAction, targetID uint64) *AuditEvent {
+	event := NewAuditEvent(actor, source, action)
+	event.TargetType = AuditTargetUser
+	event.TargetID = &targetID
+	return event
+}
@@ -2,6 +2,7 @@ package entities
 
 import (
 	"fmt"
+	"log/slog"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -20,6 +21,10 @@ func (rp RawPassword) AsBytes() []byte {
 	return []byte(rp)
 }
 
+func (rp RawPassword) LogValue() slog.Value {
+	return slog.StringValue(hiddenHash)
+}
+
 type Hash []byte
 
 func (h Hash) AsBytes() []byte {
@@ -30,11 +35,15 @@ func (h Hash) String() string {
 	return hiddenHash
 }
 
+func (h Hash) LogValue() slog.Value {
+	return slog.StringValue(hiddenHash)
+}
+
 // Compare possible errors:
 //   - ErrInvalidPassword
 func (h Hash) Compare(rawPassword RawPassword) Error {
 	if err := bcrypt.CompareHashAndPassword(h, rawPassword.AsBytes()); err != nil {
-		return NewClientError("The given password does not match with your current password.", ErrInvalidPassword, err)
+		return NewClientError("error.password.mismatch", ErrInvalidPassword, err)
 	}
 	return nil
 }
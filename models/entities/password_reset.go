@@ -1,6 +1,9 @@
 package entities
 
-import "time"
+import (
+	"log/slog"
+	"time"
+)
 
 const (
 	DefaultPasswordResetDuration = 1 * time.Hour
@@ -15,6 +18,15 @@ type PasswordReset struct {
 	ExpiresAt time.Time
 }
 
+// LogValue logs the password reset without the token
+func (pr *PasswordReset) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Uint64("id", pr.ID),
+		slog.Uint64("user_id", pr.UserID),
+		slog.Time("expires_at", pr.ExpiresAt),
+	)
+}
+
 // NewCreatablePasswordReset possible errors:
 //   - rand.ErrFailedToGenerateSlice
 //   - rand.ErrInvalidSizeUnexpected
@@ -0,0 +1,111 @@
+package entities
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	MaxDisplayNameLength = 64
+	MaxBioLength         = 500
+)
+
+var (
+	ErrInvalidUsername    = errors.New("invalid username")
+	ErrInvalidDisplayName = errors.New("invalid display name")
+	ErrInvalidBio         = errors.New("invalid bio")
+
+	// usernamePattern allows from 3 to 32 lowercase letters, digits and
+	// hyphens, not starting nor ending with a hyphen
+	usernamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,30}[a-z0-9]$`)
+)
+
+// Username is the unique slug of the user public profile. The zero
+// value means the user has not chosen one yet and is stored as NULL.
+type Username struct {
+	username string
+}
+
+func (u *Username) Set(username string) {
+	u.username = strings.ToLower(strings.TrimSpace(username))
+}
+
+func (u Username) IsEmpty() bool {
+	return u.username == ""
+}
+
+func (u Username) IsValid() bool {
+	return usernamePattern.MatchString(u.username)
+}
+
+func (u Username) String() string {
+	return u.username
+}
+
+func (u *Username) Scan(value any) error {
+	if value == nil {
+		u.username = ""
+		return nil
+	}
+
+	username, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("invalid scan type: %T", value)
+	}
+	u.Set(username)
+	return nil
+}
+
+func (u Username) Value() (driver.Value, error) {
+	if u.IsEmpty() {
+		return nil, nil
+	}
+	return u.username, nil
+}
+
+// Profile holds the public information of the user
+type Profile struct {
+	Username    Username
+	DisplayName string
+	Bio         string
+	// Avatar is the file name of the avatar image, empty when not set
+	Avatar string
+}
+
+// Name returns the display name or, when it is not set, the username
+func (p Profile) Name() string {
+	if p.DisplayName != "" {
+		return p.DisplayName
+	}
+	return p.Username.String()
+}
+
+type ProfileUpdatable struct {
+	Username    Username
+	DisplayName string
+	Bio         string
+}
+
+// ValidateProfile possible errors:
+//   - ErrInvalidUsername
+//   - ErrInvalidDisplayName
+//   - ErrInvalidBio
+func ValidateProfile(p *Profile) Error {
+	if !p.Username.IsEmpty() && !p.Username.IsValid() {
+		return NewClientError("error.profile.username_invalid", ErrInvalidUsername)
+	}
+
+	if utf8.RuneCountInString(p.DisplayName) > MaxDisplayNameLength {
+		return NewClientError("error.profile.display_name_too_long", ErrInvalidDisplayName)
+	}
+
+	if utf8.RuneCountInString(p.Bio) > MaxBioLength {
+		return NewClientError("error.profile.bio_too_long", ErrInvalidBio)
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package entities
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsername(t *testing.T) {
+	testCases := []struct {
+		username string
+		expected string
+		valid    bool
+	}{
+		{username: " Thomas-Moura ", expected: "thomas-moura", valid: true},
+		{username: "abc", expected: "abc", valid: true},
+		{username: "ab", expected: "ab", valid: false},
+		{username: "-abc", expected: "-abc", valid: false},
+		{username: "abc-", expected: "abc-", valid: false},
+		{username: "a_b_c", expected: "a_b_c", valid: false},
+		{username: "a/b", expected: "a/b", valid: false},
+		{username: strings.Repeat("a", 33), expected: strings.Repeat("a", 33), valid: false},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.username, func(t *testing.T) {
+			var u Username
+			u.Set(tC.username)
+			assert.Equal(t, tC.expected, u.String())
+			assert.Equal(t, tC.valid, u.IsValid())
+		})
+	}
+}
+
+func TestValidateProfile(t *testing.T) {
+	var profile Profile
+	assert.Nil(t, ValidateProfile(&profile), "the profile fields are optional")
+
+	profile.Username.Set("-")
+	err := ValidateProfile(&profile)
+	assert.True(t, err.Is(ErrInvalidUsername))
+	assert.True(t, err.IsClientErr())
+
+	profile.Username.Set("thomas")
+	profile.DisplayName = strings.Repeat("é", MaxDisplayNameLength)
+	assert.Nil(t, ValidateProfile(&profile), "the length is counted in characters")
+
+	profile.Bio = strings.Repeat("a", MaxBioLength+1)
+	err = ValidateProfile(&profile)
+	assert.True(t, err.Is(ErrInvalidBio))
+}
@@ -0,0 +1,68 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidRole = errors.New("invalid role")
+)
+
+// Permission is an action restricted to some roles
+type Permission string
+
+const (
+	PermissionViewAdmin    Permission = "admin.view"
+	PermissionManageUsers  Permission = "admin.users.manage"
+	PermissionViewAuditLog Permission = "admin.audit.view"
+)
+
+type Role string
+
+const (
+	RoleUser  Role = "user"
+	RoleAdmin Role = "admin"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleUser: nil,
+	RoleAdmin: {
+		PermissionViewAdmin,
+		PermissionManageUsers,
+		PermissionViewAuditLog,
+	},
+}
+
+// Can reports if the role is granted the permission
+func (r Role) Can(permission Permission) bool {
+	for _, p := range rolePermissions[r] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Role) IsValid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+func (r *Role) Scan(value any) error {
+	var role string
+	switch v := value.(type) {
+	case string:
+		role = v
+	case []byte:
+		role = string(v)
+	default:
+		return fmt.Errorf("invalid scan type: %T", value)
+	}
+
+	if !Role(role).IsValid() {
+		return fmt.Errorf("%w: %q", ErrInvalidRole, role)
+	}
+	*r = Role(role)
+	return nil
+}
@@ -0,0 +1,32 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleCan(t *testing.T) {
+	assert.True(t, RoleAdmin.Can(PermissionViewAdmin))
+	assert.True(t, RoleAdmin.Can(PermissionManageUsers))
+	assert.False(t, RoleUser.Can(PermissionViewAdmin))
+	assert.False(t, Role("root").Can(PermissionViewAdmin))
+}
+
+func TestRoleScan(t *testing.T) {
+	var role Role
+	assert.NoError(t, role.Scan("admin"))
+	assert.Equal(t, RoleAdmin, role)
+	assert.ErrorIs(t, role.Scan("root"), ErrInvalidRole)
+	assert.Error(t, role.Scan(1))
+}
+
+func TestDisabledUserHasNoPermissions(t *testing.T) {
+	user := User{Role: RoleAdmin}
+	assert.True(t, user.Can(PermissionViewAdmin))
+
+	now := time.Now()
+	user.DisabledAt = &now
+	assert.False(t, user.Can(PermissionViewAdmin))
+}
@@ -1,6 +1,7 @@
 package entities
 
 import (
+	"log/slog"
 	"time"
 )
 
@@ -12,6 +13,14 @@ type Session struct {
 	Token     SessionToken
 }
 
+// LogValue logs the session without the token
+func (s *Session) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Uint64("id", s.ID),
+		slog.Uint64("user_id", s.UserID),
+	)
+}
+
 // NewCreatableSession possible errors:
 //   - rand.ErrFailedToGenerateSlice
 //   - rand.ErrInvalidSizeUnexpected
@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"log/slog"
 
 	"github.com/twsm000/lenslocked/pkg/crypto/rand"
 )
@@ -72,6 +73,10 @@ func (st *SessionToken) String() string {
 	return hiddenHash
 }
 
+func (st SessionToken) LogValue() slog.Value {
+	return slog.StringValue(hiddenHash)
+}
+
 func (st *SessionToken) Scan(value any) error {
 	st.value = ""
 	if value == nil {
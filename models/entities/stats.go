@@ -0,0 +1,11 @@
+package entities
+
+// Stats are the system counters displayed in the admin dashboard
+type Stats struct {
+	Users                 int
+	Admins                int
+	DisabledUsers         int
+	NewUsersLastWeek      int
+	Sessions              int
+	PendingPasswordResets int
+}
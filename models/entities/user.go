@@ -1,6 +1,7 @@
 package entities
 
 import (
+	"log/slog"
 	"time"
 )
 
@@ -10,6 +11,34 @@ type User struct {
 	UpdatedAt *time.Time
 	Email     Email
 	Password  Hash
+	Profile   Profile
+	Role      Role
+	// DisabledAt is set when the account is disabled, disabled users
+	// cannot sign in
+	DisabledAt *time.Time
+	// Locale is the locale chosen by the user, empty until one is chosen.
+	// It localizes what is sent away from the requests, like the e-mails.
+	Locale string
+}
+
+// LogValue logs only the user identification, never the e-mail or
+// the password hash
+func (u *User) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Uint64("id", u.ID),
+		slog.String("role", string(u.Role)),
+	)
+}
+
+// IsDisabled reports if the account is disabled
+func (u *User) IsDisabled() bool {
+	return u.DisabledAt != nil
+}
+
+// Can reports if the user role is granted the permission.
+// Disabled users have no permissions.
+func (u *User) Can(permission Permission) bool {
+	return !u.IsDisabled() && u.Role.Can(permission)
 }
 
 // ValidateUser possible errors:
@@ -22,11 +51,11 @@ func ValidateUser(u *User) Error {
 	}
 
 	if u.Email.IsEmpty() {
-		return NewClientError("Email cannot be empty", ErrInvalidUserEmail)
+		return NewClientError("error.user.email_empty", ErrInvalidUserEmail)
 	}
 
 	if len(u.Password) == 0 {
-		return NewClientError("Password cannot be empty", ErrInvalidPassword)
+		return NewClientError("error.user.password_empty", ErrInvalidPassword)
 	}
 
 	return nil
@@ -45,6 +74,7 @@ type UserCreatable struct {
 func NewCreatableUser(input UserCreatable) (*User, Error) {
 	user := User{
 		Email: input.Email,
+		Role:  RoleUser,
 	}
 
 	if err := user.Password.GenerateFrom(input.Password); err != nil {
@@ -61,4 +91,6 @@ func NewCreatableUser(input UserCreatable) (*User, Error) {
 type UserAuthenticable struct {
 	Email    Email
 	Password RawPassword
+	// Source is recorded in the audit log with the authentication attempt
+	Source AuditSource
 }
@@ -0,0 +1,24 @@
+package httpll
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/twsm000/lenslocked/models/entities"
+)
+
+// NewAuditSource describes where the request came from to be recorded with
+// the audit events. The IP is taken from the RemoteAddr, so a proxy in front
+// of the server must be handled by a middleware like chi's RealIP.
+func NewAuditSource(r *http.Request) entities.AuditSource {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return entities.AuditSource{
+		IP:        ip,
+		UserAgent: r.UserAgent(),
+		RequestID: middleware.GetReqID(r.Context()),
+	}
+}
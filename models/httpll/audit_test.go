@@ -0,0 +1,33 @@
+package httpll
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAuditSource(t *testing.T) {
+	var r *http.Request
+	handler := middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r = req
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/signin", nil)
+	req.RemoteAddr = "203.0.113.7:52100"
+	req.Header.Set("User-Agent", "test-agent/1.0")
+	req.Header.Set(middleware.RequestIDHeader, "req-42")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	source := NewAuditSource(r)
+	assert.Equal(t, "203.0.113.7", source.IP)
+	assert.Equal(t, "test-agent/1.0", source.UserAgent)
+	assert.Equal(t, "req-42", source.RequestID)
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "@"
+	source = NewAuditSource(req)
+	assert.Equal(t, "@", source.IP)
+	assert.Empty(t, source.RequestID)
+}
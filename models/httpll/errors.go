@@ -0,0 +1,38 @@
+package httpll
+
+import (
+	"net/http"
+)
+
+func SendStatusInternalServerError(w http.ResponseWriter, r *http.Request) {
+	http.Error(
+		w,
+		http.StatusText(http.StatusInternalServerError),
+		http.StatusInternalServerError,
+	)
+}
+
+// statusKeys are the message key prefixes of the title and message
+// displayed for each status code
+var statusKeys = map[int]string{
+	http.StatusBadRequest:            "error.bad_request",
+	http.StatusUnauthorized:          "error.unauthorized",
+	http.StatusForbidden:             "error.forbidden",
+	http.StatusNotFound:              "error.not_found",
+	http.StatusMethodNotAllowed:      "error.method_not_allowed",
+	http.StatusRequestEntityTooLarge: "error.request_too_large",
+	http.StatusTooManyRequests:       "error.too_many_requests",
+	http.StatusInternalServerError:   "error.internal_server_error",
+}
+
+// StatusMessageKeys returns the message keys of the title and the message
+// displayed to the client for the status code. The statuses without their
+// own texts have the title of http.StatusText, returned with ok false, and
+// the message of the internal server error.
+func StatusMessageKeys(status int) (title, message string, ok bool) {
+	key, ok := statusKeys[status]
+	if !ok {
+		return http.StatusText(status), statusKeys[http.StatusInternalServerError] + ".message", false
+	}
+	return key + ".title", key + ".message", true
+}
@@ -0,0 +1,44 @@
+package httpll
+
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
+type FlashLevel string
+
+const (
+	FlashSuccess FlashLevel = "success"
+	FlashInfo    FlashLevel = "info"
+	FlashWarning FlashLevel = "warning"
+	FlashError   FlashLevel = "error"
+)
+
+// Flash is a message that survives a single redirect
+type Flash struct {
+	Level   FlashLevel `json:"level"`
+	Message string     `json:"message"`
+}
+
+// EncodeFlashes serializes the flashes into a cookie safe value
+func EncodeFlashes(flashes []Flash) (string, error) {
+	data, err := json.Marshal(flashes)
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(data), nil
+}
+
+// DecodeFlashes parses a value generated by EncodeFlashes
+func DecodeFlashes(value string) ([]Flash, error) {
+	data, err := base64.URLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var flashes []Flash
+	if err := json.Unmarshal(data, &flashes); err != nil {
+		return nil, err
+	}
+	return flashes, nil
+}
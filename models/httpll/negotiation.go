@@ -0,0 +1,104 @@
+package httpll
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// WantsJSON reports whether the client prefers a JSON response over HTML,
+// based on the ".json" suffix handled by JSONSuffix or the supported media
+// type with the highest quality in the Accept header. Between the same
+// qualities the first one found wins.
+func WantsJSON(r *http.Request) bool {
+	if jsonFormat, _ := r.Context().Value(jsonFormatKey).(bool); jsonFormat {
+		return true
+	}
+
+	wantsJSON := false
+	bestQuality := 0.0
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+			if err != nil {
+				continue
+			}
+
+			var isJSON bool
+			switch {
+			case mediaType == "application/json", strings.HasSuffix(mediaType, "+json"):
+				isJSON = true
+			case mediaType == "text/html":
+				isJSON = false
+			default:
+				continue
+			}
+			if quality := mediaQuality(params); quality > bestQuality {
+				wantsJSON, bestQuality = isJSON, quality
+			}
+		}
+	}
+	return wantsJSON
+}
+
+// mediaQuality returns the q parameter of a media range, 1 when missing and
+// 0, not acceptable, when invalid
+func mediaQuality(params map[string]string) float64 {
+	q, ok := params["q"]
+	if !ok {
+		return 1
+	}
+	quality, err := strconv.ParseFloat(q, 64)
+	if err != nil || quality < 0 || quality > 1 {
+		return 0
+	}
+	return quality
+}
+
+type ctxKey string
+
+const (
+	jsonFormatKey ctxKey = "json-format"
+)
+
+// JSONSuffix serves "/path.json" as "/path" and marks the request as
+// wanting JSON, so scripts can request any page without an Accept header.
+// It must be used before routing happens.
+func JSONSuffix(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		const suffix = ".json"
+		rctx := chi.RouteContext(r.Context())
+		path := RoutePath(r)
+		if !strings.HasSuffix(path, suffix) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		path = strings.TrimSuffix(path, suffix)
+		if path == "" {
+			path = "/"
+		}
+		r = r.WithContext(context.WithValue(r.Context(), jsonFormatKey, true))
+		if rctx != nil {
+			rctx.RoutePath = path
+		} else {
+			url := *r.URL
+			url.Path = path
+			r.URL = &url
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RoutePath returns the path the request is routed by, the one rewritten by
+// JSONSuffix, relative to the router it is mounted on
+func RoutePath(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePath != "" {
+		return rctx.RoutePath
+	}
+	return r.URL.Path
+}
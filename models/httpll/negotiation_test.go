@@ -0,0 +1,71 @@
+package httpll
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWantsJSON(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		accept string
+		want   bool
+	}{
+		{desc: "WithoutAcceptHeader", accept: "", want: false},
+		{desc: "Browser", accept: "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", want: false},
+		{desc: "JSON", accept: "application/json", want: true},
+		{desc: "JSONSuffixMediaType", accept: "application/problem+json", want: true},
+		{desc: "JSONBeforeHTML", accept: "application/json, text/html", want: true},
+		{desc: "HTMLBeforeJSON", accept: "text/html, application/json", want: false},
+		{desc: "Anything", accept: "*/*", want: false},
+		{desc: "HTMLWithHigherQuality", accept: "text/html;q=1, application/json;q=0.1", want: false},
+		{desc: "JSONWithHigherQuality", accept: "text/html;q=0.5, application/json", want: true},
+		{desc: "JSONNotAcceptable", accept: "application/json;q=0", want: false},
+		{desc: "SameQualityFirstWins", accept: "text/html;q=0.8, application/json;q=0.8", want: false},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tC.accept != "" {
+				r.Header.Set("Accept", tC.accept)
+			}
+			assert.Equal(t, tC.want, WantsJSON(r))
+		})
+	}
+}
+
+func TestJSONSuffix(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(JSONSuffix)
+	router.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Wants-JSON", strconv.FormatBool(WantsJSON(r)))
+	})
+	router.Get("/signup", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Wants-JSON", strconv.FormatBool(WantsJSON(r)))
+	})
+
+	testCases := []struct {
+		path      string
+		status    int
+		wantsJSON string
+	}{
+		{path: "/", status: http.StatusOK, wantsJSON: "false"},
+		{path: "/.json", status: http.StatusOK, wantsJSON: "true"},
+		{path: "/signup", status: http.StatusOK, wantsJSON: "false"},
+		{path: "/signup.json", status: http.StatusOK, wantsJSON: "true"},
+		{path: "/signin.json", status: http.StatusNotFound},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tC.path, nil))
+			assert.Equal(t, tC.status, w.Code)
+			assert.Equal(t, tC.wantsJSON, w.Header().Get("X-Wants-JSON"))
+		})
+	}
+}
@@ -6,10 +6,23 @@ var (
 	ErrDuplicateUserEmailNotAllowed = errors.New("duplicate user email not allowed")
 	ErrFailedToCreateUser           = errors.New("failed to create user")
 	ErrFailedToUpdateUserPassword   = errors.New("failed to update user password")
+	ErrDuplicateUsernameNotAllowed  = errors.New("duplicate username not allowed")
+	ErrFailedToUpdateUserProfile    = errors.New("failed to update user profile")
 	ErrFailedToCreateSession        = errors.New("failed to create session")
 	ErrFailedToDeleteSession        = errors.New("failed to delete session")
 	ErrFailedToCreatePasswordReset  = errors.New("failed to create password reset")
 	ErrFailedToDeletePasswordReset  = errors.New("failed to delete password reset")
+	ErrPasswordResetNotFound        = errors.New("password reset not found")
 	ErrFixedTokenSizeRequired       = errors.New("fixed token size required")
 	ErrUserNotFound                 = errors.New("user not found")
+	ErrFailedToFindUser             = errors.New("failed to find user")
+	ErrFailedToUpdateUser           = errors.New("failed to update user")
+	ErrFailedToSearchUsers          = errors.New("failed to search users")
+	ErrFailedToFindSessions         = errors.New("failed to find sessions")
+	ErrFailedToCountSessions        = errors.New("failed to count sessions")
+	ErrFailedToCreateAuditEvent     = errors.New("failed to create audit event")
+	ErrFailedToListAuditEvents      = errors.New("failed to list audit events")
+	ErrFailedToCollectStats         = errors.New("failed to collect stats")
+	ErrFailedToBeginTransaction     = errors.New("failed to begin transaction")
+	ErrFailedToCommitTransaction    = errors.New("failed to commit transaction")
 )
@@ -1,7 +1,9 @@
 package repositories
 
 import (
+	"context"
 	"io"
+	"strings"
 
 	"github.com/twsm000/lenslocked/models/entities"
 )
@@ -9,13 +11,35 @@ import (
 type User interface {
 	// Create possible errors:
 	//  - ErrFailedToCreateUser {ErrDuplicateUserEmailNotAllowed}
-	Create(user *entities.User) entities.Error
+	Create(ctx context.Context, user *entities.User) entities.Error
 	// FindByEmail possible errors:
 	//  - ErrUserNotFound
-	FindByEmail(email entities.Email) (*entities.User, entities.Error)
+	//  - ErrFailedToFindUser, when the query fails, like on a timeout
+	FindByEmail(ctx context.Context, email entities.Email) (*entities.User, entities.Error)
+	// FindByUsername possible errors:
+	//  - ErrUserNotFound
+	//  - ErrFailedToFindUser, when the query fails, like on a timeout
+	FindByUsername(ctx context.Context, username entities.Username) (*entities.User, entities.Error)
 	// UpdatePassword possible errors:
 	//  - ErrFailedToUpdateUserPassword
-	UpdatePassword(user *entities.User) error
+	UpdatePassword(ctx context.Context, user *entities.User) error
+	// UpdateProfile possible errors:
+	//  - ErrFailedToUpdateUserProfile {ErrDuplicateUsernameNotAllowed}
+	UpdateProfile(ctx context.Context, user *entities.User) entities.Error
+	// FindByID possible errors:
+	//  - ErrUserNotFound
+	//  - ErrFailedToFindUser, when the query fails, like on a timeout
+	FindByID(ctx context.Context, id uint64) (*entities.User, entities.Error)
+	// Search returns a page of the users matching the filter and the
+	// total of users matching it. Possible errors:
+	//  - ErrFailedToSearchUsers
+	Search(ctx context.Context, filter UserFilter) ([]entities.User, int, error)
+	// UpdateDisabledAt possible errors:
+	//  - ErrFailedToUpdateUser
+	UpdateDisabledAt(ctx context.Context, user *entities.User) error
+	// UpdateLocale possible errors:
+	//  - ErrFailedToUpdateUser {ErrUserNotFound}
+	UpdateLocale(ctx context.Context, user *entities.User) error
 
 	io.Closer
 }
@@ -23,17 +47,111 @@ type User interface {
 type Session interface {
 	// Create possible errors:
 	//   - ErrFailedToCreateSession {ErrFixedTokenSizeRequired, ErrUserNotFound}
-	Create(session *entities.Session) entities.Error
-	FindUserByToken(token entities.SessionToken) (*entities.User, error)
-	DeleteByToken(token entities.SessionToken) error
+	Create(ctx context.Context, session *entities.Session) entities.Error
+	// FindUserByToken possible errors:
+	//   - ErrUserNotFound
+	//   - ErrFailedToFindUser
+	FindUserByToken(ctx context.Context, token entities.SessionToken) (*entities.User, error)
+	DeleteByToken(ctx context.Context, token entities.SessionToken) error
+	// FindByUserID possible errors:
+	//   - ErrFailedToFindSessions
+	FindByUserID(ctx context.Context, userID uint64) ([]entities.Session, error)
+	// DeleteByUserID possible errors:
+	//   - ErrFailedToDeleteSession
+	DeleteByUserID(ctx context.Context, userID uint64) (int64, error)
+	// DeleteAll deletes every session and returns how many. Possible errors:
+	//   - ErrFailedToDeleteSession
+	DeleteAll(ctx context.Context) (int64, error)
+	// Count returns how many sessions there are. Possible errors:
+	//   - ErrFailedToCountSessions
+	Count(ctx context.Context) (int64, error)
 
 	io.Closer
 }
 
 type PasswordReset interface {
-	Create(reset *entities.PasswordReset) error
-	FindPasswordResetAndUserByToken(token entities.SessionToken) (*entities.PasswordReset, *entities.User, error)
-	DeleteByID(id uint64) error
+	Create(ctx context.Context, reset *entities.PasswordReset) error
+	// FindPasswordResetAndUserByToken possible errors:
+	//   - ErrUserNotFound
+	//   - ErrFailedToFindUser
+	FindPasswordResetAndUserByToken(ctx context.Context, token entities.SessionToken) (*entities.PasswordReset, *entities.User, error)
+	// DeleteByID fails when the password reset was already deleted, so a
+	// token is consumed only once, even by concurrent transactions.
+	// Possible errors:
+	//   - ErrFailedToDeletePasswordReset {ErrPasswordResetNotFound}
+	DeleteByID(ctx context.Context, id uint64) error
+
+	io.Closer
+}
+
+// Audit is append-only, the events cannot be changed once created
+type Audit interface {
+	// Create possible errors:
+	//   - ErrFailedToCreateAuditEvent
+	Create(ctx context.Context, event *entities.AuditEvent) error
+	// List returns a page of the events matching the filter, newest first,
+	// and the total of events matching it. Possible errors:
+	//   - ErrFailedToListAuditEvents
+	List(ctx context.Context, filter AuditFilter) ([]entities.AuditEvent, int, error)
+
+	io.Closer
+}
+
+type Stats interface {
+	// Stats possible errors:
+	//   - ErrFailedToCollectStats
+	Stats(ctx context.Context) (*entities.Stats, error)
 
 	io.Closer
 }
+
+// Transaction holds the repositories bound to one unit of work, every
+// operation done through them is committed or rolled back together
+type Transaction struct {
+	Users          User
+	Sessions       Session
+	PasswordResets PasswordReset
+	// Audit records the events committed with the actions they record
+	Audit Audit
+}
+
+type UnitOfWork interface {
+	// Do runs fn in a transaction, committed when fn returns nil and rolled
+	// back when it fails or panics. The repositories of tx must not be used
+	// after fn returns. Possible errors:
+	//   - the error returned by fn
+	//   - ErrFailedToBeginTransaction
+	//   - ErrFailedToCommitTransaction
+	Do(ctx context.Context, fn func(tx Transaction) error) error
+}
+
+// UserFilter selects a page of users. Query matches the email, username
+// or display name of the users, it matches every user when empty.
+type UserFilter struct {
+	Query  string
+	Limit  int
+	Offset int
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// Pattern returns the Query as a case insensitive LIKE pattern matching
+// anywhere in the text, empty when Query is empty.
+func (f UserFilter) Pattern() string {
+	query := strings.TrimSpace(f.Query)
+	if query == "" {
+		return ""
+	}
+	return "%" + likeEscaper.Replace(query) + "%"
+}
+
+// AuditFilter selects a page of audit events. The zero value fields
+// match every event.
+type AuditFilter struct {
+	Action entities.AuditAction
+	// UserID matches the events with the user as the actor or the target
+	UserID uint64
+	IP     string
+	Limit  int
+	Offset int
+}
@@ -0,0 +1,170 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/twsm000/lenslocked/models/entities"
+	"github.com/twsm000/lenslocked/models/repositories"
+)
+
+const (
+	// auditEventsFilter ignores the empty/zero parameters, $2 matches the
+	// events with the user as the actor or the target
+	auditEventsFilter = `
+		 WHERE ($1 = '' OR action = $1)
+		   AND ($2 = 0 OR actor_id = $2 OR (target_type = 'user' AND target_id = $2))
+		   AND ($3 = '' OR ip = $3)
+	`
+
+	listAuditEventsQuery = `
+		SELECT id,
+		       created_at,
+		       actor_id,
+		       action,
+		       target_type,
+		       target_id,
+		       ip,
+		       user_agent,
+		       request_id,
+		       details
+		  FROM audit_events
+		` + auditEventsFilter + `
+		 ORDER BY created_at DESC, id DESC
+		 LIMIT $4
+		OFFSET $5
+	`
+
+	countAuditEventsQuery = `
+		SELECT COUNT(*)
+		  FROM audit_events
+		` + auditEventsFilter
+)
+
+func NewAuditRepository(db *DB, d *Dialect) (repositories.Audit, error) {
+	insertStmt, err := d.prepare(db, "audit_events.insert", d.Queries.InsertAuditEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	listStmt, err := d.prepare(db, "audit_events.list", listAuditEventsQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	countStmt, err := d.prepare(db, "audit_events.count", countAuditEventsQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	return &auditRepository{
+		db:         db,
+		dialect:    d,
+		insertStmt: insertStmt,
+		listStmt:   listStmt,
+		countStmt:  countStmt,
+	}, nil
+}
+
+type auditRepository struct {
+	db         *DB
+	dialect    *Dialect
+	insertStmt *stmt
+	listStmt   *stmt
+	countStmt  *stmt
+}
+
+// withTx returns the repository with its statements bound to the transaction
+func (ar *auditRepository) withTx(ctx context.Context, tx *sql.Tx) *auditRepository {
+	return &auditRepository{
+		db:         ar.db,
+		dialect:    ar.dialect,
+		insertStmt: ar.insertStmt.WithTx(ctx, tx),
+		listStmt:   ar.listStmt.WithTx(ctx, tx),
+		countStmt:  ar.countStmt.WithTx(ctx, tx),
+	}
+}
+
+func (ar *auditRepository) Close() error {
+	return errors.Join(
+		ar.insertStmt.Close(),
+		ar.listStmt.Close(),
+		ar.countStmt.Close(),
+	)
+}
+
+// Create possible errors:
+//   - ErrFailedToCreateAuditEvent
+func (ar *auditRepository) Create(ctx context.Context, event *entities.AuditEvent) error {
+	details := []byte("{}")
+	if len(event.Details) > 0 {
+		var err error
+		if details, err = json.Marshal(event.Details); err != nil {
+			return errors.Join(repositories.ErrFailedToCreateAuditEvent, err)
+		}
+	}
+
+	args := ar.dialect.withNow(
+		event.ActorID,
+		event.Action,
+		event.TargetType,
+		event.TargetID,
+		event.Source.IP,
+		event.Source.UserAgent,
+		event.Source.RequestID,
+		string(details),
+	)
+	row := ar.insertStmt.QueryRowContext(ctx, args...)
+	if err := row.Scan(&event.ID, &event.CreatedAt); err != nil {
+		return errors.Join(repositories.ErrFailedToCreateAuditEvent, err)
+	}
+	return nil
+}
+
+// List possible errors:
+//   - ErrFailedToListAuditEvents
+func (ar *auditRepository) List(ctx context.Context, filter repositories.AuditFilter) ([]entities.AuditEvent, int, error) {
+	args := []any{filter.Action, filter.UserID, filter.IP}
+	var total int
+	if err := ar.countStmt.QueryRowContext(ctx, args...).Scan(&total); err != nil {
+		return nil, 0, errors.Join(repositories.ErrFailedToListAuditEvents, err)
+	}
+
+	rows, err := ar.listStmt.QueryContext(ctx, append(args, filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, errors.Join(repositories.ErrFailedToListAuditEvents, err)
+	}
+	defer rows.Close()
+
+	var events []entities.AuditEvent
+	for rows.Next() {
+		var event entities.AuditEvent
+		var details []byte
+		err := rows.Scan(
+			&event.ID,
+			&event.CreatedAt,
+			&event.ActorID,
+			&event.Action,
+			&event.TargetType,
+			&event.TargetID,
+			&event.Source.IP,
+			&event.Source.UserAgent,
+			&event.Source.RequestID,
+			&details,
+		)
+		if err != nil {
+			return nil, 0, errors.Join(repositories.ErrFailedToListAuditEvents, err)
+		}
+		if err := json.Unmarshal(details, &event.Details); err != nil {
+			return nil, 0, errors.Join(repositories.ErrFailedToListAuditEvents, err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, errors.Join(repositories.ErrFailedToListAuditEvents, err)
+	}
+
+	return events, total, nil
+}
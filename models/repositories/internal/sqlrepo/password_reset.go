@@ -0,0 +1,146 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+
+	"github.com/twsm000/lenslocked/models/entities"
+	"github.com/twsm000/lenslocked/models/repositories"
+)
+
+const (
+	queryFindPasswordResetAndUserByToken = `
+		SELECT pr.id,
+               pr.created_at,
+               pr.updated_at,
+               pr.user_id,
+               pr.token,
+               pr.expires_at,
+               ` + userColumns + `
+          FROM password_resets pr
+		 INNER JOIN users u
+		    ON u.id = pr.user_id
+		WHERE pr.token = $1
+	`
+
+	QueryDeletePasswordResetByID = `
+		DELETE FROM password_resets
+		 WHERE id = $1
+	`
+)
+
+func NewPasswordResetRepository(db *DB, d *Dialect, logger *slog.Logger) (repositories.PasswordReset, error) {
+	insertUpdateStmt, err := d.prepare(db, "password_resets.upsert", d.Queries.UpsertPasswordReset)
+	if err != nil {
+		return nil, err
+	}
+
+	findUserByTokenStmt, err := d.prepare(db, "password_resets.find_by_token", queryFindPasswordResetAndUserByToken)
+	if err != nil {
+		return nil, err
+	}
+
+	deleteByTokenStmt, err := d.prepare(db, "password_resets.delete_by_id", QueryDeletePasswordResetByID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &passwordResetRepository{
+		db:                             db,
+		dialect:                        d,
+		logger:                         logger,
+		insertUpdateStmt:               insertUpdateStmt,
+		findPasswordAndUserByTokenStmt: findUserByTokenStmt,
+		deleteByTokenStmt:              deleteByTokenStmt,
+	}, nil
+}
+
+type passwordResetRepository struct {
+	db                             *DB
+	dialect                        *Dialect
+	logger                         *slog.Logger
+	insertUpdateStmt               *stmt
+	findPasswordAndUserByTokenStmt *stmt
+	deleteByTokenStmt              *stmt
+}
+
+// withTx returns the repository with its statements bound to the transaction
+func (sr *passwordResetRepository) withTx(ctx context.Context, tx *sql.Tx) *passwordResetRepository {
+	return &passwordResetRepository{
+		db:                             sr.db,
+		dialect:                        sr.dialect,
+		logger:                         sr.logger,
+		insertUpdateStmt:               sr.insertUpdateStmt.WithTx(ctx, tx),
+		findPasswordAndUserByTokenStmt: sr.findPasswordAndUserByTokenStmt.WithTx(ctx, tx),
+		deleteByTokenStmt:              sr.deleteByTokenStmt.WithTx(ctx, tx),
+	}
+}
+
+func (sr *passwordResetRepository) Close() error {
+	return errors.Join(
+		sr.deleteByTokenStmt.Close(),
+		sr.findPasswordAndUserByTokenStmt.Close(),
+		sr.insertUpdateStmt.Close(),
+	)
+}
+
+// Create possible errors:
+//   - ErrFailedToCreatePasswordReset {ErrUserNotFound}
+func (sr *passwordResetRepository) Create(ctx context.Context, reset *entities.PasswordReset) error {
+	args := sr.dialect.withNow(reset.UserID, reset.Token.Hash(), reset.ExpiresAt.UTC())
+	if err := sr.insertUpdateStmt.QueryRowContext(ctx, args...).Scan(&reset.ID, &reset.CreatedAt, &reset.UpdatedAt); err != nil {
+		if sr.dialect.Classify(err) == ForeignKeyViolation {
+			return errors.Join(repositories.ErrFailedToCreatePasswordReset, repositories.ErrUserNotFound, err)
+		}
+		return errors.Join(repositories.ErrFailedToCreatePasswordReset, err)
+	}
+
+	return nil
+}
+
+func (sr *passwordResetRepository) FindPasswordResetAndUserByToken(
+	ctx context.Context, token entities.SessionToken) (*entities.PasswordReset, *entities.User, error) {
+	/*******************************************************************************/
+	row := sr.findPasswordAndUserByTokenStmt.QueryRowContext(ctx, token.Hash())
+	var passwordReset entities.PasswordReset
+	var user entities.User
+	dest := []any{
+		&passwordReset.ID,
+		&passwordReset.CreatedAt,
+		&passwordReset.UpdatedAt,
+		&passwordReset.UserID,
+		&passwordReset.Token,
+		&passwordReset.ExpiresAt,
+	}
+	err := row.Scan(append(dest, userScanDest(&user)...)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, errors.Join(repositories.ErrUserNotFound, err)
+	} else if err != nil {
+		return nil, nil, errors.Join(repositories.ErrFailedToFindUser, err)
+	}
+	return &passwordReset, &user, nil
+}
+
+func (sr *passwordResetRepository) DeleteByID(ctx context.Context, id uint64) error {
+	result, err := sr.deleteByTokenStmt.ExecContext(ctx, id)
+	if err != nil {
+		return errors.Join(repositories.ErrFailedToDeletePasswordReset, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil
+	}
+
+	switch rowsAffected {
+	case 0:
+		sr.logger.Warn("Password reset to delete not found", "password_reset_id", id)
+		return errors.Join(repositories.ErrFailedToDeletePasswordReset, repositories.ErrPasswordResetNotFound)
+	case 1:
+		sr.logger.Debug("Password reset deleted", "password_reset_id", id)
+	default:
+		sr.logger.Error("Failed to delete password reset", "password_reset_id", id, "rows_affected", rowsAffected)
+	}
+	return nil
+}
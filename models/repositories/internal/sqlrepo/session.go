@@ -0,0 +1,262 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+
+	"github.com/twsm000/lenslocked/models/entities"
+	"github.com/twsm000/lenslocked/models/repositories"
+)
+
+const (
+	// findUserBySessionTokenQuery ignores the sessions of disabled users
+	findUserBySessionTokenQuery = `
+		SELECT ` + userColumns + `
+          FROM sessions s
+		 INNER JOIN users u
+		    ON u.id = s.user_id
+		WHERE s.token = $1
+		  AND u.disabled_at IS NULL
+	`
+
+	findSessionsByUserIDQuery = `
+		SELECT id,
+		       created_at,
+		       updated_at,
+		       user_id
+		  FROM sessions
+		 WHERE user_id = $1
+		 ORDER BY id
+	`
+
+	deleteSessionsByUserIDQuery = `
+		DELETE FROM sessions
+		 WHERE user_id = $1
+	`
+
+	deleteAllSessionsQuery = `
+		DELETE FROM sessions
+	`
+
+	countSessionsQuery = `
+		SELECT COUNT(*)
+		  FROM sessions
+	`
+
+	deleteBySessionTokenQuery = `
+		DELETE FROM sessions
+		 WHERE token = $1
+	`
+)
+
+func NewSessionRepository(db *DB, d *Dialect, logger *slog.Logger) (repositories.Session, error) {
+	insertUpdateSessionStmt, err := d.prepare(db, "sessions.upsert", d.Queries.UpsertSession)
+	if err != nil {
+		return nil, err
+	}
+
+	findUserByTokenStmt, err := d.prepare(db, "sessions.find_user_by_token", findUserBySessionTokenQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	deleteByTokenStmt, err := d.prepare(db, "sessions.delete_by_token", deleteBySessionTokenQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	findByUserIDStmt, err := d.prepare(db, "sessions.find_by_user_id", findSessionsByUserIDQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	deleteByUserIDStmt, err := d.prepare(db, "sessions.delete_by_user_id", deleteSessionsByUserIDQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	deleteAllStmt, err := d.prepare(db, "sessions.delete_all", deleteAllSessionsQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	countStmt, err := d.prepare(db, "sessions.count", countSessionsQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sessionRepository{
+		db:                      db,
+		dialect:                 d,
+		logger:                  logger,
+		insertUpdateSessionStmt: insertUpdateSessionStmt,
+		findUserByTokenStmt:     findUserByTokenStmt,
+		deleteByTokenStmt:       deleteByTokenStmt,
+		findByUserIDStmt:        findByUserIDStmt,
+		deleteByUserIDStmt:      deleteByUserIDStmt,
+		deleteAllStmt:           deleteAllStmt,
+		countStmt:               countStmt,
+	}, nil
+}
+
+type sessionRepository struct {
+	db                      *DB
+	dialect                 *Dialect
+	logger                  *slog.Logger
+	insertUpdateSessionStmt *stmt
+	findUserByTokenStmt     *stmt
+	deleteByTokenStmt       *stmt
+	findByUserIDStmt        *stmt
+	deleteByUserIDStmt      *stmt
+	deleteAllStmt           *stmt
+	countStmt               *stmt
+}
+
+// withTx returns the repository with its statements bound to the transaction
+func (sr *sessionRepository) withTx(ctx context.Context, tx *sql.Tx) *sessionRepository {
+	return &sessionRepository{
+		db:                      sr.db,
+		dialect:                 sr.dialect,
+		logger:                  sr.logger,
+		insertUpdateSessionStmt: sr.insertUpdateSessionStmt.WithTx(ctx, tx),
+		findUserByTokenStmt:     sr.findUserByTokenStmt.WithTx(ctx, tx),
+		deleteByTokenStmt:       sr.deleteByTokenStmt.WithTx(ctx, tx),
+		findByUserIDStmt:        sr.findByUserIDStmt.WithTx(ctx, tx),
+		deleteByUserIDStmt:      sr.deleteByUserIDStmt.WithTx(ctx, tx),
+		deleteAllStmt:           sr.deleteAllStmt.WithTx(ctx, tx),
+		countStmt:               sr.countStmt.WithTx(ctx, tx),
+	}
+}
+
+func (sr *sessionRepository) Close() error {
+	return errors.Join(
+		sr.deleteByTokenStmt.Close(),
+		sr.findUserByTokenStmt.Close(),
+		sr.insertUpdateSessionStmt.Close(),
+		sr.findByUserIDStmt.Close(),
+		sr.deleteByUserIDStmt.Close(),
+		sr.deleteAllStmt.Close(),
+		sr.countStmt.Close(),
+	)
+}
+
+// Create possible errors:
+//   - ErrFailedToCreateSession {ErrFixedTokenSizeRequired, ErrUserNotFound}
+func (sr *sessionRepository) Create(ctx context.Context, session *entities.Session) entities.Error {
+	args := sr.dialect.withNow(session.UserID, session.Token.Hash())
+	row := sr.insertUpdateSessionStmt.QueryRowContext(ctx, args...)
+	if err := row.Scan(&session.ID, &session.CreatedAt, &session.UpdatedAt); err != nil {
+		switch sr.dialect.Classify(err) {
+		case CheckViolation:
+			return entities.NewError(
+				repositories.ErrFailedToCreateSession,
+				repositories.ErrFixedTokenSizeRequired,
+				err,
+			)
+		case ForeignKeyViolation:
+			return entities.NewClientError(
+				"error.user.not_found",
+				repositories.ErrFailedToCreateSession,
+				repositories.ErrUserNotFound,
+				err,
+			)
+		}
+		return entities.NewError(repositories.ErrFailedToCreateSession, err)
+	}
+
+	return nil
+}
+
+func (sr *sessionRepository) FindUserByToken(ctx context.Context, token entities.SessionToken) (*entities.User, error) {
+	row := sr.findUserByTokenStmt.QueryRowContext(ctx, token.Hash())
+	var user entities.User
+	err := row.Scan(userScanDest(&user)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.Join(repositories.ErrUserNotFound, err)
+	} else if err != nil {
+		return nil, errors.Join(repositories.ErrFailedToFindUser, err)
+	}
+	return &user, nil
+}
+
+func (sr *sessionRepository) DeleteByToken(ctx context.Context, token entities.SessionToken) error {
+	result, err := sr.deleteByTokenStmt.ExecContext(ctx, token.Hash())
+	if err != nil {
+		return errors.Join(repositories.ErrFailedToDeleteSession, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil
+	}
+
+	// the token is a credential, it is never logged
+	switch rowsAffected {
+	case 0:
+		sr.logger.Warn("Session to delete not found")
+	case 1:
+		sr.logger.Debug("Session deleted")
+	default:
+		sr.logger.Error("Failed to delete session", "rows_affected", rowsAffected)
+	}
+	return nil
+}
+
+// FindByUserID returns the sessions of the user, without the tokens
+// that are stored hashed.
+func (sr *sessionRepository) FindByUserID(ctx context.Context, userID uint64) ([]entities.Session, error) {
+	rows, err := sr.findByUserIDStmt.QueryContext(ctx, userID)
+	if err != nil {
+		return nil, errors.Join(repositories.ErrFailedToFindSessions, err)
+	}
+	defer rows.Close()
+
+	var sessions []entities.Session
+	for rows.Next() {
+		var session entities.Session
+		if err := rows.Scan(&session.ID, &session.CreatedAt, &session.UpdatedAt, &session.UserID); err != nil {
+			return nil, errors.Join(repositories.ErrFailedToFindSessions, err)
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Join(repositories.ErrFailedToFindSessions, err)
+	}
+	return sessions, nil
+}
+
+// DeleteByUserID deletes every session of the user and returns how many
+func (sr *sessionRepository) DeleteByUserID(ctx context.Context, userID uint64) (int64, error) {
+	result, err := sr.deleteByUserIDStmt.ExecContext(ctx, userID)
+	if err != nil {
+		return 0, errors.Join(repositories.ErrFailedToDeleteSession, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, nil
+	}
+	return rowsAffected, nil
+}
+
+// DeleteAll deletes every session and returns how many
+func (sr *sessionRepository) DeleteAll(ctx context.Context) (int64, error) {
+	result, err := sr.deleteAllStmt.ExecContext(ctx)
+	if err != nil {
+		return 0, errors.Join(repositories.ErrFailedToDeleteSession, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, nil
+	}
+	return rowsAffected, nil
+}
+
+// Count returns how many sessions there are
+func (sr *sessionRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	if err := sr.countStmt.QueryRowContext(ctx).Scan(&count); err != nil {
+		return 0, errors.Join(repositories.ErrFailedToCountSessions, err)
+	}
+	return count, nil
+}
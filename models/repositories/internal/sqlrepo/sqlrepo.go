@@ -0,0 +1,96 @@
+// Package sqlrepo implements the repositories on the SQL databases. What
+// differs between them, the queries writing timestamps or matching
+// patterns and the errors of the constraints, is given by a Dialect.
+package sqlrepo
+
+import (
+	"time"
+
+	"github.com/twsm000/lenslocked/models/repositories/internal/sqlstmt"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DB is the database the repositories prepare their statements on
+type DB = sqlstmt.DB
+
+type stmt = sqlstmt.Stmt
+
+// Violation is the kind of constraint a statement failed to satisfy
+type Violation int
+
+const (
+	NoViolation Violation = iota
+	UniqueViolation
+	ForeignKeyViolation
+	CheckViolation
+)
+
+// Dialect is a SQL database the repositories run on
+type Dialect struct {
+	// System identifies the database in the spans, as
+	// semconv.DBSystemPostgreSQL
+	System  attribute.KeyValue
+	Queries Queries
+	// Now returns the current time bound as the last argument of the
+	// queries writing timestamps, nil when the queries use the clock of
+	// the database
+	Now func() time.Time
+	// Classify returns the kind of constraint violated by the statement
+	// failed with err, NoViolation for the other errors
+	Classify func(err error) Violation
+}
+
+// Queries are the statements written differently by each database, the
+// others are shared by every dialect
+type Queries struct {
+	// InsertUser takes the email, password and role, returning the id and
+	// created_at
+	InsertUser string
+	// SearchUsersFilter matches every user when $1 is empty, otherwise the
+	// users with email, username or display name matching the pattern $1
+	// ignoring the case. The users table is aliased as u.
+	SearchUsersFilter string
+	// UpdateUserProfile takes the id, username, display name, bio and
+	// avatar, returning the updated_at
+	UpdateUserProfile string
+	// UpdateUserDisabledAt takes the id and the disabled_at, returning the
+	// updated_at
+	UpdateUserDisabledAt string
+	// UpsertSession takes the user id and the token hash, replacing the
+	// session of the user, returning the id, created_at and updated_at
+	UpsertSession string
+	// UpsertPasswordReset takes the user id, the token hash and the
+	// expires_at, replacing the reset of the user, returning the id,
+	// created_at and updated_at
+	UpsertPasswordReset string
+	// InsertAuditEvent takes the actor id, action, target type, target id,
+	// ip, user agent, request id and details, returning the id and
+	// created_at
+	InsertAuditEvent string
+	// Stats returns the counts of entities.Stats in order. When the dialect
+	// has a Now, it takes the current time and the time a week ago.
+	Stats string
+}
+
+// prepare creates the prepared statement, name identifies its spans
+func (d *Dialect) prepare(db *DB, name, query string) (*stmt, error) {
+	return sqlstmt.Prepare(db, d.System, name, query)
+}
+
+// withNow appends the current time to args when the dialect binds it
+func (d *Dialect) withNow(args ...any) []any {
+	if d.Now == nil {
+		return args
+	}
+	return append(args, d.Now())
+}
+
+// utc returns t in UTC, nil when t is nil. The timestamps are bound in UTC
+// since some databases store them as text, compared and sorted as such.
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
@@ -0,0 +1,56 @@
+package sqlrepo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/twsm000/lenslocked/models/entities"
+	"github.com/twsm000/lenslocked/models/repositories"
+)
+
+func NewStatsRepository(db *DB, d *Dialect) (repositories.Stats, error) {
+	statsStmt, err := d.prepare(db, "stats.collect", d.Queries.Stats)
+	if err != nil {
+		return nil, err
+	}
+
+	return &statsRepository{
+		db:        db,
+		dialect:   d,
+		statsStmt: statsStmt,
+	}, nil
+}
+
+type statsRepository struct {
+	db        *DB
+	dialect   *Dialect
+	statsStmt *stmt
+}
+
+func (sr *statsRepository) Close() error {
+	return sr.statsStmt.Close()
+}
+
+// Stats possible errors:
+//   - ErrFailedToCollectStats
+func (sr *statsRepository) Stats(ctx context.Context) (*entities.Stats, error) {
+	var args []any
+	if sr.dialect.Now != nil {
+		at := sr.dialect.Now()
+		args = []any{at, at.AddDate(0, 0, -7)}
+	}
+
+	var stats entities.Stats
+	err := sr.statsStmt.QueryRowContext(ctx, args...).Scan(
+		&stats.Users,
+		&stats.Admins,
+		&stats.DisabledUsers,
+		&stats.NewUsersLastWeek,
+		&stats.Sessions,
+		&stats.PendingPasswordResets,
+	)
+	if err != nil {
+		return nil, errors.Join(repositories.ErrFailedToCollectStats, err)
+	}
+	return &stats, nil
+}
@@ -0,0 +1,182 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twsm000/lenslocked/models/repositories"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// fakeDriver executes every statement without a database. While blocking,
+// the statements wait for their context to be done, like a slow query
+// aborted by the driver.
+type fakeDriver struct {
+	blocking atomic.Bool
+	// started is signalled when a blocking statement starts waiting
+	started chan struct{}
+	aborted atomic.Int32
+	// commits and rollbacks count the ended transactions
+	commits   atomic.Int32
+	rollbacks atomic.Int32
+	// columns and values are the result of every query
+	columns []string
+	values  [][]driver.Value
+}
+
+// testDialect runs the statements on the fakeDriver
+var testDialect = &Dialect{
+	System:   semconv.DBSystemOtherSQL,
+	Now:      time.Now,
+	Classify: func(error) Violation { return NoViolation },
+}
+
+func newFakeDB(t *testing.T, fd *fakeDriver, timeout time.Duration) *DB {
+	t.Helper()
+	fd.started = make(chan struct{}, 1)
+	db := sql.OpenDB(fd)
+	t.Cleanup(func() { db.Close() })
+	return &DB{DB: db, QueryTimeout: timeout}
+}
+
+func (fd *fakeDriver) Connect(context.Context) (driver.Conn, error) { return fakeConn{fd}, nil }
+func (fd *fakeDriver) Driver() driver.Driver                        { return fd }
+func (fd *fakeDriver) Open(string) (driver.Conn, error)             { return fakeConn{fd}, nil }
+
+// wait blocks until ctx is done when the driver is blocking
+func (fd *fakeDriver) wait(ctx context.Context) error {
+	if !fd.blocking.Load() {
+		return nil
+	}
+	fd.started <- struct{}{}
+	<-ctx.Done()
+	fd.aborted.Add(1)
+	return ctx.Err()
+}
+
+type fakeConn struct{ fd *fakeDriver }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return fakeStmt(c), nil }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)           { return fakeTx(c), nil }
+
+type fakeTx struct{ fd *fakeDriver }
+
+func (tx fakeTx) Commit() error   { tx.fd.commits.Add(1); return nil }
+func (tx fakeTx) Rollback() error { tx.fd.rollbacks.Add(1); return nil }
+
+type fakeStmt struct{ fd *fakeDriver }
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec([]driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), nil)
+}
+
+func (s fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), nil)
+}
+
+func (s fakeStmt) ExecContext(ctx context.Context, _ []driver.NamedValue) (driver.Result, error) {
+	if err := s.fd.wait(ctx); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s fakeStmt) QueryContext(ctx context.Context, _ []driver.NamedValue) (driver.Rows, error) {
+	if err := s.fd.wait(ctx); err != nil {
+		return nil, err
+	}
+	return &fakeRows{columns: s.fd.columns, values: s.fd.values}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func TestCancelledRequestAbortsQuery(t *testing.T) {
+	fd := &fakeDriver{}
+	repo, err := NewStatsRepository(newFakeDB(t, fd, 0), testDialect)
+	require.NoError(t, err)
+	fd.blocking.Store(true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-fd.started
+		cancel() // the client went away
+	}()
+	_, err = repo.Stats(ctx)
+	assert.ErrorIs(t, err, repositories.ErrFailedToCollectStats)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int32(1), fd.aborted.Load())
+}
+
+func TestCancelledRequestAbortsExec(t *testing.T) {
+	fd := &fakeDriver{}
+	repo, err := NewSessionRepository(newFakeDB(t, fd, 0), testDialect, nil)
+	require.NoError(t, err)
+	fd.blocking.Store(true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-fd.started
+		cancel()
+	}()
+	_, err = repo.DeleteByUserID(ctx, 7)
+	assert.ErrorIs(t, err, repositories.ErrFailedToDeleteSession)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int32(1), fd.aborted.Load())
+}
+
+func TestQueryTimeout(t *testing.T) {
+	fd := &fakeDriver{}
+	repo, err := NewStatsRepository(newFakeDB(t, fd, 10*time.Millisecond), testDialect)
+	require.NoError(t, err)
+	fd.blocking.Store(true)
+
+	_, err = repo.Stats(context.Background())
+	assert.ErrorIs(t, err, repositories.ErrFailedToCollectStats)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(1), fd.aborted.Load())
+}
+
+func TestQueryTimeoutCoversReadingRows(t *testing.T) {
+	now := time.Now()
+	fd := &fakeDriver{
+		columns: []string{"id", "created_at", "updated_at", "user_id"},
+		values: [][]driver.Value{
+			{int64(1), now, nil, int64(7)},
+			{int64(2), now, now, int64(7)},
+		},
+	}
+	repo, err := NewSessionRepository(newFakeDB(t, fd, time.Minute), testDialect, nil)
+	require.NoError(t, err)
+
+	sessions, err := repo.FindByUserID(context.Background(), 7)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, []uint64{1, 2}, []uint64{sessions[0].ID, sessions[1].ID})
+	assert.Equal(t, uint64(7), sessions[1].UserID)
+}
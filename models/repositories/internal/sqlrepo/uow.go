@@ -0,0 +1,66 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/twsm000/lenslocked/models/repositories"
+	"github.com/twsm000/lenslocked/models/repositories/internal/sqlstmt"
+)
+
+var (
+	ErrForeignRepository = errors.New("repository not created by the dialect of the unit of work")
+)
+
+// NewUnitOfWork runs the operations of the repositories in transactions,
+// binding their prepared statements to each transaction.
+//
+// Possible errors:
+//   - ErrForeignRepository
+func NewUnitOfWork(
+	db *DB,
+	d *Dialect,
+	users repositories.User,
+	sessions repositories.Session,
+	passwordResets repositories.PasswordReset,
+	audit repositories.Audit) (repositories.UnitOfWork, error) {
+	/***************************************************/
+	uow := unitOfWork{db: db}
+	var ok bool
+	if uow.users, ok = users.(*userRepository); !ok || uow.users.dialect != d {
+		return nil, ErrForeignRepository
+	}
+	if uow.sessions, ok = sessions.(*sessionRepository); !ok || uow.sessions.dialect != d {
+		return nil, ErrForeignRepository
+	}
+	if uow.passwordResets, ok = passwordResets.(*passwordResetRepository); !ok || uow.passwordResets.dialect != d {
+		return nil, ErrForeignRepository
+	}
+	if uow.audit, ok = audit.(*auditRepository); !ok || uow.audit.dialect != d {
+		return nil, ErrForeignRepository
+	}
+	return &uow, nil
+}
+
+type unitOfWork struct {
+	db             *DB
+	users          *userRepository
+	sessions       *sessionRepository
+	passwordResets *passwordResetRepository
+	audit          *auditRepository
+}
+
+func (uow *unitOfWork) Do(ctx context.Context, fn func(tx repositories.Transaction) error) error {
+	return sqlstmt.Transact(ctx, uow.db, uow.bind, fn)
+}
+
+// bind returns the repositories with their statements bound to tx
+func (uow *unitOfWork) bind(ctx context.Context, tx *sql.Tx) repositories.Transaction {
+	return repositories.Transaction{
+		Users:          uow.users.withTx(ctx, tx),
+		Sessions:       uow.sessions.withTx(ctx, tx),
+		PasswordResets: uow.passwordResets.withTx(ctx, tx),
+		Audit:          uow.audit.withTx(ctx, tx),
+	}
+}
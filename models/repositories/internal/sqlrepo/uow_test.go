@@ -0,0 +1,121 @@
+package sqlrepo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twsm000/lenslocked/models/repositories"
+)
+
+func newFakeUnitOfWork(t *testing.T, fd *fakeDriver) repositories.UnitOfWork {
+	t.Helper()
+	db := newFakeDB(t, fd, 0)
+	users, err := NewUserRepository(db, testDialect)
+	require.NoError(t, err)
+	sessions, err := NewSessionRepository(db, testDialect, nil)
+	require.NoError(t, err)
+	passwordResets, err := NewPasswordResetRepository(db, testDialect, nil)
+	require.NoError(t, err)
+	audit, err := NewAuditRepository(db, testDialect)
+	require.NoError(t, err)
+	uow, err := NewUnitOfWork(db, testDialect, users, sessions, passwordResets, audit)
+	require.NoError(t, err)
+	return uow
+}
+
+func TestUnitOfWorkCommits(t *testing.T) {
+	fd := &fakeDriver{}
+	uow := newFakeUnitOfWork(t, fd)
+
+	var deleted int64
+	err := uow.Do(context.Background(), func(tx repositories.Transaction) error {
+		var err error
+		deleted, err = tx.Sessions.DeleteByUserID(context.Background(), 7)
+		return err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	assert.Equal(t, int32(1), fd.commits.Load())
+	assert.Equal(t, int32(0), fd.rollbacks.Load())
+}
+
+func TestUnitOfWorkRollsBack(t *testing.T) {
+	fd := &fakeDriver{}
+	uow := newFakeUnitOfWork(t, fd)
+
+	errFailed := errors.New("failed midway")
+	err := uow.Do(context.Background(), func(tx repositories.Transaction) error {
+		if _, err := tx.Sessions.DeleteByUserID(context.Background(), 7); err != nil {
+			return err
+		}
+		return errFailed
+	})
+	assert.ErrorIs(t, err, errFailed)
+	assert.Equal(t, int32(0), fd.commits.Load())
+	assert.Equal(t, int32(1), fd.rollbacks.Load())
+
+	assert.Panics(t, func() {
+		uow.Do(context.Background(), func(tx repositories.Transaction) error {
+			panic("unexpected")
+		})
+	})
+	assert.Equal(t, int32(0), fd.commits.Load())
+	assert.Equal(t, int32(2), fd.rollbacks.Load())
+}
+
+func TestUnitOfWorkAbortedByCancelledContext(t *testing.T) {
+	fd := &fakeDriver{}
+	uow := newFakeUnitOfWork(t, fd)
+	fd.blocking.Store(true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-fd.started
+		cancel()
+	}()
+	err := uow.Do(ctx, func(tx repositories.Transaction) error {
+		_, err := tx.Sessions.DeleteByUserID(ctx, 7)
+		return err
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int32(0), fd.commits.Load())
+	assert.Equal(t, int32(1), fd.aborted.Load())
+}
+
+type foreignSessions struct {
+	repositories.Session
+}
+
+func TestNewUnitOfWorkRequiresItsRepositories(t *testing.T) {
+	db := newFakeDB(t, &fakeDriver{}, 0)
+	users, err := NewUserRepository(db, testDialect)
+	require.NoError(t, err)
+	passwordResets, err := NewPasswordResetRepository(db, testDialect, nil)
+	require.NoError(t, err)
+
+	audit, err := NewAuditRepository(db, testDialect)
+	require.NoError(t, err)
+
+	_, err = NewUnitOfWork(db, testDialect, users, foreignSessions{}, passwordResets, audit)
+	assert.ErrorIs(t, err, ErrForeignRepository)
+}
+
+func TestNewUnitOfWorkRequiresTheSameDialect(t *testing.T) {
+	db := newFakeDB(t, &fakeDriver{}, 0)
+	users, err := NewUserRepository(db, testDialect)
+	require.NoError(t, err)
+	otherDialect := *testDialect
+	sessions, err := NewSessionRepository(db, &otherDialect, nil)
+	require.NoError(t, err)
+	passwordResets, err := NewPasswordResetRepository(db, testDialect, nil)
+	require.NoError(t, err)
+
+	audit, err := NewAuditRepository(db, testDialect)
+	require.NoError(t, err)
+
+	_, err = NewUnitOfWork(db, testDialect, users, sessions, passwordResets, audit)
+	assert.ErrorIs(t, err, ErrForeignRepository)
+}
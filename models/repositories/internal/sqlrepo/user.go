@@ -0,0 +1,365 @@
+package sqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/twsm000/lenslocked/models/entities"
+	"github.com/twsm000/lenslocked/models/repositories"
+)
+
+const (
+	// userColumns are the columns scanned by userScanDest, the users
+	// table must be aliased as u
+	userColumns = `
+		u.id,
+		u.created_at,
+		u.updated_at,
+		u.email,
+		u.password,
+		u.username,
+		u.display_name,
+		u.bio,
+		u.avatar,
+		u.role,
+		u.disabled_at,
+		u.locale
+	`
+
+	findUserByEmailQuery = `
+		SELECT ` + userColumns + `
+		  FROM users u
+		 WHERE u.email = $1
+	`
+
+	findUserByUsernameQuery = `
+		SELECT ` + userColumns + `
+		  FROM users u
+		 WHERE u.username = $1
+	`
+
+	findUserByIDQuery = `
+		SELECT ` + userColumns + `
+		  FROM users u
+		 WHERE u.id = $1
+	`
+
+	updateUserPasswordQuery = `
+		UPDATE users
+		   SET password = $2
+		 WHERE id = $1
+	`
+
+	updateUserLocaleQuery = `
+		UPDATE users
+		   SET locale = $2
+		 WHERE id = $1
+	`
+)
+
+// searchUsersQuery returns the query of a page of the users matched by the
+// SearchUsersFilter of a dialect
+func searchUsersQuery(filter string) string {
+	return `
+		SELECT ` + userColumns + `
+		  FROM users u
+		` + filter + `
+		 ORDER BY u.id
+		 LIMIT $2
+		OFFSET $3
+	`
+}
+
+// countUsersQuery returns the query of the count of the users matched by
+// the SearchUsersFilter of a dialect
+func countUsersQuery(filter string) string {
+	return `
+		SELECT COUNT(*)
+		  FROM users u
+		` + filter
+}
+
+// userScanDest returns the destinations to scan the userColumns into
+func userScanDest(user *entities.User) []any {
+	return []any{
+		&user.ID,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.Email,
+		&user.Password,
+		&user.Profile.Username,
+		&user.Profile.DisplayName,
+		&user.Profile.Bio,
+		&user.Profile.Avatar,
+		&user.Role,
+		&user.DisabledAt,
+		&user.Locale,
+	}
+}
+
+func NewUserRepository(db *DB, d *Dialect) (repositories.User, error) {
+	insertUserStmt, err := d.prepare(db, "users.insert", d.Queries.InsertUser)
+	if err != nil {
+		return nil, err
+	}
+
+	findUserByEmailStmt, err := d.prepare(db, "users.find_by_email", findUserByEmailQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	updateUserPasswordStmt, err := d.prepare(db, "users.update_password", updateUserPasswordQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	findUserByUsernameStmt, err := d.prepare(db, "users.find_by_username", findUserByUsernameQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	updateUserProfileStmt, err := d.prepare(db, "users.update_profile", d.Queries.UpdateUserProfile)
+	if err != nil {
+		return nil, err
+	}
+
+	findUserByIDStmt, err := d.prepare(db, "users.find_by_id", findUserByIDQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	searchUsersStmt, err := d.prepare(db, "users.search", searchUsersQuery(d.Queries.SearchUsersFilter))
+	if err != nil {
+		return nil, err
+	}
+
+	countUsersStmt, err := d.prepare(db, "users.count", countUsersQuery(d.Queries.SearchUsersFilter))
+	if err != nil {
+		return nil, err
+	}
+
+	updateUserDisabledAtStmt, err := d.prepare(db, "users.update_disabled_at", d.Queries.UpdateUserDisabledAt)
+	if err != nil {
+		return nil, err
+	}
+
+	updateUserLocaleStmt, err := d.prepare(db, "users.update_locale", updateUserLocaleQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	return &userRepository{
+		db:                       db,
+		dialect:                  d,
+		insertUserStmt:           insertUserStmt,
+		findUserByEmailStmt:      findUserByEmailStmt,
+		findUserByUsernameStmt:   findUserByUsernameStmt,
+		findUserByIDStmt:         findUserByIDStmt,
+		searchUsersStmt:          searchUsersStmt,
+		countUsersStmt:           countUsersStmt,
+		updateUserPasswordStmt:   updateUserPasswordStmt,
+		updateUserProfileStmt:    updateUserProfileStmt,
+		updateUserDisabledAtStmt: updateUserDisabledAtStmt,
+		updateUserLocaleStmt:     updateUserLocaleStmt,
+	}, nil
+}
+
+type userRepository struct {
+	db                       *DB
+	dialect                  *Dialect
+	insertUserStmt           *stmt
+	findUserByEmailStmt      *stmt
+	findUserByUsernameStmt   *stmt
+	findUserByIDStmt         *stmt
+	searchUsersStmt          *stmt
+	countUsersStmt           *stmt
+	updateUserPasswordStmt   *stmt
+	updateUserProfileStmt    *stmt
+	updateUserDisabledAtStmt *stmt
+	updateUserLocaleStmt     *stmt
+}
+
+// withTx returns the repository with its statements bound to the transaction
+func (ur *userRepository) withTx(ctx context.Context, tx *sql.Tx) *userRepository {
+	return &userRepository{
+		db:                       ur.db,
+		dialect:                  ur.dialect,
+		insertUserStmt:           ur.insertUserStmt.WithTx(ctx, tx),
+		findUserByEmailStmt:      ur.findUserByEmailStmt.WithTx(ctx, tx),
+		findUserByUsernameStmt:   ur.findUserByUsernameStmt.WithTx(ctx, tx),
+		findUserByIDStmt:         ur.findUserByIDStmt.WithTx(ctx, tx),
+		searchUsersStmt:          ur.searchUsersStmt.WithTx(ctx, tx),
+		countUsersStmt:           ur.countUsersStmt.WithTx(ctx, tx),
+		updateUserPasswordStmt:   ur.updateUserPasswordStmt.WithTx(ctx, tx),
+		updateUserProfileStmt:    ur.updateUserProfileStmt.WithTx(ctx, tx),
+		updateUserDisabledAtStmt: ur.updateUserDisabledAtStmt.WithTx(ctx, tx),
+		updateUserLocaleStmt:     ur.updateUserLocaleStmt.WithTx(ctx, tx),
+	}
+}
+
+func (ur *userRepository) Close() error {
+	return errors.Join(
+		ur.findUserByEmailStmt.Close(),
+		ur.insertUserStmt.Close(),
+		ur.updateUserPasswordStmt.Close(),
+		ur.findUserByUsernameStmt.Close(),
+		ur.updateUserProfileStmt.Close(),
+		ur.findUserByIDStmt.Close(),
+		ur.searchUsersStmt.Close(),
+		ur.countUsersStmt.Close(),
+		ur.updateUserDisabledAtStmt.Close(),
+		ur.updateUserLocaleStmt.Close(),
+	)
+}
+
+// Create possible errors:
+//   - ErrFailedToCreateUser {ErrDuplicateUserEmailNotAllowed}
+func (ur *userRepository) Create(ctx context.Context, user *entities.User) entities.Error {
+	if user.Role == "" {
+		user.Role = entities.RoleUser
+	}
+	args := ur.dialect.withNow(user.Email.String(), string(user.Password.AsBytes()), user.Role)
+	if err := ur.insertUserStmt.QueryRowContext(ctx, args...).Scan(&user.ID, &user.CreatedAt); err != nil {
+		// the email is the only unique column inserted
+		if ur.dialect.Classify(err) == UniqueViolation {
+			return entities.NewClientError(
+				"error.user.email_taken",
+				repositories.ErrFailedToCreateUser,
+				repositories.ErrDuplicateUserEmailNotAllowed,
+				err,
+			)
+		}
+		return entities.NewError(repositories.ErrFailedToCreateUser, err)
+	}
+
+	return nil
+}
+
+// FindByEmail possible errors:
+//   - ErrUserNotFound
+//   - ErrFailedToFindUser
+func (ur *userRepository) FindByEmail(ctx context.Context, email entities.Email) (*entities.User, entities.Error) {
+	var user entities.User
+	err := ur.findUserByEmailStmt.QueryRowContext(ctx, email.String()).Scan(userScanDest(&user)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, entities.NewClientError("error.user.email_not_found", repositories.ErrUserNotFound, err)
+	} else if err != nil {
+		return nil, entities.NewError(repositories.ErrFailedToFindUser, err)
+	}
+	return &user, nil
+}
+
+// FindByUsername possible errors:
+//   - ErrUserNotFound
+//   - ErrFailedToFindUser
+func (ur *userRepository) FindByUsername(ctx context.Context, username entities.Username) (*entities.User, entities.Error) {
+	var user entities.User
+	err := ur.findUserByUsernameStmt.QueryRowContext(ctx, username).Scan(userScanDest(&user)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, entities.NewClientError("error.user.not_found", repositories.ErrUserNotFound, err)
+	} else if err != nil {
+		return nil, entities.NewError(repositories.ErrFailedToFindUser, err)
+	}
+	return &user, nil
+}
+
+// FindByID possible errors:
+//   - ErrUserNotFound
+//   - ErrFailedToFindUser
+func (ur *userRepository) FindByID(ctx context.Context, id uint64) (*entities.User, entities.Error) {
+	var user entities.User
+	err := ur.findUserByIDStmt.QueryRowContext(ctx, id).Scan(userScanDest(&user)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, entities.NewClientError("error.user.not_found", repositories.ErrUserNotFound, err)
+	} else if err != nil {
+		return nil, entities.NewError(repositories.ErrFailedToFindUser, err)
+	}
+	return &user, nil
+}
+
+// Search possible errors:
+//   - ErrFailedToSearchUsers
+func (ur *userRepository) Search(ctx context.Context, filter repositories.UserFilter) ([]entities.User, int, error) {
+	pattern := filter.Pattern()
+	var total int
+	if err := ur.countUsersStmt.QueryRowContext(ctx, pattern).Scan(&total); err != nil {
+		return nil, 0, errors.Join(repositories.ErrFailedToSearchUsers, err)
+	}
+
+	rows, err := ur.searchUsersStmt.QueryContext(ctx, pattern, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, 0, errors.Join(repositories.ErrFailedToSearchUsers, err)
+	}
+	defer rows.Close()
+
+	var users []entities.User
+	for rows.Next() {
+		var user entities.User
+		if err := rows.Scan(userScanDest(&user)...); err != nil {
+			return nil, 0, errors.Join(repositories.ErrFailedToSearchUsers, err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, errors.Join(repositories.ErrFailedToSearchUsers, err)
+	}
+
+	return users, total, nil
+}
+
+func (ur *userRepository) UpdatePassword(ctx context.Context, user *entities.User) error {
+	if _, err := ur.updateUserPasswordStmt.ExecContext(ctx, user.ID, string(user.Password.AsBytes())); err != nil {
+		return errors.Join(repositories.ErrFailedToUpdateUserPassword, err)
+	}
+	return nil
+}
+
+// UpdateProfile possible errors:
+//   - ErrFailedToUpdateUserProfile {ErrDuplicateUsernameNotAllowed}
+func (ur *userRepository) UpdateProfile(ctx context.Context, user *entities.User) entities.Error {
+	args := ur.dialect.withNow(
+		user.ID,
+		user.Profile.Username,
+		user.Profile.DisplayName,
+		user.Profile.Bio,
+		user.Profile.Avatar,
+	)
+	if err := ur.updateUserProfileStmt.QueryRowContext(ctx, args...).Scan(&user.UpdatedAt); err != nil {
+		// the username is the only unique column updated
+		if ur.dialect.Classify(err) == UniqueViolation {
+			return entities.NewClientError(
+				"error.profile.username_taken",
+				repositories.ErrFailedToUpdateUserProfile,
+				repositories.ErrDuplicateUsernameNotAllowed,
+				err,
+			)
+		}
+		return entities.NewError(repositories.ErrFailedToUpdateUserProfile, err)
+	}
+
+	return nil
+}
+
+// UpdateDisabledAt possible errors:
+//   - ErrFailedToUpdateUser
+func (ur *userRepository) UpdateDisabledAt(ctx context.Context, user *entities.User) error {
+	args := ur.dialect.withNow(user.ID, utc(user.DisabledAt))
+	if err := ur.updateUserDisabledAtStmt.QueryRowContext(ctx, args...).Scan(&user.UpdatedAt); err != nil {
+		return errors.Join(repositories.ErrFailedToUpdateUser, err)
+	}
+	return nil
+}
+
+// UpdateLocale possible errors:
+//   - ErrFailedToUpdateUser {ErrUserNotFound}
+func (ur *userRepository) UpdateLocale(ctx context.Context, user *entities.User) error {
+	result, err := ur.updateUserLocaleStmt.ExecContext(ctx, user.ID, user.Locale)
+	if err != nil {
+		return errors.Join(repositories.ErrFailedToUpdateUser, err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err == nil && rowsAffected == 0 {
+		return errors.Join(repositories.ErrFailedToUpdateUser, repositories.ErrUserNotFound)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+// Package sqlstmt wraps the prepared statements of the SQL repositories,
+// tracing every execution and bounding it by a timeout.
+package sqlstmt
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/twsm000/lenslocked/models/repositories/internal/sqlstmt")
+
+// DB is the database the repositories prepare their statements on
+type DB struct {
+	*sql.DB
+	// QueryTimeout bounds every statement execution, zero leaves them
+	// bound by the context of the caller only
+	QueryTimeout time.Duration
+}
+
+// Stmt is a prepared statement tracing every execution with a span named
+// after the statement. The executions are aborted when the context of the
+// caller is done or the query timeout expires.
+type Stmt struct {
+	stmt    *sql.Stmt
+	name    string
+	query   string
+	system  attribute.KeyValue
+	timeout time.Duration
+}
+
+// Prepare creates the prepared statement, name identifies its spans and
+// system the database in them, as semconv.DBSystemPostgreSQL
+func Prepare(db *DB, system attribute.KeyValue, name, query string) (*Stmt, error) {
+	s, err := db.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return &Stmt{
+		stmt:    s,
+		name:    name,
+		query:   strings.Join(strings.Fields(query), " "),
+		system:  system,
+		timeout: db.QueryTimeout,
+	}, nil
+}
+
+func (s *Stmt) Close() error {
+	return s.stmt.Close()
+}
+
+// WithTx returns the statement bound to the transaction, it is closed when
+// the transaction ends
+func (s *Stmt) WithTx(ctx context.Context, tx *sql.Tx) *Stmt {
+	bound := *s
+	bound.stmt = tx.StmtContext(ctx, s.stmt)
+	return &bound
+}
+
+// start starts the span of an execution and bounds its context by the
+// query timeout, cancel must be called once the results are read
+func (s *Stmt) start(ctx context.Context) (context.Context, trace.Span, context.CancelFunc) {
+	ctx, span := tracer.Start(ctx, s.name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			s.system,
+			semconv.DBQueryText(s.query),
+		),
+	)
+	if s.timeout <= 0 {
+		return ctx, span, func() {}
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	return ctx, span, cancel
+}
+
+// QueryRowContext executes the query, the span ends when the row is scanned
+func (s *Stmt) QueryRowContext(ctx context.Context, args ...any) *Row {
+	ctx, span, cancel := s.start(ctx)
+	return &Row{
+		row:    s.stmt.QueryRowContext(ctx, args...),
+		span:   span,
+		cancel: cancel,
+	}
+}
+
+// QueryContext executes the query, the span does not cover reading the rows.
+// The rows must be closed to release the query timeout.
+func (s *Stmt) QueryContext(ctx context.Context, args ...any) (*Rows, error) {
+	ctx, span, cancel := s.start(ctx)
+	defer span.End()
+	r, err := s.stmt.QueryContext(ctx, args...)
+	if err != nil {
+		cancel()
+		RecordError(span, err)
+		return nil, err
+	}
+	return &Rows{Rows: r, cancel: cancel}, nil
+}
+
+func (s *Stmt) ExecContext(ctx context.Context, args ...any) (sql.Result, error) {
+	ctx, span, cancel := s.start(ctx)
+	defer span.End()
+	defer cancel()
+	result, err := s.stmt.ExecContext(ctx, args...)
+	RecordError(span, err)
+	return result, err
+}
+
+type Row struct {
+	row    *sql.Row
+	span   trace.Span
+	cancel context.CancelFunc
+}
+
+func (r *Row) Scan(dest ...any) error {
+	defer r.span.End()
+	defer r.cancel()
+	err := r.row.Scan(dest...)
+	// no rows is an expected result, not a failure of the statement
+	if !errors.Is(err, sql.ErrNoRows) {
+		RecordError(r.span, err)
+	}
+	return err
+}
+
+// Rows keeps the query context alive until the rows are closed, since
+// cancelling it stops the reading of the rows
+type Rows struct {
+	*sql.Rows
+	cancel context.CancelFunc
+}
+
+func (r *Rows) Close() error {
+	defer r.cancel()
+	return r.Rows.Close()
+}
+
+// RecordError marks the span as failed by err, if not nil
+func RecordError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
@@ -0,0 +1,46 @@
+package sqlstmt
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/twsm000/lenslocked/models/repositories"
+)
+
+// Transact runs fn in a transaction of db, with the repositories bound to
+// it by bind, committed when fn succeeds and rolled back otherwise. It is
+// the Do of the unit of work of the SQL repositories.
+//
+// Possible errors:
+//   - repositories.ErrFailedToBeginTransaction
+//   - repositories.ErrFailedToCommitTransaction
+//   - the errors of fn
+func Transact(
+	ctx context.Context,
+	db *DB,
+	bind func(ctx context.Context, tx *sql.Tx) repositories.Transaction,
+	fn func(tx repositories.Transaction) error) error {
+	/***************************************************/
+	ctx, span := tracer.Start(ctx, "transaction")
+	defer span.End()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		RecordError(span, err)
+		return errors.Join(repositories.ErrFailedToBeginTransaction, err)
+	}
+	// rolls back when fn fails or panics, it does nothing once committed
+	defer tx.Rollback()
+
+	if err := fn(bind(ctx, tx)); err != nil {
+		RecordError(span, err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		RecordError(span, err)
+		return errors.Join(repositories.ErrFailedToCommitTransaction, err)
+	}
+	return nil
+}
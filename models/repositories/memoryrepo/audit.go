@@ -0,0 +1,92 @@
+package memoryrepo
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"time"
+
+	"github.com/twsm000/lenslocked/models/entities"
+	"github.com/twsm000/lenslocked/models/repositories"
+)
+
+func NewAuditRepository(store *Store) repositories.Audit {
+	return &auditRepository{access: access{store: store}}
+}
+
+type auditRepository struct {
+	access
+}
+
+func (ar *auditRepository) Close() error {
+	return nil
+}
+
+// Create possible errors:
+//   - ErrFailedToCreateAuditEvent
+func (ar *auditRepository) Create(ctx context.Context, event *entities.AuditEvent) error {
+	if err := ctx.Err(); err != nil {
+		return errors.Join(repositories.ErrFailedToCreateAuditEvent, err)
+	}
+
+	ar.write(func(d *data) {
+		d.lastAuditEventID++
+		event.ID = d.lastAuditEventID
+		event.CreatedAt = time.Now()
+		d.auditEvents = append(d.auditEvents, copyAuditEvent(*event))
+	})
+	return nil
+}
+
+// List possible errors:
+//   - ErrFailedToListAuditEvents
+func (ar *auditRepository) List(ctx context.Context, filter repositories.AuditFilter) ([]entities.AuditEvent, int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, errors.Join(repositories.ErrFailedToListAuditEvents, err)
+	}
+
+	var matches []entities.AuditEvent
+	ar.read(func(d *data) {
+		// newest first
+		for i := len(d.auditEvents) - 1; i >= 0; i-- {
+			if event := d.auditEvents[i]; matchAuditEvent(event, filter) {
+				matches = append(matches, copyAuditEvent(event))
+			}
+		}
+	})
+	return page(matches, filter.Limit, filter.Offset), len(matches), nil
+}
+
+// matchAuditEvent ignores the zero fields of the filter, UserID matches the
+// events with the user as the actor or the target
+func matchAuditEvent(event entities.AuditEvent, filter repositories.AuditFilter) bool {
+	if filter.Action != "" && event.Action != filter.Action {
+		return false
+	}
+	if filter.UserID != 0 {
+		isActor := event.ActorID != nil && *event.ActorID == filter.UserID
+		isTarget := event.TargetType == entities.AuditTargetUser && event.TargetID != nil && *event.TargetID == filter.UserID
+		if !isActor && !isTarget {
+			return false
+		}
+	}
+	return filter.IP == "" || event.Source.IP == filter.IP
+}
+
+// copyAuditEvent returns a copy of the event not sharing its ids and details,
+// the details are never nil
+func copyAuditEvent(event entities.AuditEvent) entities.AuditEvent {
+	if event.ActorID != nil {
+		actorID := *event.ActorID
+		event.ActorID = &actorID
+	}
+	if event.TargetID != nil {
+		targetID := *event.TargetID
+		event.TargetID = &targetID
+	}
+	event.Details = maps.Clone(event.Details)
+	if event.Details == nil {
+		event.Details = map[string]string{}
+	}
+	return event
+}
@@ -0,0 +1,21 @@
+package memoryrepo
+
+import (
+	"testing"
+
+	"github.com/twsm000/lenslocked/models/repositories/repotest"
+)
+
+func TestConformance(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		store := NewStore()
+		return repotest.Repositories{
+			Users:          NewUserRepository(store),
+			Sessions:       NewSessionRepository(store),
+			PasswordResets: NewPasswordResetRepository(store),
+			Audit:          NewAuditRepository(store),
+			Stats:          NewStatsRepository(store),
+			UnitOfWork:     NewUnitOfWork(store),
+		}
+	})
+}
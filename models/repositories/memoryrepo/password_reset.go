@@ -0,0 +1,107 @@
+package memoryrepo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/twsm000/lenslocked/models/entities"
+	"github.com/twsm000/lenslocked/models/repositories"
+)
+
+func NewPasswordResetRepository(store *Store) repositories.PasswordReset {
+	return &passwordResetRepository{access: access{store: store}}
+}
+
+type passwordResetRepository struct {
+	access
+}
+
+func (pr *passwordResetRepository) Close() error {
+	return nil
+}
+
+// Create replaces the password reset of the user, if any. Possible errors:
+//   - ErrFailedToCreatePasswordReset {ErrUserNotFound}
+func (pr *passwordResetRepository) Create(ctx context.Context, reset *entities.PasswordReset) error {
+	if err := ctx.Err(); err != nil {
+		return errors.Join(repositories.ErrFailedToCreatePasswordReset, err)
+	}
+
+	var err error
+	pr.write(func(d *data) {
+		if _, ok := d.users[reset.UserID]; !ok {
+			err = errors.Join(repositories.ErrFailedToCreatePasswordReset, repositories.ErrUserNotFound)
+			return
+		}
+
+		stored := entities.PasswordReset{
+			UserID:    reset.UserID,
+			Token:     hashedToken(reset.Token),
+			ExpiresAt: reset.ExpiresAt,
+		}
+		if previous, ok := findPasswordReset(d, func(r entities.PasswordReset) bool { return r.UserID == reset.UserID }); ok {
+			now := time.Now()
+			stored.ID, stored.CreatedAt, stored.UpdatedAt = previous.ID, previous.CreatedAt, &now
+		} else {
+			d.lastPasswordResetID++
+			stored.ID, stored.CreatedAt = d.lastPasswordResetID, time.Now()
+		}
+		d.passwordResets[stored.ID] = stored
+		reset.ID, reset.CreatedAt, reset.UpdatedAt = stored.ID, stored.CreatedAt, stored.UpdatedAt
+	})
+	return err
+}
+
+// FindPasswordResetAndUserByToken possible errors:
+//   - ErrUserNotFound
+func (pr *passwordResetRepository) FindPasswordResetAndUserByToken(
+	ctx context.Context, token entities.SessionToken) (*entities.PasswordReset, *entities.User, error) {
+	/*******************************************************************************/
+	if err := ctx.Err(); err != nil {
+		return nil, nil, errors.Join(repositories.ErrFailedToFindUser, err)
+	}
+
+	var reset *entities.PasswordReset
+	var user *entities.User
+	pr.read(func(d *data) {
+		r, ok := findPasswordReset(d, func(r entities.PasswordReset) bool {
+			return bytes.Equal(r.Token.Hash(), token.Hash())
+		})
+		if u, found := d.users[r.UserID]; ok && found {
+			reset, user = &r, copyUser(u)
+		}
+	})
+	if reset == nil {
+		return nil, nil, repositories.ErrUserNotFound
+	}
+	return reset, user, nil
+}
+
+// DeleteByID possible errors:
+//   - ErrFailedToDeletePasswordReset {ErrPasswordResetNotFound}
+func (pr *passwordResetRepository) DeleteByID(ctx context.Context, id uint64) error {
+	if err := ctx.Err(); err != nil {
+		return errors.Join(repositories.ErrFailedToDeletePasswordReset, err)
+	}
+
+	var err error
+	pr.write(func(d *data) {
+		if _, ok := d.passwordResets[id]; !ok {
+			err = errors.Join(repositories.ErrFailedToDeletePasswordReset, repositories.ErrPasswordResetNotFound)
+			return
+		}
+		delete(d.passwordResets, id)
+	})
+	return err
+}
+
+func findPasswordReset(d *data, match func(r entities.PasswordReset) bool) (entities.PasswordReset, bool) {
+	for _, r := range d.passwordResets {
+		if match(r) {
+			return r, true
+		}
+	}
+	return entities.PasswordReset{}, false
+}
@@ -0,0 +1,177 @@
+package memoryrepo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/twsm000/lenslocked/models/entities"
+	"github.com/twsm000/lenslocked/models/repositories"
+)
+
+func NewSessionRepository(store *Store) repositories.Session {
+	return &sessionRepository{access: access{store: store}}
+}
+
+type sessionRepository struct {
+	access
+}
+
+func (sr *sessionRepository) Close() error {
+	return nil
+}
+
+// Create replaces the session of the user, if any. Possible errors:
+//   - ErrFailedToCreateSession {ErrUserNotFound}
+func (sr *sessionRepository) Create(ctx context.Context, session *entities.Session) entities.Error {
+	if err := ctx.Err(); err != nil {
+		return entities.NewError(repositories.ErrFailedToCreateSession, err)
+	}
+
+	var err entities.Error
+	sr.write(func(d *data) {
+		if _, ok := d.users[session.UserID]; !ok {
+			err = entities.NewClientError(
+				"error.user.not_found",
+				repositories.ErrFailedToCreateSession,
+				repositories.ErrUserNotFound,
+			)
+			return
+		}
+
+		stored := entities.Session{
+			UserID: session.UserID,
+			Token:  hashedToken(session.Token),
+		}
+		if previous, ok := findSession(d, func(s entities.Session) bool { return s.UserID == session.UserID }); ok {
+			now := time.Now()
+			stored.ID, stored.CreatedAt, stored.UpdatedAt = previous.ID, previous.CreatedAt, &now
+		} else {
+			d.lastSessionID++
+			stored.ID, stored.CreatedAt = d.lastSessionID, time.Now()
+		}
+		d.sessions[stored.ID] = stored
+		session.ID, session.CreatedAt, session.UpdatedAt = stored.ID, stored.CreatedAt, stored.UpdatedAt
+	})
+	return err
+}
+
+// FindUserByToken ignores the sessions of disabled users. Possible errors:
+//   - ErrUserNotFound
+func (sr *sessionRepository) FindUserByToken(ctx context.Context, token entities.SessionToken) (*entities.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.Join(repositories.ErrFailedToFindUser, err)
+	}
+
+	var user *entities.User
+	sr.read(func(d *data) {
+		session, ok := findSession(d, func(s entities.Session) bool {
+			return bytes.Equal(s.Token.Hash(), token.Hash())
+		})
+		if u, found := d.users[session.UserID]; ok && found && !u.IsDisabled() {
+			user = copyUser(u)
+		}
+	})
+	if user == nil {
+		return nil, repositories.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// DeleteByToken possible errors:
+//   - ErrFailedToDeleteSession
+func (sr *sessionRepository) DeleteByToken(ctx context.Context, token entities.SessionToken) error {
+	if err := ctx.Err(); err != nil {
+		return errors.Join(repositories.ErrFailedToDeleteSession, err)
+	}
+
+	sr.write(func(d *data) {
+		if session, ok := findSession(d, func(s entities.Session) bool {
+			return bytes.Equal(s.Token.Hash(), token.Hash())
+		}); ok {
+			delete(d.sessions, session.ID)
+		}
+	})
+	return nil
+}
+
+// FindByUserID returns the sessions of the user, without the tokens.
+// Possible errors:
+//   - ErrFailedToFindSessions
+func (sr *sessionRepository) FindByUserID(ctx context.Context, userID uint64) ([]entities.Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.Join(repositories.ErrFailedToFindSessions, err)
+	}
+
+	var sessions []entities.Session
+	sr.read(func(d *data) {
+		for _, s := range d.sessions {
+			if s.UserID == userID {
+				s.Token = entities.SessionToken{}
+				sessions = append(sessions, s)
+			}
+		}
+	})
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].ID < sessions[j].ID })
+	return sessions, nil
+}
+
+// DeleteByUserID deletes every session of the user and returns how many.
+// Possible errors:
+//   - ErrFailedToDeleteSession
+func (sr *sessionRepository) DeleteByUserID(ctx context.Context, userID uint64) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, errors.Join(repositories.ErrFailedToDeleteSession, err)
+	}
+
+	var deleted int64
+	sr.write(func(d *data) {
+		for id, s := range d.sessions {
+			if s.UserID == userID {
+				delete(d.sessions, id)
+				deleted++
+			}
+		}
+	})
+	return deleted, nil
+}
+
+// DeleteAll deletes every session and returns how many. Possible errors:
+//   - ErrFailedToDeleteSession
+func (sr *sessionRepository) DeleteAll(ctx context.Context) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, errors.Join(repositories.ErrFailedToDeleteSession, err)
+	}
+
+	var deleted int64
+	sr.write(func(d *data) {
+		deleted = int64(len(d.sessions))
+		clear(d.sessions)
+	})
+	return deleted, nil
+}
+
+// Count returns how many sessions there are. Possible errors:
+//   - ErrFailedToCountSessions
+func (sr *sessionRepository) Count(ctx context.Context) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, errors.Join(repositories.ErrFailedToCountSessions, err)
+	}
+
+	var count int64
+	sr.read(func(d *data) {
+		count = int64(len(d.sessions))
+	})
+	return count, nil
+}
+
+func findSession(d *data, match func(s entities.Session) bool) (entities.Session, bool) {
+	for _, s := range d.sessions {
+		if match(s) {
+			return s, true
+		}
+	}
+	return entities.Session{}, false
+}
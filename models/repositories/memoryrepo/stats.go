@@ -0,0 +1,55 @@
+package memoryrepo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/twsm000/lenslocked/models/entities"
+	"github.com/twsm000/lenslocked/models/repositories"
+)
+
+func NewStatsRepository(store *Store) repositories.Stats {
+	return &statsRepository{access: access{store: store}}
+}
+
+type statsRepository struct {
+	access
+}
+
+func (sr *statsRepository) Close() error {
+	return nil
+}
+
+// Stats possible errors:
+//   - ErrFailedToCollectStats
+func (sr *statsRepository) Stats(ctx context.Context) (*entities.Stats, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, errors.Join(repositories.ErrFailedToCollectStats, err)
+	}
+
+	var stats entities.Stats
+	now := time.Now()
+	lastWeek := now.AddDate(0, 0, -7)
+	sr.read(func(d *data) {
+		for _, u := range d.users {
+			stats.Users++
+			if u.Role == entities.RoleAdmin {
+				stats.Admins++
+			}
+			if u.IsDisabled() {
+				stats.DisabledUsers++
+			}
+			if u.CreatedAt.After(lastWeek) {
+				stats.NewUsersLastWeek++
+			}
+		}
+		stats.Sessions = len(d.sessions)
+		for _, r := range d.passwordResets {
+			if r.ExpiresAt.After(now) {
+				stats.PendingPasswordResets++
+			}
+		}
+	})
+	return &stats, nil
+}
@@ -0,0 +1,131 @@
+// Package memoryrepo implements the repositories in memory, with the same
+// error semantics as the database implementations. It is meant for the
+// tests and the development without a database.
+package memoryrepo
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/twsm000/lenslocked/models/entities"
+	"github.com/twsm000/lenslocked/models/repositories"
+)
+
+// Store holds the data of the repositories. The repositories created on
+// the same store see the data of each other, like the tables of a database.
+type Store struct {
+	mu   sync.RWMutex
+	data data
+}
+
+func NewStore() *Store {
+	return &Store{
+		data: data{
+			users:          map[uint64]entities.User{},
+			sessions:       map[uint64]entities.Session{},
+			passwordResets: map[uint64]entities.PasswordReset{},
+		},
+	}
+}
+
+// data are the tables of the store, the tokens are kept hashed only
+type data struct {
+	users          map[uint64]entities.User
+	sessions       map[uint64]entities.Session
+	passwordResets map[uint64]entities.PasswordReset
+	auditEvents    []entities.AuditEvent
+
+	lastUserID          uint64
+	lastSessionID       uint64
+	lastPasswordResetID uint64
+	lastAuditEventID    uint64
+}
+
+// clone copies the tables, the rows are never changed in place
+func (d *data) clone() data {
+	c := *d
+	c.users = maps.Clone(d.users)
+	c.sessions = maps.Clone(d.sessions)
+	c.passwordResets = maps.Clone(d.passwordResets)
+	c.auditEvents = slices.Clone(d.auditEvents)
+	return c
+}
+
+// access is how a repository reaches the store. The repositories of a
+// transaction run while the transaction holds the lock of the store.
+type access struct {
+	store *Store
+	inTx  bool
+}
+
+func (a access) read(fn func(d *data)) {
+	if !a.inTx {
+		a.store.mu.RLock()
+		defer a.store.mu.RUnlock()
+	}
+	fn(&a.store.data)
+}
+
+func (a access) write(fn func(d *data)) {
+	if !a.inTx {
+		a.store.mu.Lock()
+		defer a.store.mu.Unlock()
+	}
+	fn(&a.store.data)
+}
+
+// NewUnitOfWork runs the transactions on the store. A transaction holds the
+// lock of the store until it ends, so the repositories outside of it wait,
+// and it restores the data it changed when rolled back.
+func NewUnitOfWork(store *Store) repositories.UnitOfWork {
+	return &unitOfWork{store: store}
+}
+
+type unitOfWork struct {
+	store *Store
+}
+
+func (uow *unitOfWork) Do(ctx context.Context, fn func(tx repositories.Transaction) error) error {
+	if err := ctx.Err(); err != nil {
+		return errors.Join(repositories.ErrFailedToBeginTransaction, err)
+	}
+
+	uow.store.mu.Lock()
+	defer uow.store.mu.Unlock()
+	snapshot := uow.store.data.clone()
+	committed := false
+	defer func() {
+		if !committed {
+			uow.store.data = snapshot
+		}
+	}()
+
+	tx := access{store: uow.store, inTx: true}
+	err := fn(repositories.Transaction{
+		Users:          &userRepository{access: tx},
+		Sessions:       &sessionRepository{access: tx},
+		PasswordResets: &passwordResetRepository{access: tx},
+		Audit:          &auditRepository{access: tx},
+	})
+	if err != nil {
+		return err
+	}
+	committed = true
+	return nil
+}
+
+// hashedToken returns the token without its value, as read from a database
+func hashedToken(token entities.SessionToken) entities.SessionToken {
+	var hashed entities.SessionToken
+	hashed.Scan(token.Hash()) // never fails, the hash has the right size
+	return hashed
+}
+
+// copyUser returns a copy of the user not sharing the password
+func copyUser(user entities.User) *entities.User {
+	user.Password = slices.Clone(user.Password)
+	return &user
+}
@@ -0,0 +1,245 @@
+package memoryrepo
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/twsm000/lenslocked/models/entities"
+	"github.com/twsm000/lenslocked/models/repositories"
+)
+
+func NewUserRepository(store *Store) repositories.User {
+	return &userRepository{access: access{store: store}}
+}
+
+type userRepository struct {
+	access
+}
+
+func (ur *userRepository) Close() error {
+	return nil
+}
+
+// Create possible errors:
+//   - ErrFailedToCreateUser {ErrDuplicateUserEmailNotAllowed}
+func (ur *userRepository) Create(ctx context.Context, user *entities.User) entities.Error {
+	if err := ctx.Err(); err != nil {
+		return entities.NewError(repositories.ErrFailedToCreateUser, err)
+	}
+	if user.Role == "" {
+		user.Role = entities.RoleUser
+	}
+
+	var err entities.Error
+	ur.write(func(d *data) {
+		for _, u := range d.users {
+			if u.Email == user.Email {
+				err = entities.NewClientError(
+					"error.user.email_taken",
+					repositories.ErrFailedToCreateUser,
+					repositories.ErrDuplicateUserEmailNotAllowed,
+				)
+				return
+			}
+		}
+
+		d.lastUserID++
+		user.ID = d.lastUserID
+		user.CreatedAt = time.Now()
+		d.users[user.ID] = entities.User{
+			ID:        user.ID,
+			CreatedAt: user.CreatedAt,
+			Email:     user.Email,
+			Password:  slices.Clone(user.Password),
+			Role:      user.Role,
+		}
+	})
+	return err
+}
+
+// FindByEmail possible errors:
+//   - ErrUserNotFound
+//   - ErrFailedToFindUser
+func (ur *userRepository) FindByEmail(ctx context.Context, email entities.Email) (*entities.User, entities.Error) {
+	return ur.find(ctx, "error.user.email_not_found", func(u entities.User) bool {
+		return u.Email == email
+	})
+}
+
+// FindByUsername possible errors:
+//   - ErrUserNotFound
+//   - ErrFailedToFindUser
+func (ur *userRepository) FindByUsername(ctx context.Context, username entities.Username) (*entities.User, entities.Error) {
+	return ur.find(ctx, "error.user.not_found", func(u entities.User) bool {
+		return !username.IsEmpty() && u.Profile.Username == username
+	})
+}
+
+// FindByID possible errors:
+//   - ErrUserNotFound
+//   - ErrFailedToFindUser
+func (ur *userRepository) FindByID(ctx context.Context, id uint64) (*entities.User, entities.Error) {
+	return ur.find(ctx, "error.user.not_found", func(u entities.User) bool {
+		return u.ID == id
+	})
+}
+
+// find returns the user matching, or the client error msg when none does
+func (ur *userRepository) find(ctx context.Context, msg string, match func(u entities.User) bool) (*entities.User, entities.Error) {
+	if err := ctx.Err(); err != nil {
+		return nil, entities.NewError(repositories.ErrFailedToFindUser, err)
+	}
+
+	var user *entities.User
+	ur.read(func(d *data) {
+		for _, u := range d.users {
+			if match(u) {
+				user = copyUser(u)
+				return
+			}
+		}
+	})
+	if user == nil {
+		return nil, entities.NewClientError(msg, repositories.ErrUserNotFound)
+	}
+	return user, nil
+}
+
+// Search possible errors:
+//   - ErrFailedToSearchUsers
+func (ur *userRepository) Search(ctx context.Context, filter repositories.UserFilter) ([]entities.User, int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, errors.Join(repositories.ErrFailedToSearchUsers, err)
+	}
+
+	query := strings.ToLower(strings.TrimSpace(filter.Query))
+	var matches []entities.User
+	ur.read(func(d *data) {
+		for _, u := range d.users {
+			if query == "" ||
+				strings.Contains(u.Email.String(), query) ||
+				strings.Contains(u.Profile.Username.String(), query) ||
+				strings.Contains(strings.ToLower(u.Profile.DisplayName), query) {
+				matches = append(matches, *copyUser(u))
+			}
+		}
+	})
+	sort.Slice(matches, func(i, j int) bool { return matches[i].ID < matches[j].ID })
+
+	return page(matches, filter.Limit, filter.Offset), len(matches), nil
+}
+
+// UpdatePassword possible errors:
+//   - ErrFailedToUpdateUserPassword
+func (ur *userRepository) UpdatePassword(ctx context.Context, user *entities.User) error {
+	if err := ctx.Err(); err != nil {
+		return errors.Join(repositories.ErrFailedToUpdateUserPassword, err)
+	}
+
+	ur.write(func(d *data) {
+		if u, ok := d.users[user.ID]; ok {
+			u.Password = slices.Clone(user.Password)
+			d.users[u.ID] = u
+		}
+	})
+	return nil
+}
+
+// UpdateProfile possible errors:
+//   - ErrFailedToUpdateUserProfile {ErrDuplicateUsernameNotAllowed}
+func (ur *userRepository) UpdateProfile(ctx context.Context, user *entities.User) entities.Error {
+	if err := ctx.Err(); err != nil {
+		return entities.NewError(repositories.ErrFailedToUpdateUserProfile, err)
+	}
+
+	var err entities.Error
+	ur.write(func(d *data) {
+		u, ok := d.users[user.ID]
+		if !ok {
+			err = entities.NewError(repositories.ErrFailedToUpdateUserProfile, repositories.ErrUserNotFound)
+			return
+		}
+		for _, other := range d.users {
+			if other.ID != u.ID && !user.Profile.Username.IsEmpty() && other.Profile.Username == user.Profile.Username {
+				err = entities.NewClientError(
+					"error.profile.username_taken",
+					repositories.ErrFailedToUpdateUserProfile,
+					repositories.ErrDuplicateUsernameNotAllowed,
+				)
+				return
+			}
+		}
+
+		now := time.Now()
+		u.Profile = user.Profile
+		u.UpdatedAt = &now
+		d.users[u.ID] = u
+		user.UpdatedAt = &now
+	})
+	return err
+}
+
+// UpdateDisabledAt possible errors:
+//   - ErrFailedToUpdateUser
+func (ur *userRepository) UpdateDisabledAt(ctx context.Context, user *entities.User) error {
+	if err := ctx.Err(); err != nil {
+		return errors.Join(repositories.ErrFailedToUpdateUser, err)
+	}
+
+	var err error
+	ur.write(func(d *data) {
+		u, ok := d.users[user.ID]
+		if !ok {
+			err = errors.Join(repositories.ErrFailedToUpdateUser, repositories.ErrUserNotFound)
+			return
+		}
+
+		now := time.Now()
+		u.DisabledAt = nil
+		if user.DisabledAt != nil {
+			disabledAt := *user.DisabledAt
+			u.DisabledAt = &disabledAt
+		}
+		u.UpdatedAt = &now
+		d.users[u.ID] = u
+		user.UpdatedAt = &now
+	})
+	return err
+}
+
+// UpdateLocale possible errors:
+//   - ErrFailedToUpdateUser {ErrUserNotFound}
+func (ur *userRepository) UpdateLocale(ctx context.Context, user *entities.User) error {
+	if err := ctx.Err(); err != nil {
+		return errors.Join(repositories.ErrFailedToUpdateUser, err)
+	}
+
+	var err error
+	ur.write(func(d *data) {
+		u, ok := d.users[user.ID]
+		if !ok {
+			err = errors.Join(repositories.ErrFailedToUpdateUser, repositories.ErrUserNotFound)
+			return
+		}
+
+		u.Locale = user.Locale
+		d.users[u.ID] = u
+	})
+	return err
+}
+
+// page returns the items of the page, like LIMIT and OFFSET
+func page[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return nil
+	}
+	items = items[offset:]
+	if limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
@@ -0,0 +1,66 @@
+package postgresrepo
+
+import (
+	"database/sql"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+
+	_ "github.com/jackc/pgx/v4/stdlib"
+	"github.com/stretchr/testify/require"
+	"github.com/twsm000/lenslocked/models/database"
+	"github.com/twsm000/lenslocked/models/repositories/repotest"
+)
+
+// testDatabaseURL names the variable with the connection string of a
+// disposable database, its tables are emptied before every test
+const testDatabaseURL = "LENSLOCKED_TEST_DATABASE_URL"
+
+func TestConformance(t *testing.T) {
+	url := os.Getenv(testDatabaseURL)
+	if url == "" {
+		t.Skipf("%s not set", testDatabaseURL)
+	}
+
+	sqlDB, err := sql.Open("pgx", url)
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+	require.NoError(t, database.MigrateFS(sqlDB, database.Config{Driver: database.DriverPostgres}, ""))
+	db := &DB{DB: sqlDB}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		_, err := db.Exec(`TRUNCATE users, sessions, password_resets, audit_events RESTART IDENTITY CASCADE`)
+		require.NoError(t, err)
+
+		users, err := NewUserRepository(db)
+		require.NoError(t, err)
+		sessions, err := NewSessionRepository(db, logger)
+		require.NoError(t, err)
+		passwordResets, err := NewPasswordResetRepository(db, logger)
+		require.NoError(t, err)
+		audit, err := NewAuditRepository(db)
+		require.NoError(t, err)
+		stats, err := NewStatsRepository(db)
+		require.NoError(t, err)
+		uow, err := NewUnitOfWork(db, users, sessions, passwordResets, audit)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			users.Close()
+			sessions.Close()
+			passwordResets.Close()
+			audit.Close()
+			stats.Close()
+		})
+
+		return repotest.Repositories{
+			Users:          users,
+			Sessions:       sessions,
+			PasswordResets: passwordResets,
+			Audit:          audit,
+			Stats:          stats,
+			UnitOfWork:     uow,
+		}
+	})
+}
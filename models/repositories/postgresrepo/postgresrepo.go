@@ -0,0 +1,135 @@
+package postgresrepo
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/jackc/pgconn"
+	"github.com/twsm000/lenslocked/models/repositories"
+	"github.com/twsm000/lenslocked/models/repositories/internal/sqlrepo"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+var (
+	ErrForeignRepository = sqlrepo.ErrForeignRepository
+)
+
+// DB is the database the repositories prepare their statements on
+type DB = sqlrepo.DB
+
+// dialect writes the timestamps with the clock of the database
+var dialect = &sqlrepo.Dialect{
+	System:   semconv.DBSystemPostgreSQL,
+	Classify: classify,
+	Queries: sqlrepo.Queries{
+		InsertUser: `
+			INSERT INTO users (created_at, email, password, role)
+			VALUES (CURRENT_TIMESTAMP, $1, $2, $3)
+			RETURNING id, created_at
+		`,
+		SearchUsersFilter: `
+			 WHERE $1 = ''
+			    OR u.email ILIKE $1
+			    OR u.username ILIKE $1
+			    OR u.display_name ILIKE $1
+		`,
+		UpdateUserProfile: `
+			UPDATE users
+			   SET username = $2,
+			       display_name = $3,
+			       bio = $4,
+			       avatar = $5,
+			       updated_at = CURRENT_TIMESTAMP
+			 WHERE id = $1
+			RETURNING updated_at
+		`,
+		UpdateUserDisabledAt: `
+			UPDATE users
+			   SET disabled_at = $2,
+			       updated_at = CURRENT_TIMESTAMP
+			 WHERE id = $1
+			RETURNING updated_at
+		`,
+		UpsertSession: `
+			INSERT INTO sessions (created_at, user_id, token)
+			VALUES (CURRENT_TIMESTAMP, $1, $2)
+			ON CONFLICT (user_id)
+			DO UPDATE SET token = EXCLUDED.token, updated_at = CURRENT_TIMESTAMP
+			RETURNING id, created_at, updated_at
+		`,
+		UpsertPasswordReset: `
+			INSERT INTO password_resets (created_at, user_id, token, expires_at)
+			VALUES (CURRENT_TIMESTAMP, $1, $2, $3)
+			ON CONFLICT (user_id)
+			DO UPDATE SET token = EXCLUDED.token
+			             ,updated_at = CURRENT_TIMESTAMP
+			             ,expires_at = EXCLUDED.expires_at
+			RETURNING id, created_at, updated_at
+		`,
+		InsertAuditEvent: `
+			INSERT INTO audit_events (created_at, actor_id, action, target_type, target_id, ip, user_agent, request_id, details)
+			VALUES (CURRENT_TIMESTAMP, $1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id, created_at
+		`,
+		Stats: `
+			SELECT (SELECT COUNT(*) FROM users),
+			       (SELECT COUNT(*) FROM users WHERE role = 'admin'),
+			       (SELECT COUNT(*) FROM users WHERE disabled_at IS NOT NULL),
+			       (SELECT COUNT(*) FROM users WHERE created_at > CURRENT_TIMESTAMP - INTERVAL '7 days'),
+			       (SELECT COUNT(*) FROM sessions),
+			       (SELECT COUNT(*) FROM password_resets WHERE expires_at > CURRENT_TIMESTAMP)
+		`,
+	},
+}
+
+// classify tells the constraint violations apart by their SQLSTATE
+func classify(err error) sqlrepo.Violation {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return sqlrepo.NoViolation
+	}
+	switch pgErr.Code {
+	case "23505": // unique_violation
+		return sqlrepo.UniqueViolation
+	case "23503": // foreign_key_violation
+		return sqlrepo.ForeignKeyViolation
+	case "23514": // check_violation
+		return sqlrepo.CheckViolation
+	}
+	return sqlrepo.NoViolation
+}
+
+func NewUserRepository(db *DB) (repositories.User, error) {
+	return sqlrepo.NewUserRepository(db, dialect)
+}
+
+func NewSessionRepository(db *DB, logger *slog.Logger) (repositories.Session, error) {
+	return sqlrepo.NewSessionRepository(db, dialect, logger)
+}
+
+func NewPasswordResetRepository(db *DB, logger *slog.Logger) (repositories.PasswordReset, error) {
+	return sqlrepo.NewPasswordResetRepository(db, dialect, logger)
+}
+
+func NewAuditRepository(db *DB) (repositories.Audit, error) {
+	return sqlrepo.NewAuditRepository(db, dialect)
+}
+
+func NewStatsRepository(db *DB) (repositories.Stats, error) {
+	return sqlrepo.NewStatsRepository(db, dialect)
+}
+
+// NewUnitOfWork runs the operations of the repositories in transactions,
+// binding their prepared statements to each transaction.
+//
+// Possible errors:
+//   - ErrForeignRepository
+func NewUnitOfWork(
+	db *DB,
+	users repositories.User,
+	sessions repositories.Session,
+	passwordResets repositories.PasswordReset,
+	audit repositories.Audit) (repositories.UnitOfWork, error) {
+	/***************************************************/
+	return sqlrepo.NewUnitOfWork(db, dialect, users, sessions, passwordResets, audit)
+}
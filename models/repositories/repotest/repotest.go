@@ -0,0 +1,577 @@
+// Package repotest is the conformance suite of the repositories, every
+// implementation must pass it to be interchangeable with the others.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twsm000/lenslocked/models/entities"
+	"github.com/twsm000/lenslocked/models/repositories"
+)
+
+// Repositories are the implementations under test, sharing the same data
+type Repositories struct {
+	Users          repositories.User
+	Sessions       repositories.Session
+	PasswordResets repositories.PasswordReset
+	Audit          repositories.Audit
+	Stats          repositories.Stats
+	UnitOfWork     repositories.UnitOfWork
+}
+
+// Run runs the suite, newRepos must return repositories without data for
+// every test
+func Run(t *testing.T, newRepos func(t *testing.T) Repositories) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, repos Repositories)
+	}{
+		{"UserCreate", testUserCreate},
+		{"UserFind", testUserFind},
+		{"UserUpdateProfile", testUserUpdateProfile},
+		{"UserUpdatePassword", testUserUpdatePassword},
+		{"UserUpdateDisabledAt", testUserUpdateDisabledAt},
+		{"UserUpdateLocale", testUserUpdateLocale},
+		{"UserSearch", testUserSearch},
+		{"SessionCreate", testSessionCreate},
+		{"SessionDelete", testSessionDelete},
+		{"SessionOfDisabledUser", testSessionOfDisabledUser},
+		{"PasswordReset", testPasswordReset},
+		{"AuditList", testAuditList},
+		{"Stats", testStats},
+		{"UnitOfWorkCommits", testUnitOfWorkCommits},
+		{"UnitOfWorkRollsBack", testUnitOfWorkRollsBack},
+		{"CancelledContext", testCancelledContext},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newRepos(t))
+		})
+	}
+}
+
+var ctx = context.Background()
+
+// createUser creates an user with the email and a valid password
+func createUser(t *testing.T, repos Repositories, email string) *entities.User {
+	t.Helper()
+	var input entities.UserCreatable
+	input.Email.Set(email)
+	input.Password.Set("secret-password")
+	user, err := entities.NewCreatableUser(input)
+	require.NoError(t, err)
+	require.NoError(t, repos.Users.Create(ctx, user))
+	return user
+}
+
+// createSession creates a session of the user and returns it with the token
+func createSession(t *testing.T, repos Repositories, userID uint64) *entities.Session {
+	t.Helper()
+	session, err := entities.NewCreatableSession(userID, entities.MinBytesPerToken)
+	require.NoError(t, err)
+	require.NoError(t, repos.Sessions.Create(ctx, session))
+	return session
+}
+
+func setProfile(t *testing.T, repos Repositories, user *entities.User, username, displayName string) {
+	t.Helper()
+	user.Profile.Username.Set(username)
+	user.Profile.DisplayName = displayName
+	require.NoError(t, repos.Users.UpdateProfile(ctx, user))
+}
+
+func disable(t *testing.T, repos Repositories, user *entities.User) {
+	t.Helper()
+	now := time.Now()
+	user.DisabledAt = &now
+	require.NoError(t, repos.Users.UpdateDisabledAt(ctx, user))
+}
+
+func ids(users []entities.User) []uint64 {
+	result := []uint64{}
+	for _, u := range users {
+		result = append(result, u.ID)
+	}
+	return result
+}
+
+func testUserCreate(t *testing.T, repos Repositories) {
+	alice := createUser(t, repos, "Alice@Example.com")
+	assert.NotZero(t, alice.ID)
+	assert.False(t, alice.CreatedAt.IsZero())
+
+	bob := createUser(t, repos, "bob@example.com")
+	assert.Greater(t, bob.ID, alice.ID)
+
+	var duplicate entities.User
+	duplicate.Email.Set("alice@example.com")
+	duplicate.Password = alice.Password
+	err := repos.Users.Create(ctx, &duplicate)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, repositories.ErrFailedToCreateUser)
+	assert.ErrorIs(t, err, repositories.ErrDuplicateUserEmailNotAllowed)
+	assert.True(t, err.IsClientErr())
+}
+
+func testUserFind(t *testing.T, repos Repositories) {
+	alice := createUser(t, repos, "alice@example.com")
+	setProfile(t, repos, alice, "alice", "Alice")
+
+	byEmail, err := repos.Users.FindByEmail(ctx, alice.Email)
+	require.NoError(t, err)
+	assert.Equal(t, alice.ID, byEmail.ID)
+	assert.Equal(t, entities.RoleUser, byEmail.Role)
+	assert.NoError(t, byEmail.Password.Compare("secret-password"))
+
+	byID, err := repos.Users.FindByID(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, alice.Email, byID.Email)
+	assert.Equal(t, "Alice", byID.Profile.DisplayName)
+
+	byUsername, err := repos.Users.FindByUsername(ctx, alice.Profile.Username)
+	require.NoError(t, err)
+	assert.Equal(t, alice.ID, byUsername.ID)
+
+	var unknownEmail entities.Email
+	unknownEmail.Set("nobody@example.com")
+	_, err = repos.Users.FindByEmail(ctx, unknownEmail)
+	assert.ErrorIs(t, err, repositories.ErrUserNotFound)
+	_, err = repos.Users.FindByID(ctx, alice.ID+100)
+	assert.ErrorIs(t, err, repositories.ErrUserNotFound)
+	var unknownUsername entities.Username
+	unknownUsername.Set("nobody")
+	_, err = repos.Users.FindByUsername(ctx, unknownUsername)
+	assert.ErrorIs(t, err, repositories.ErrUserNotFound)
+}
+
+func testUserUpdateProfile(t *testing.T, repos Repositories) {
+	alice := createUser(t, repos, "alice@example.com")
+	bob := createUser(t, repos, "bob@example.com")
+	setProfile(t, repos, alice, "alice", "Alice")
+	assert.NotNil(t, alice.UpdatedAt)
+
+	// users without username do not conflict
+	setProfile(t, repos, bob, "", "Bob")
+
+	bob.Profile.Username.Set("alice")
+	err := repos.Users.UpdateProfile(ctx, bob)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, repositories.ErrFailedToUpdateUserProfile)
+	assert.ErrorIs(t, err, repositories.ErrDuplicateUsernameNotAllowed)
+	assert.True(t, err.IsClientErr())
+
+	stored, err := repos.Users.FindByID(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.Profile.Username.IsEmpty())
+	assert.Equal(t, "Bob", stored.Profile.DisplayName)
+}
+
+func testUserUpdatePassword(t *testing.T, repos Repositories) {
+	alice := createUser(t, repos, "alice@example.com")
+	require.NoError(t, alice.Password.GenerateFrom("another-password"))
+	require.NoError(t, repos.Users.UpdatePassword(ctx, alice))
+
+	stored, err := repos.Users.FindByID(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.NoError(t, stored.Password.Compare("another-password"))
+	assert.Error(t, stored.Password.Compare("secret-password"))
+}
+
+func testUserUpdateDisabledAt(t *testing.T, repos Repositories) {
+	alice := createUser(t, repos, "alice@example.com")
+	disable(t, repos, alice)
+
+	stored, findErr := repos.Users.FindByID(ctx, alice.ID)
+	require.NoError(t, findErr)
+	assert.True(t, stored.IsDisabled())
+
+	alice.DisabledAt = nil
+	require.NoError(t, repos.Users.UpdateDisabledAt(ctx, alice))
+	stored, findErr = repos.Users.FindByID(ctx, alice.ID)
+	require.NoError(t, findErr)
+	assert.False(t, stored.IsDisabled())
+
+	unknown := entities.User{ID: alice.ID + 100}
+	err := repos.Users.UpdateDisabledAt(ctx, &unknown)
+	assert.ErrorIs(t, err, repositories.ErrFailedToUpdateUser)
+}
+
+func testUserUpdateLocale(t *testing.T, repos Repositories) {
+	alice := createUser(t, repos, "alice@example.com")
+	assert.Empty(t, alice.Locale)
+
+	alice.Locale = "pt-BR"
+	require.NoError(t, repos.Users.UpdateLocale(ctx, alice))
+	stored, findErr := repos.Users.FindByID(ctx, alice.ID)
+	require.NoError(t, findErr)
+	assert.Equal(t, "pt-BR", stored.Locale)
+
+	unknown := entities.User{ID: alice.ID + 100, Locale: "en"}
+	err := repos.Users.UpdateLocale(ctx, &unknown)
+	assert.ErrorIs(t, err, repositories.ErrFailedToUpdateUser)
+	assert.ErrorIs(t, err, repositories.ErrUserNotFound)
+}
+
+func testUserSearch(t *testing.T, repos Repositories) {
+	alice := createUser(t, repos, "alice@example.com")
+	bob := createUser(t, repos, "bob@example.com")
+	carol := createUser(t, repos, "carol@example.org")
+	setProfile(t, repos, bob, "bobby", "Robert Smith")
+
+	users, total, err := repos.Users.Search(ctx, repositories.UserFilter{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, []uint64{alice.ID, bob.ID, carol.ID}, ids(users))
+
+	users, total, err = repos.Users.Search(ctx, repositories.UserFilter{Query: "EXAMPLE.COM", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, []uint64{alice.ID, bob.ID}, ids(users))
+
+	users, _, err = repos.Users.Search(ctx, repositories.UserFilter{Query: "smith", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{bob.ID}, ids(users))
+
+	// the LIKE wildcards match literally
+	users, total, err = repos.Users.Search(ctx, repositories.UserFilter{Query: "%", Limit: 10})
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	assert.Empty(t, users)
+
+	users, total, err = repos.Users.Search(ctx, repositories.UserFilter{Limit: 2, Offset: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, []uint64{bob.ID, carol.ID}, ids(users))
+
+	users, total, err = repos.Users.Search(ctx, repositories.UserFilter{Limit: 2, Offset: 3})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Empty(t, users)
+}
+
+func testSessionCreate(t *testing.T, repos Repositories) {
+	alice := createUser(t, repos, "alice@example.com")
+	first := createSession(t, repos, alice.ID)
+	assert.NotZero(t, first.ID)
+	assert.Nil(t, first.UpdatedAt)
+
+	user, err := repos.Sessions.FindUserByToken(ctx, first.Token)
+	require.NoError(t, err)
+	assert.Equal(t, alice.ID, user.ID)
+	assert.Equal(t, alice.Email, user.Email)
+
+	// a new sign in replaces the session of the user
+	second := createSession(t, repos, alice.ID)
+	assert.Equal(t, first.ID, second.ID)
+	assert.NotNil(t, second.UpdatedAt)
+	_, err = repos.Sessions.FindUserByToken(ctx, first.Token)
+	assert.ErrorIs(t, err, repositories.ErrUserNotFound)
+	_, err = repos.Sessions.FindUserByToken(ctx, second.Token)
+	assert.NoError(t, err)
+
+	sessions, err := repos.Sessions.FindByUserID(ctx, alice.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, second.ID, sessions[0].ID)
+	assert.Equal(t, alice.ID, sessions[0].UserID)
+	assert.Empty(t, sessions[0].Token.Value())
+
+	orphan, err := entities.NewCreatableSession(alice.ID+100, entities.MinBytesPerToken)
+	require.NoError(t, err)
+	err = repos.Sessions.Create(ctx, orphan)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, repositories.ErrFailedToCreateSession)
+	assert.ErrorIs(t, err, repositories.ErrUserNotFound)
+}
+
+func testSessionDelete(t *testing.T, repos Repositories) {
+	alice := createUser(t, repos, "alice@example.com")
+	bob := createUser(t, repos, "bob@example.com")
+	aliceSession := createSession(t, repos, alice.ID)
+	bobSession := createSession(t, repos, bob.ID)
+
+	require.NoError(t, repos.Sessions.DeleteByToken(ctx, aliceSession.Token))
+	_, err := repos.Sessions.FindUserByToken(ctx, aliceSession.Token)
+	assert.ErrorIs(t, err, repositories.ErrUserNotFound)
+	// deleting twice is not an error
+	assert.NoError(t, repos.Sessions.DeleteByToken(ctx, aliceSession.Token))
+
+	deleted, err := repos.Sessions.DeleteByUserID(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	_, err = repos.Sessions.FindUserByToken(ctx, bobSession.Token)
+	assert.ErrorIs(t, err, repositories.ErrUserNotFound)
+
+	deleted, err = repos.Sessions.DeleteByUserID(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+	sessions, err := repos.Sessions.FindByUserID(ctx, bob.ID)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	createSession(t, repos, alice.ID)
+	createSession(t, repos, bob.ID)
+	count, err := repos.Sessions.Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	deleted, err = repos.Sessions.DeleteAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+	stats, err := repos.Stats.Stats(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, stats.Sessions)
+	count, err = repos.Sessions.Count(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func testSessionOfDisabledUser(t *testing.T, repos Repositories) {
+	alice := createUser(t, repos, "alice@example.com")
+	session := createSession(t, repos, alice.ID)
+	disable(t, repos, alice)
+
+	_, err := repos.Sessions.FindUserByToken(ctx, session.Token)
+	assert.ErrorIs(t, err, repositories.ErrUserNotFound)
+}
+
+func testPasswordReset(t *testing.T, repos Repositories) {
+	alice := createUser(t, repos, "alice@example.com")
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	first, newErr := entities.NewCreatablePasswordReset(alice.ID, entities.MinBytesPerToken, expiresAt)
+	require.NoError(t, newErr)
+	require.NoError(t, repos.PasswordResets.Create(ctx, first))
+	assert.NotZero(t, first.ID)
+
+	reset, user, err := repos.PasswordResets.FindPasswordResetAndUserByToken(ctx, first.Token)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, reset.ID)
+	assert.Equal(t, alice.ID, reset.UserID)
+	assert.True(t, expiresAt.Equal(reset.ExpiresAt))
+	assert.Equal(t, alice.ID, user.ID)
+
+	// a new request replaces the reset of the user
+	second, newErr := entities.NewCreatablePasswordReset(alice.ID, entities.MinBytesPerToken, expiresAt)
+	require.NoError(t, newErr)
+	require.NoError(t, repos.PasswordResets.Create(ctx, second))
+	assert.Equal(t, first.ID, second.ID)
+	_, _, err = repos.PasswordResets.FindPasswordResetAndUserByToken(ctx, first.Token)
+	assert.ErrorIs(t, err, repositories.ErrUserNotFound)
+
+	require.NoError(t, repos.PasswordResets.DeleteByID(ctx, second.ID))
+	_, _, err = repos.PasswordResets.FindPasswordResetAndUserByToken(ctx, second.Token)
+	assert.ErrorIs(t, err, repositories.ErrUserNotFound)
+	// the token is consumed only once
+	err = repos.PasswordResets.DeleteByID(ctx, second.ID)
+	assert.ErrorIs(t, err, repositories.ErrFailedToDeletePasswordReset)
+	assert.ErrorIs(t, err, repositories.ErrPasswordResetNotFound)
+
+	orphan, newErr := entities.NewCreatablePasswordReset(alice.ID+100, entities.MinBytesPerToken, expiresAt)
+	require.NoError(t, newErr)
+	err = repos.PasswordResets.Create(ctx, orphan)
+	assert.ErrorIs(t, err, repositories.ErrFailedToCreatePasswordReset)
+	assert.ErrorIs(t, err, repositories.ErrUserNotFound)
+}
+
+func testAuditList(t *testing.T, repos Repositories) {
+	alice := createUser(t, repos, "alice@example.com")
+	bob := createUser(t, repos, "bob@example.com")
+	events := []entities.AuditEvent{
+		{Action: entities.AuditSignInFailed, Source: entities.AuditSource{IP: "10.0.0.1"}},
+		{ActorID: &alice.ID, Action: entities.AuditSignInSucceeded, Source: entities.AuditSource{IP: "10.0.0.1"}},
+		{
+			ActorID:    &alice.ID,
+			Action:     entities.AuditUserDisabled,
+			TargetType: entities.AuditTargetUser,
+			TargetID:   &bob.ID,
+			Source:     entities.AuditSource{IP: "10.0.0.2", UserAgent: "test", RequestID: "req-1"},
+			Details:    map[string]string{"reason": "spam"},
+		},
+	}
+	for i := range events {
+		require.NoError(t, repos.Audit.Create(ctx, &events[i]))
+		assert.NotZero(t, events[i].ID)
+		assert.False(t, events[i].CreatedAt.IsZero())
+	}
+
+	list, total, err := repos.Audit.List(ctx, repositories.AuditFilter{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, list, 3)
+	// newest first
+	assert.Equal(t, []uint64{events[2].ID, events[1].ID, events[0].ID}, []uint64{list[0].ID, list[1].ID, list[2].ID})
+	disabled := list[0]
+	assert.Equal(t, entities.AuditUserDisabled, disabled.Action)
+	assert.Equal(t, alice.ID, *disabled.ActorID)
+	assert.Equal(t, entities.AuditTargetUser, disabled.TargetType)
+	assert.Equal(t, bob.ID, *disabled.TargetID)
+	assert.Equal(t, events[2].Source, disabled.Source)
+	assert.Equal(t, map[string]string{"reason": "spam"}, disabled.Details)
+	assert.Nil(t, list[2].ActorID)
+	assert.NotNil(t, list[2].Details)
+	assert.Empty(t, list[2].Details)
+
+	list, total, err = repos.Audit.List(ctx, repositories.AuditFilter{UserID: bob.ID, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, events[2].ID, list[0].ID)
+
+	_, total, err = repos.Audit.List(ctx, repositories.AuditFilter{UserID: alice.ID, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+
+	list, total, err = repos.Audit.List(ctx, repositories.AuditFilter{Action: entities.AuditSignInFailed, IP: "10.0.0.1", Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, events[0].ID, list[0].ID)
+
+	list, total, err = repos.Audit.List(ctx, repositories.AuditFilter{Limit: 1, Offset: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+	require.Len(t, list, 1)
+	assert.Equal(t, events[1].ID, list[0].ID)
+}
+
+func testStats(t *testing.T, repos Repositories) {
+	stats, err := repos.Stats.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, entities.Stats{}, *stats)
+
+	alice := createUser(t, repos, "alice@example.com")
+	bob := createUser(t, repos, "bob@example.com")
+	createSession(t, repos, alice.ID)
+	createSession(t, repos, bob.ID)
+	disable(t, repos, bob)
+	pending, err := entities.NewCreatablePasswordReset(alice.ID, entities.MinBytesPerToken, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, repos.PasswordResets.Create(ctx, pending))
+	expired, err := entities.NewCreatablePasswordReset(bob.ID, entities.MinBytesPerToken, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, repos.PasswordResets.Create(ctx, expired))
+
+	stats, err = repos.Stats.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, entities.Stats{
+		Users:                 2,
+		DisabledUsers:         1,
+		NewUsersLastWeek:      2,
+		Sessions:              2,
+		PendingPasswordResets: 1,
+	}, *stats)
+}
+
+func testUnitOfWorkCommits(t *testing.T, repos Repositories) {
+	alice := createUser(t, repos, "alice@example.com")
+	session := createSession(t, repos, alice.ID)
+
+	err := repos.UnitOfWork.Do(ctx, func(tx repositories.Transaction) error {
+		now := time.Now()
+		alice.DisabledAt = &now
+		if err := tx.Users.UpdateDisabledAt(ctx, alice); err != nil {
+			return err
+		}
+		_, err := tx.Sessions.DeleteByUserID(ctx, alice.ID)
+		return err
+	})
+	require.NoError(t, err)
+
+	stored, err := repos.Users.FindByID(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.IsDisabled())
+	sessions, err := repos.Sessions.FindByUserID(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+	_, err = repos.Sessions.FindUserByToken(ctx, session.Token)
+	assert.ErrorIs(t, err, repositories.ErrUserNotFound)
+
+	err = repos.UnitOfWork.Do(ctx, func(tx repositories.Transaction) error {
+		return tx.Audit.Create(ctx, entities.NewUserAuditEvent(nil, entities.AuditSource{}, entities.AuditUserDisabled, alice.ID))
+	})
+	require.NoError(t, err)
+	events, total, err := repos.Audit.List(ctx, repositories.AuditFilter{Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, events, 1)
+	assert.Equal(t, entities.AuditUserDisabled, events[0].Action)
+}
+
+func testUnitOfWorkRollsBack(t *testing.T, repos Repositories) {
+	alice := createUser(t, repos, "alice@example.com")
+	session := createSession(t, repos, alice.ID)
+
+	errFailed := errors.New("failed midway")
+	err := repos.UnitOfWork.Do(ctx, func(tx repositories.Transaction) error {
+		if _, err := tx.Sessions.DeleteByUserID(ctx, alice.ID); err != nil {
+			return err
+		}
+		changed := entities.User{ID: alice.ID, Profile: entities.Profile{DisplayName: "Changed"}}
+		if err := tx.Users.UpdateProfile(ctx, &changed); err != nil {
+			return err
+		}
+		event := entities.NewUserAuditEvent(nil, entities.AuditSource{}, entities.AuditUserSignedOut, alice.ID)
+		if err := tx.Audit.Create(ctx, event); err != nil {
+			return err
+		}
+		return errFailed
+	})
+	assert.ErrorIs(t, err, errFailed)
+
+	assert.Panics(t, func() {
+		repos.UnitOfWork.Do(ctx, func(tx repositories.Transaction) error {
+			if _, err := tx.Sessions.DeleteByUserID(ctx, alice.ID); err != nil {
+				return err
+			}
+			panic("unexpected")
+		})
+	})
+
+	user, err := repos.Sessions.FindUserByToken(ctx, session.Token)
+	require.NoError(t, err)
+	assert.Equal(t, alice.ID, user.ID)
+	assert.Empty(t, user.Profile.DisplayName)
+	events, _, err := repos.Audit.List(ctx, repositories.AuditFilter{Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func testCancelledContext(t *testing.T, repos Repositories) {
+	alice := createUser(t, repos, "alice@example.com")
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	// a failed query is not a missing user, so it is not a client error
+	_, findErr := repos.Users.FindByID(cancelled, alice.ID)
+	assert.ErrorIs(t, findErr, repositories.ErrFailedToFindUser)
+	assert.NotErrorIs(t, findErr, repositories.ErrUserNotFound)
+	assert.ErrorIs(t, findErr, context.Canceled)
+	assert.False(t, findErr.IsClientErr())
+	_, findErr = repos.Users.FindByEmail(cancelled, alice.Email)
+	assert.ErrorIs(t, findErr, repositories.ErrFailedToFindUser)
+	assert.False(t, findErr.IsClientErr())
+	_, err := repos.Sessions.FindUserByToken(cancelled, entities.SessionToken{})
+	assert.ErrorIs(t, err, repositories.ErrFailedToFindUser)
+	assert.NotErrorIs(t, err, repositories.ErrUserNotFound)
+	_, _, err = repos.Users.Search(cancelled, repositories.UserFilter{Limit: 10})
+	assert.ErrorIs(t, err, repositories.ErrFailedToSearchUsers)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repos.Sessions.DeleteByUserID(cancelled, alice.ID)
+	assert.ErrorIs(t, err, repositories.ErrFailedToDeleteSession)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, _, err = repos.Audit.List(cancelled, repositories.AuditFilter{Limit: 10})
+	assert.ErrorIs(t, err, repositories.ErrFailedToListAuditEvents)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = repos.Stats.Stats(cancelled)
+	assert.ErrorIs(t, err, repositories.ErrFailedToCollectStats)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	err = repos.UnitOfWork.Do(cancelled, func(tx repositories.Transaction) error {
+		t.Fatal("ran in a cancelled context")
+		return nil
+	})
+	assert.ErrorIs(t, err, repositories.ErrFailedToBeginTransaction)
+	assert.ErrorIs(t, err, context.Canceled)
+}
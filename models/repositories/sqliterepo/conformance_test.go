@@ -0,0 +1,57 @@
+package sqliterepo
+
+import (
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/twsm000/lenslocked/models/database"
+	"github.com/twsm000/lenslocked/models/repositories/repotest"
+	_ "modernc.org/sqlite"
+)
+
+func TestConformance(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		config := database.Config{
+			Driver: database.DriverSQLite,
+			Path:   filepath.Join(t.TempDir(), "lenslocked.db"),
+		}
+		sqlDB, err := database.NewConnection(config)
+		require.NoError(t, err)
+		t.Cleanup(func() { sqlDB.Close() })
+		require.NoError(t, database.MigrateFS(sqlDB, config, ""))
+		db := &DB{DB: sqlDB}
+
+		users, err := NewUserRepository(db)
+		require.NoError(t, err)
+		sessions, err := NewSessionRepository(db, logger)
+		require.NoError(t, err)
+		passwordResets, err := NewPasswordResetRepository(db, logger)
+		require.NoError(t, err)
+		audit, err := NewAuditRepository(db)
+		require.NoError(t, err)
+		stats, err := NewStatsRepository(db)
+		require.NoError(t, err)
+		uow, err := NewUnitOfWork(db, users, sessions, passwordResets, audit)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			users.Close()
+			sessions.Close()
+			passwordResets.Close()
+			audit.Close()
+			stats.Close()
+		})
+
+		return repotest.Repositories{
+			Users:          users,
+			Sessions:       sessions,
+			PasswordResets: passwordResets,
+			Audit:          audit,
+			Stats:          stats,
+			UnitOfWork:     uow,
+		}
+	})
+}
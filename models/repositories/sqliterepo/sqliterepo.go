@@ -0,0 +1,152 @@
+// Package sqliterepo implements the repositories on SQLite, for the
+// deployments without a database server.
+//
+// SQLite stores the timestamps as text, so they are always bound in UTC to
+// compare and sort as times.
+package sqliterepo
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/twsm000/lenslocked/models/repositories"
+	"github.com/twsm000/lenslocked/models/repositories/internal/sqlrepo"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+var (
+	ErrForeignRepository = sqlrepo.ErrForeignRepository
+)
+
+// DB is the database the repositories prepare their statements on
+type DB = sqlrepo.DB
+
+// dialect binds the current time, since CURRENT_TIMESTAMP is written in a
+// format not comparing with the times bound
+var dialect = &sqlrepo.Dialect{
+	System:   semconv.DBSystemSqlite,
+	Now:      now,
+	Classify: classify,
+	Queries: sqlrepo.Queries{
+		InsertUser: `
+			INSERT INTO users (created_at, email, password, role)
+			VALUES ($4, $1, $2, $3)
+			RETURNING id, created_at
+		`,
+		// LIKE ignores the case of the ASCII letters only
+		SearchUsersFilter: `
+			 WHERE $1 = ''
+			    OR u.email LIKE $1 ESCAPE '\'
+			    OR u.username LIKE $1 ESCAPE '\'
+			    OR u.display_name LIKE $1 ESCAPE '\'
+		`,
+		UpdateUserProfile: `
+			UPDATE users
+			   SET username = $2,
+			       display_name = $3,
+			       bio = $4,
+			       avatar = $5,
+			       updated_at = $6
+			 WHERE id = $1
+			RETURNING updated_at
+		`,
+		UpdateUserDisabledAt: `
+			UPDATE users
+			   SET disabled_at = $2,
+			       updated_at = $3
+			 WHERE id = $1
+			RETURNING updated_at
+		`,
+		// the session of the user is updated at the created_at of the
+		// conflicting row, $3
+		UpsertSession: `
+			INSERT INTO sessions (created_at, user_id, token)
+			VALUES ($3, $1, $2)
+			ON CONFLICT (user_id)
+			DO UPDATE SET token = excluded.token, updated_at = excluded.created_at
+			RETURNING id, created_at, updated_at
+		`,
+		UpsertPasswordReset: `
+			INSERT INTO password_resets (created_at, user_id, token, expires_at)
+			VALUES ($4, $1, $2, $3)
+			ON CONFLICT (user_id)
+			DO UPDATE SET token = excluded.token
+			             ,updated_at = excluded.created_at
+			             ,expires_at = excluded.expires_at
+			RETURNING id, created_at, updated_at
+		`,
+		InsertAuditEvent: `
+			INSERT INTO audit_events (created_at, actor_id, action, target_type, target_id, ip, user_agent, request_id, details)
+			VALUES ($9, $1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id, created_at
+		`,
+		Stats: `
+			SELECT (SELECT COUNT(*) FROM users),
+			       (SELECT COUNT(*) FROM users WHERE role = 'admin'),
+			       (SELECT COUNT(*) FROM users WHERE disabled_at IS NOT NULL),
+			       (SELECT COUNT(*) FROM users WHERE created_at > $2),
+			       (SELECT COUNT(*) FROM sessions),
+			       (SELECT COUNT(*) FROM password_resets WHERE expires_at > $1)
+		`,
+	},
+}
+
+func now() time.Time {
+	return time.Now().UTC()
+}
+
+// classify tells the constraint violations apart by their extended result
+// codes, enabled by the driver
+func classify(err error) sqlrepo.Violation {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return sqlrepo.NoViolation
+	}
+	switch sqliteErr.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE:
+		return sqlrepo.UniqueViolation
+	case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		return sqlrepo.ForeignKeyViolation
+	case sqlite3.SQLITE_CONSTRAINT_CHECK:
+		return sqlrepo.CheckViolation
+	}
+	return sqlrepo.NoViolation
+}
+
+func NewUserRepository(db *DB) (repositories.User, error) {
+	return sqlrepo.NewUserRepository(db, dialect)
+}
+
+func NewSessionRepository(db *DB, logger *slog.Logger) (repositories.Session, error) {
+	return sqlrepo.NewSessionRepository(db, dialect, logger)
+}
+
+func NewPasswordResetRepository(db *DB, logger *slog.Logger) (repositories.PasswordReset, error) {
+	return sqlrepo.NewPasswordResetRepository(db, dialect, logger)
+}
+
+func NewAuditRepository(db *DB) (repositories.Audit, error) {
+	return sqlrepo.NewAuditRepository(db, dialect)
+}
+
+func NewStatsRepository(db *DB) (repositories.Stats, error) {
+	return sqlrepo.NewStatsRepository(db, dialect)
+}
+
+// NewUnitOfWork runs the operations of the repositories in transactions,
+// binding their prepared statements to each transaction.
+//
+// Possible errors:
+//   - ErrForeignRepository
+func NewUnitOfWork(
+	db *DB,
+	users repositories.User,
+	sessions repositories.Session,
+	passwordResets repositories.PasswordReset,
+	audit repositories.Audit) (repositories.UnitOfWork, error) {
+	/***************************************************/
+	return sqlrepo.NewUnitOfWork(db, dialect, users, sessions, passwordResets, audit)
+}
@@ -0,0 +1,323 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/twsm000/lenslocked/models/entities"
+	"github.com/twsm000/lenslocked/models/repositories"
+)
+
+// Admin holds the administrative actions on users. Every action changing
+// a user is recorded in the audit log, with the admin as the actor, in the
+// transaction of the action.
+type Admin interface {
+	// Stats possible errors:
+	//   - repositories.ErrFailedToCollectStats
+	Stats(ctx context.Context) (*entities.Stats, error)
+
+	// SearchUsers possible errors:
+	//   - repositories.ErrFailedToSearchUsers
+	SearchUsers(ctx context.Context, filter repositories.UserFilter) ([]entities.User, int, error)
+
+	// User returns the user and its sessions. Possible errors:
+	//   - repositories.ErrUserNotFound
+	//   - repositories.ErrFailedToFindSessions
+	User(ctx context.Context, id uint64) (*entities.User, []entities.Session, entities.Error)
+
+	// SignOutUser revokes every session of the user. Possible errors:
+	//   - repositories.ErrUserNotFound
+	//   - repositories.ErrFailedToDeleteSession
+	//   - repositories.ErrFailedToBeginTransaction
+	//   - repositories.ErrFailedToCommitTransaction
+	//   - ErrAuditEventNotRecorded {repositories.ErrFailedToCreateAuditEvent}
+	SignOutUser(ctx context.Context, actor *entities.User, source entities.AuditSource, id uint64) entities.Error
+
+	// DisableUser disables the account and signs the user out in a single
+	// transaction. Possible errors:
+	//   - ErrCannotActOnSelf
+	//   - repositories.ErrUserNotFound
+	//   - repositories.ErrFailedToUpdateUser
+	//   - repositories.ErrFailedToDeleteSession
+	//   - repositories.ErrFailedToBeginTransaction
+	//   - repositories.ErrFailedToCommitTransaction
+	//   - ErrAuditEventNotRecorded {repositories.ErrFailedToCreateAuditEvent}
+	DisableUser(ctx context.Context, actor *entities.User, source entities.AuditSource, id uint64) entities.Error
+
+	// EnableUser possible errors:
+	//   - repositories.ErrUserNotFound
+	//   - repositories.ErrFailedToUpdateUser
+	//   - repositories.ErrFailedToBeginTransaction
+	//   - repositories.ErrFailedToCommitTransaction
+	//   - ErrAuditEventNotRecorded {repositories.ErrFailedToCreateAuditEvent}
+	EnableUser(ctx context.Context, actor *entities.User, source entities.AuditSource, id uint64) entities.Error
+
+	// ResetUserPassword creates a password reset for the user, the caller
+	// sends it to the user. Possible errors:
+	//   - repositories.ErrUserNotFound
+	//   - repositories.ErrFailedToCreatePasswordReset
+	//   - repositories.ErrFailedToBeginTransaction
+	//   - repositories.ErrFailedToCommitTransaction
+	//   - ErrAuditEventNotRecorded {repositories.ErrFailedToCreateAuditEvent}
+	ResetUserPassword(ctx context.Context, actor *entities.User, source entities.AuditSource, id uint64) (*entities.User, *entities.PasswordReset, entities.Error)
+
+	// SetUserPassword changes the password of the user and signs the user
+	// out in a single transaction. Possible errors:
+	//   - repositories.ErrUserNotFound
+	//   - entities.ErrFailedToHashPassword
+	//   - entities.ErrInvalidPassword
+	//   - repositories.ErrFailedToUpdateUserPassword
+	//   - repositories.ErrFailedToDeleteSession
+	//   - repositories.ErrFailedToBeginTransaction
+	//   - repositories.ErrFailedToCommitTransaction
+	//   - ErrAuditEventNotRecorded {repositories.ErrFailedToCreateAuditEvent}
+	SetUserPassword(ctx context.Context, actor *entities.User, source entities.AuditSource, id uint64, rawPassword entities.RawPassword) entities.Error
+
+	// SignOutEveryone revokes every session of every user and returns how
+	// many. Possible errors:
+	//   - repositories.ErrFailedToDeleteSession
+	//   - repositories.ErrFailedToBeginTransaction
+	//   - repositories.ErrFailedToCommitTransaction
+	//   - ErrAuditEventNotRecorded {repositories.ErrFailedToCreateAuditEvent}
+	SignOutEveryone(ctx context.Context, actor *entities.User, source entities.AuditSource) (int64, entities.Error)
+
+	// AuditEvents returns a page of the audit log events matching the
+	// filter, newest first, and the total of events matching it.
+	// Possible errors:
+	//   - repositories.ErrFailedToListAuditEvents
+	AuditEvents(ctx context.Context, filter repositories.AuditFilter) ([]entities.AuditEvent, int, error)
+}
+
+func NewAdmin(
+	userRepo repositories.User,
+	sessionRepo repositories.Session,
+	statsRepo repositories.Stats,
+	auditLogger AuditLogger,
+	passwordResetService PasswordReset,
+	uow repositories.UnitOfWork) Admin {
+	/***************************************************/
+	return &adminService{
+		UserRepository:       userRepo,
+		SessionRepository:    sessionRepo,
+		StatsRepository:      statsRepo,
+		AuditLogger:          auditLogger,
+		PasswordResetService: passwordResetService,
+		UnitOfWork:           uow,
+	}
+}
+
+type adminService struct {
+	UserRepository       repositories.User
+	SessionRepository    repositories.Session
+	StatsRepository      repositories.Stats
+	AuditLogger          AuditLogger
+	PasswordResetService PasswordReset
+	UnitOfWork           repositories.UnitOfWork
+}
+
+func (as *adminService) Stats(ctx context.Context) (*entities.Stats, error) {
+	ctx, span := tracer.Start(ctx, "Admin.Stats")
+	defer span.End()
+
+	return as.StatsRepository.Stats(ctx)
+}
+
+func (as *adminService) SearchUsers(ctx context.Context, filter repositories.UserFilter) ([]entities.User, int, error) {
+	ctx, span := tracer.Start(ctx, "Admin.SearchUsers")
+	defer span.End()
+
+	return as.UserRepository.Search(ctx, filter)
+}
+
+func (as *adminService) User(ctx context.Context, id uint64) (*entities.User, []entities.Session, entities.Error) {
+	ctx, span := tracer.Start(ctx, "Admin.User")
+	defer span.End()
+
+	user, err := as.UserRepository.FindByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sessions, sErr := as.SessionRepository.FindByUserID(ctx, id)
+	if sErr != nil {
+		return nil, nil, entities.NewError(sErr)
+	}
+	return user, sessions, nil
+}
+
+func (as *adminService) SignOutUser(ctx context.Context, actor *entities.User, source entities.AuditSource, id uint64) entities.Error {
+	ctx, span := tracer.Start(ctx, "Admin.SignOutUser")
+	defer span.End()
+
+	user, err := as.UserRepository.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	txErr := as.UnitOfWork.Do(ctx, func(tx repositories.Transaction) error {
+		deleted, err := tx.Sessions.DeleteByUserID(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		event := entities.NewUserAuditEvent(actor, source, entities.AuditUserSignedOut, user.ID)
+		event.Details["sessions"] = strconv.FormatInt(deleted, 10)
+		return as.AuditLogger.RecordIn(ctx, tx, event)
+	})
+	if txErr != nil {
+		return entities.NewError(txErr)
+	}
+	return nil
+}
+
+func (as *adminService) DisableUser(ctx context.Context, actor *entities.User, source entities.AuditSource, id uint64) entities.Error {
+	ctx, span := tracer.Start(ctx, "Admin.DisableUser")
+	defer span.End()
+
+	if actor != nil && actor.ID == id {
+		return entities.NewClientError("error.admin.cannot_disable_self", ErrCannotActOnSelf)
+	}
+
+	user, err := as.UserRepository.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if user.IsDisabled() {
+		return nil
+	}
+
+	now := time.Now()
+	user.DisabledAt = &now
+	txErr := as.UnitOfWork.Do(ctx, func(tx repositories.Transaction) error {
+		if err := tx.Users.UpdateDisabledAt(ctx, user); err != nil {
+			return err
+		}
+		deleted, err := tx.Sessions.DeleteByUserID(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		event := entities.NewUserAuditEvent(actor, source, entities.AuditUserDisabled, user.ID)
+		event.Details["sessions"] = strconv.FormatInt(deleted, 10)
+		return as.AuditLogger.RecordIn(ctx, tx, event)
+	})
+	if txErr != nil {
+		user.DisabledAt = nil
+		return entities.NewError(txErr)
+	}
+	return nil
+}
+
+func (as *adminService) EnableUser(ctx context.Context, actor *entities.User, source entities.AuditSource, id uint64) entities.Error {
+	ctx, span := tracer.Start(ctx, "Admin.EnableUser")
+	defer span.End()
+
+	user, err := as.UserRepository.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !user.IsDisabled() {
+		return nil
+	}
+
+	disabledAt := user.DisabledAt
+	user.DisabledAt = nil
+	txErr := as.UnitOfWork.Do(ctx, func(tx repositories.Transaction) error {
+		if err := tx.Users.UpdateDisabledAt(ctx, user); err != nil {
+			return err
+		}
+		return as.AuditLogger.RecordIn(ctx, tx, entities.NewUserAuditEvent(actor, source, entities.AuditUserEnabled, user.ID))
+	})
+	if txErr != nil {
+		user.DisabledAt = disabledAt
+		return entities.NewError(txErr)
+	}
+	return nil
+}
+
+func (as *adminService) ResetUserPassword(ctx context.Context, actor *entities.User, source entities.AuditSource, id uint64) (*entities.User, *entities.PasswordReset, entities.Error) {
+	ctx, span := tracer.Start(ctx, "Admin.ResetUserPassword")
+	defer span.End()
+
+	user, err := as.UserRepository.FindByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var pr *entities.PasswordReset
+	txErr := as.UnitOfWork.Do(ctx, func(tx repositories.Transaction) error {
+		var err error
+		if pr, err = as.PasswordResetService.CreateIn(ctx, tx, user); err != nil {
+			return err
+		}
+		event := entities.NewUserAuditEvent(actor, source, entities.AuditUserPasswordReset, user.ID)
+		event.Details["expires_at"] = pr.ExpiresAt.UTC().Format(time.RFC3339)
+		return as.AuditLogger.RecordIn(ctx, tx, event)
+	})
+	if txErr != nil {
+		return nil, nil, entities.NewError(txErr)
+	}
+	return user, pr, nil
+}
+
+func (as *adminService) SetUserPassword(
+	ctx context.Context,
+	actor *entities.User,
+	source entities.AuditSource,
+	id uint64,
+	rawPassword entities.RawPassword) entities.Error {
+	/***************************************************/
+	ctx, span := tracer.Start(ctx, "Admin.SetUserPassword")
+	defer span.End()
+
+	user, err := as.UserRepository.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := setPassword(user, rawPassword); err != nil {
+		return entities.NewError(err)
+	}
+
+	txErr := as.UnitOfWork.Do(ctx, func(tx repositories.Transaction) error {
+		if err := tx.Users.UpdatePassword(ctx, user); err != nil {
+			return err
+		}
+		deleted, err := tx.Sessions.DeleteByUserID(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		event := entities.NewUserAuditEvent(actor, source, entities.AuditUserPasswordSet, user.ID)
+		event.Details["sessions"] = strconv.FormatInt(deleted, 10)
+		return as.AuditLogger.RecordIn(ctx, tx, event)
+	})
+	if txErr != nil {
+		return entities.NewError(txErr)
+	}
+	return nil
+}
+
+func (as *adminService) SignOutEveryone(ctx context.Context, actor *entities.User, source entities.AuditSource) (int64, entities.Error) {
+	ctx, span := tracer.Start(ctx, "Admin.SignOutEveryone")
+	defer span.End()
+
+	var deleted int64
+	txErr := as.UnitOfWork.Do(ctx, func(tx repositories.Transaction) error {
+		var err error
+		if deleted, err = tx.Sessions.DeleteAll(ctx); err != nil {
+			return err
+		}
+		event := entities.NewAuditEvent(actor, source, entities.AuditSessionsPurged)
+		event.Details["sessions"] = strconv.FormatInt(deleted, 10)
+		return as.AuditLogger.RecordIn(ctx, tx, event)
+	})
+	if txErr != nil {
+		return 0, entities.NewError(txErr)
+	}
+	return deleted, nil
+}
+
+func (as *adminService) AuditEvents(ctx context.Context, filter repositories.AuditFilter) ([]entities.AuditEvent, int, error) {
+	ctx, span := tracer.Start(ctx, "Admin.AuditEvents")
+	defer span.End()
+
+	return as.AuditLogger.List(ctx, filter)
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twsm000/lenslocked/models/entities"
+	"github.com/twsm000/lenslocked/models/repositories"
+	"github.com/twsm000/lenslocked/models/repositories/memoryrepo"
+)
+
+// failingAuditUnitOfWork runs the transactions with an audit repository
+// failing to create the events
+type failingAuditUnitOfWork struct {
+	repositories.UnitOfWork
+}
+
+func (uow failingAuditUnitOfWork) Do(ctx context.Context, fn func(tx repositories.Transaction) error) error {
+	return uow.UnitOfWork.Do(ctx, func(tx repositories.Transaction) error {
+		tx.Audit = failingAudit{tx.Audit}
+		return fn(tx)
+	})
+}
+
+type failingAudit struct {
+	repositories.Audit
+}
+
+func (failingAudit) Create(context.Context, *entities.AuditEvent) error {
+	return repositories.ErrFailedToCreateAuditEvent
+}
+
+func TestAdminDisableUser(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	testCases := []struct {
+		desc     string
+		uow      func(store *memoryrepo.Store) repositories.UnitOfWork
+		fails    bool
+		disabled bool
+		events   int
+	}{
+		{
+			desc:     "commits the action with its audit event",
+			uow:      memoryrepo.NewUnitOfWork,
+			disabled: true,
+			events:   1,
+		},
+		{
+			desc: "rolls the action back when the audit event fails",
+			uow: func(store *memoryrepo.Store) repositories.UnitOfWork {
+				return failingAuditUnitOfWork{memoryrepo.NewUnitOfWork(store)}
+			},
+			fails: true,
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			store := memoryrepo.NewStore()
+			users := memoryrepo.NewUserRepository(store)
+			sessions := memoryrepo.NewSessionRepository(store)
+			audit := memoryrepo.NewAuditRepository(store)
+			uow := tC.uow(store)
+			auditLogger := NewAuditLogger(audit, logger)
+			passwordResets := NewPasswordReset(entities.MinBytesPerToken, 0,
+				memoryrepo.NewPasswordResetRepository(store), users, uow, logger)
+			service := NewAdmin(users, sessions, memoryrepo.NewStatsRepository(store), auditLogger, passwordResets, uow)
+
+			admin := createUser(t, users, "admin@example.com")
+			user := createUser(t, users, "alice@example.com")
+
+			err := service.DisableUser(ctx, admin, entities.AuditSource{}, user.ID)
+			if tC.fails {
+				require.Error(t, err)
+				assert.True(t, err.Is(ErrAuditEventNotRecorded))
+			} else {
+				require.NoError(t, err)
+			}
+
+			stored, findErr := users.FindByID(ctx, user.ID)
+			require.NoError(t, findErr)
+			assert.Equal(t, tC.disabled, stored.IsDisabled())
+			events, _, listErr := audit.List(ctx, repositories.AuditFilter{Limit: 10})
+			require.NoError(t, listErr)
+			assert.Len(t, events, tC.events)
+		})
+	}
+}
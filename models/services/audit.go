@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/twsm000/lenslocked/models/entities"
+	"github.com/twsm000/lenslocked/models/repositories"
+)
+
+// AuditLogger records the security relevant actions in the audit log
+type AuditLogger interface {
+	// Record stores the event, even when ctx is cancelled. Possible errors:
+	//   - ErrAuditEventNotRecorded {repositories.ErrFailedToCreateAuditEvent}
+	Record(ctx context.Context, event *entities.AuditEvent) entities.Error
+
+	// RecordIn stores the event in the transaction, so it is committed or
+	// rolled back with the action it records. Possible errors:
+	//   - ErrAuditEventNotRecorded {repositories.ErrFailedToCreateAuditEvent}
+	RecordIn(ctx context.Context, tx repositories.Transaction, event *entities.AuditEvent) entities.Error
+
+	// TryRecord stores the event, only logging the failures. It is used by
+	// the actions that must not fail when the audit log is not available.
+	TryRecord(ctx context.Context, event *entities.AuditEvent)
+
+	// List possible errors:
+	//   - repositories.ErrFailedToListAuditEvents
+	List(ctx context.Context, filter repositories.AuditFilter) ([]entities.AuditEvent, int, error)
+}
+
+func NewAuditLogger(repo repositories.Audit, logger *slog.Logger) AuditLogger {
+	return &auditLogger{
+		Repository: repo,
+		Logger:     logger,
+	}
+}
+
+type auditLogger struct {
+	Repository repositories.Audit
+	Logger     *slog.Logger
+}
+
+func (al *auditLogger) Record(ctx context.Context, event *entities.AuditEvent) entities.Error {
+	ctx, span := tracer.Start(ctx, "AuditLogger.Record")
+	defer span.End()
+
+	// recorded even when the request is cancelled, the action it records
+	// may already have happened
+	return create(context.WithoutCancel(ctx), al.Repository, event)
+}
+
+func (al *auditLogger) RecordIn(ctx context.Context, tx repositories.Transaction, event *entities.AuditEvent) entities.Error {
+	ctx, span := tracer.Start(ctx, "AuditLogger.RecordIn")
+	defer span.End()
+
+	return create(ctx, tx.Audit, event)
+}
+
+// create stores the event in repo, with the user agent truncated
+func create(ctx context.Context, repo repositories.Audit, event *entities.AuditEvent) entities.Error {
+	if len(event.Source.UserAgent) > entities.MaxUserAgentLength {
+		event.Source.UserAgent = event.Source.UserAgent[:entities.MaxUserAgentLength]
+	}
+	if err := repo.Create(ctx, event); err != nil {
+		return entities.NewError(errors.Join(ErrAuditEventNotRecorded, err))
+	}
+	return nil
+}
+
+func (al *auditLogger) TryRecord(ctx context.Context, event *entities.AuditEvent) {
+	if err := al.Record(ctx, event); err != nil {
+		al.Logger.Error("Failed to record audit event", "action", event.Action, "error", err)
+	}
+}
+
+func (al *auditLogger) List(ctx context.Context, filter repositories.AuditFilter) ([]entities.AuditEvent, int, error) {
+	ctx, span := tracer.Start(ctx, "AuditLogger.List")
+	defer span.End()
+
+	return al.Repository.List(ctx, filter)
+}
@@ -5,4 +5,9 @@ import "errors"
 var (
 	ErrInvalidAuthCredentials    = errors.New("invalid authentication credentials")
 	ErrPasswordResetTokenExpired = errors.New("password reset token expired")
+	ErrInvalidAvatar             = errors.New("invalid avatar")
+	ErrFailedToSaveAvatar        = errors.New("failed to save avatar")
+	ErrAccountDisabled           = errors.New("account disabled")
+	ErrCannotActOnSelf           = errors.New("admins cannot act on their own account")
+	ErrAuditEventNotRecorded     = errors.New("audit event not recorded")
 )
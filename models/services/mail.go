@@ -1,11 +1,21 @@
 package services
 
 import (
+	"context"
 	"errors"
-	"fmt"
+	"html"
+	"log/slog"
+	"net"
+	"strconv"
 	"strings"
 
 	"github.com/go-mail/mail/v2"
+	"github.com/twsm000/lenslocked/pkg/i18n"
+	"github.com/twsm000/lenslocked/pkg/logging"
+	"github.com/twsm000/lenslocked/pkg/settings"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -30,11 +40,44 @@ type SMTPConfig struct {
 	Port     int    `json:"port"`
 	Username string `json:"username"`
 	Password string `json:"password"`
+	// PasswordFile is read into the Password, like a mounted secret
+	PasswordFile string `json:"password_file"`
+}
+
+// LogValue logs the config with the password redacted
+func (c SMTPConfig) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("host", c.Host),
+		slog.Int("port", c.Port),
+		slog.String("username", c.Username),
+		slog.Any("password", logging.Secret(c.Password)),
+		slog.String("password_file", c.PasswordFile),
+	)
+}
+
+// Validate checks the server address and that the password is set when
+// the username is. Possible errors:
+//   - settings.ErrInvalidSettings {settings.ErrRequired, settings.ErrInvalidPort}
+func (c SMTPConfig) Validate() error {
+	var p settings.Problems
+	p.Required("host", c.Host)
+	p.Port("port", c.Port)
+	if c.Username != "" {
+		p.Required("password", c.Password)
+	}
+	return p.Err()
+}
+
+// EmailObserver is notified of every e-mail sent, err is nil on success
+type EmailObserver interface {
+	EmailSent(err error)
 }
 
 type EmailService struct {
 	DefaultEmail string
-	dialer       *mail.Dialer
+	// Observer, when set, is notified of every e-mail sent
+	Observer EmailObserver
+	dialer   *mail.Dialer
 }
 
 func NewEmailService(config SMTPConfig) *EmailService {
@@ -43,7 +86,17 @@ func NewEmailService(config SMTPConfig) *EmailService {
 	}
 }
 
-func (es *EmailService) Send(email Email) error {
+// Send delivers the e-mail, traced by a smtp.send span
+func (es *EmailService) Send(ctx context.Context, email Email) error {
+	_, span := tracer.Start(ctx, "smtp.send",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.ServerAddress(es.dialer.Host),
+			semconv.ServerPort(es.dialer.Port),
+		),
+	)
+	defer span.End()
+
 	msg := mail.NewMessage()
 	msg.SetHeader("From", es.getEmailFromOrDefault(email.From))
 	msg.SetHeader("To", email.To)
@@ -60,12 +113,27 @@ func (es *EmailService) Send(email Email) error {
 	}
 
 	err := es.dialer.DialAndSend(msg)
+	if es.Observer != nil {
+		es.Observer.EmailSent(err)
+	}
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
 		return errors.Join(ErrFailedToSendEmail, err)
 	}
 	return nil
 }
 
+// Ping checks the SMTP server accepts connections, without sending anything
+func (es *EmailService) Ping(ctx context.Context) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(es.dialer.Host, strconv.Itoa(es.dialer.Port)))
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
 func (es *EmailService) getEmailFromOrDefault(from string) string {
 	if from := strings.TrimSpace(from); from != "" {
 		return from
@@ -75,16 +143,14 @@ func (es *EmailService) getEmailFromOrDefault(from string) string {
 	return DefaultEmail
 }
 
-func (es *EmailService) ForgotPassword(to, resetURL string) error {
-	err := es.Send(Email{
+// ForgotPassword sends the reset password link translated by the localizer
+func (es *EmailService) ForgotPassword(ctx context.Context, to, resetURL string, l *i18n.Localizer) error {
+	err := es.Send(ctx, Email{
 		From:      "",
 		To:        to,
-		Subject:   "Reset your password",
-		PlainText: fmt.Sprintf("To reset your password, please visit the following link: %s", resetURL),
-		HTML: fmt.Sprintf(
-			`<p>To reset your password, please visit the following link: <a href="%s">reset your password!</a></p>`,
-			resetURL,
-		),
+		Subject:   l.T("email.forgot_password.subject"),
+		PlainText: l.T("email.forgot_password.text", resetURL),
+		HTML:      l.T("email.forgot_password.html", html.EscapeString(resetURL)),
 	})
 	if err != nil {
 		return errors.Join(ErrFailedToSendResetPasswordEmail, err)
@@ -1,7 +1,9 @@
 package services
 
 import (
-	"log"
+	"context"
+	"errors"
+	"log/slog"
 	"time"
 
 	"github.com/twsm000/lenslocked/models/entities"
@@ -9,8 +11,28 @@ import (
 )
 
 type PasswordReset interface {
-	Create(email entities.Email) (*entities.PasswordReset, error)
-	Consume(token entities.SessionToken) (*entities.User, error)
+	// Create creates the password reset of the user of the email, returned
+	// to address the e-mail with the reset link
+	Create(ctx context.Context, email entities.Email) (*entities.User, *entities.PasswordReset, error)
+
+	// CreateIn creates the password reset of the user in the transaction,
+	// for the actions committing it with others
+	CreateIn(ctx context.Context, tx repositories.Transaction, user *entities.User) (*entities.PasswordReset, error)
+
+	// ResetPassword consumes the password reset of the token, updates the
+	// password of its user and creates a session for the user in a single
+	// transaction. Expired password resets are deleted too. Possible errors:
+	//   - repositories.ErrUserNotFound
+	//   - repositories.ErrFailedToDeletePasswordReset {repositories.ErrPasswordResetNotFound},
+	//     when the token was consumed concurrently
+	//   - ErrPasswordResetTokenExpired
+	//   - repositories.ErrFailedToBeginTransaction
+	//   - repositories.ErrFailedToCommitTransaction
+	//   - entities.ErrFailedToHashPassword
+	//   - entities.ErrInvalidPassword
+	//   - repositories.ErrFailedToUpdateUserPassword
+	//   - repositories.ErrFailedToCreateSession
+	ResetPassword(ctx context.Context, token entities.SessionToken, rawPassword entities.RawPassword) (*entities.User, *entities.Session, error)
 }
 
 func NewPasswordReset(
@@ -18,7 +40,8 @@ func NewPasswordReset(
 	duration time.Duration,
 	repo repositories.PasswordReset,
 	userRepo repositories.User,
-	logError *log.Logger) PasswordReset {
+	uow repositories.UnitOfWork,
+	logger *slog.Logger) PasswordReset {
 	/***************************************************/
 	if bytesPerToken < entities.MinBytesPerToken {
 		bytesPerToken = entities.MinBytesPerToken
@@ -29,28 +52,50 @@ func NewPasswordReset(
 		Repository:     repo,
 		Duration:       duration,
 		UserRepository: userRepo,
-		logError:       logError,
+		UnitOfWork:     uow,
+		logger:         logger,
 	}
 }
 
 type PasswordResetService struct {
+	// BytesPerToken is the size of the password reset tokens and of the
+	// session tokens created by ResetPassword
 	BytesPerToken int
 
 	// Duration is the amount of time that a PasswordReset is valid for
 	Duration       time.Duration
 	Repository     repositories.PasswordReset
 	UserRepository repositories.User
+	UnitOfWork     repositories.UnitOfWork
 
-	// logs
-	logError *log.Logger
+	logger *slog.Logger
 }
 
-func (prs PasswordResetService) Create(email entities.Email) (*entities.PasswordReset, error) {
-	user, err := prs.UserRepository.FindByEmail(email)
+func (prs PasswordResetService) Create(ctx context.Context, email entities.Email) (*entities.User, *entities.PasswordReset, error) {
+	ctx, span := tracer.Start(ctx, "PasswordReset.Create")
+	defer span.End()
+
+	user, err := prs.UserRepository.FindByEmail(ctx, email)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
+	passwordReset, createErr := prs.create(ctx, prs.Repository, user)
+	if createErr != nil {
+		return nil, nil, createErr
+	}
+	return user, passwordReset, nil
+}
+
+func (prs PasswordResetService) CreateIn(ctx context.Context, tx repositories.Transaction, user *entities.User) (*entities.PasswordReset, error) {
+	ctx, span := tracer.Start(ctx, "PasswordReset.CreateIn")
+	defer span.End()
+
+	return prs.create(ctx, tx.PasswordResets, user)
+}
+
+// create stores a new password reset of the user in repo
+func (prs PasswordResetService) create(ctx context.Context, repo repositories.PasswordReset, user *entities.User) (*entities.PasswordReset, error) {
 	passwordReset, err := entities.NewCreatablePasswordReset(
 		user.ID,
 		prs.BytesPerToken,
@@ -60,23 +105,75 @@ func (prs PasswordResetService) Create(email entities.Email) (*entities.Password
 		return nil, err
 	}
 
-	if err := prs.Repository.Create(passwordReset); err != nil {
+	if err := repo.Create(ctx, passwordReset); err != nil {
 		return nil, err
 	}
+	return passwordReset, nil
+}
 
-	return passwordReset, err
+func (prs PasswordResetService) ResetPassword(
+	ctx context.Context,
+	token entities.SessionToken,
+	rawPassword entities.RawPassword) (*entities.User, *entities.Session, error) {
+	/***************************************************/
+	ctx, span := tracer.Start(ctx, "PasswordReset.ResetPassword")
+	defer span.End()
+
+	var user *entities.User
+	var session *entities.Session
+	var expired error
+	err := prs.UnitOfWork.Do(ctx, func(tx repositories.Transaction) error {
+		var err error
+		user, err = prs.consume(ctx, tx.PasswordResets, token)
+		if errors.Is(err, ErrPasswordResetTokenExpired) {
+			expired = err
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if err := setPassword(user, rawPassword); err != nil {
+			return err
+		}
+		if err := tx.Users.UpdatePassword(ctx, user); err != nil {
+			return err
+		}
+
+		session, err = entities.NewCreatableSession(user.ID, prs.BytesPerToken)
+		if err != nil {
+			return err
+		}
+		return tx.Sessions.Create(ctx, session)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if expired != nil {
+		return nil, nil, expired
+	}
+	return user, session, nil
 }
 
-func (prs PasswordResetService) Consume(token entities.SessionToken) (*entities.User, error) {
-	passwordReset, user, err := prs.Repository.FindPasswordResetAndUserByToken(token)
+// consume deletes the password reset of the token with repo and returns its
+// user, or ErrPasswordResetTokenExpired once the password reset is deleted.
+// The delete fails when another transaction consumed the token in between,
+// so the token resets the password only once.
+func (prs PasswordResetService) consume(
+	ctx context.Context,
+	repo repositories.PasswordReset,
+	token entities.SessionToken) (*entities.User, error) {
+	/***************************************************/
+	passwordReset, user, err := repo.FindPasswordResetAndUserByToken(ctx, token)
 	if err != nil {
 		return nil, err
 	}
-	defer prs.Repository.DeleteByID(passwordReset.ID) // error ignored because its not useful
+	if err := repo.DeleteByID(ctx, passwordReset.ID); err != nil {
+		return nil, err
+	}
 
 	now := time.Now()
 	if passwordReset.ExpiresAt.Before(now) {
-		prs.logError.Printf("\nPasswordReset.ExpiresAt: %v - Now: %v\n", passwordReset.ExpiresAt, now)
+		prs.logger.Warn("Password reset token expired", "password_reset", passwordReset, "now", now)
 		return nil, ErrPasswordResetTokenExpired
 	}
 
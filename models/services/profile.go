@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"github.com/twsm000/lenslocked/models/entities"
+	"github.com/twsm000/lenslocked/models/repositories"
+	"github.com/twsm000/lenslocked/pkg/crypto/rand"
+	"github.com/twsm000/lenslocked/pkg/images"
+)
+
+const (
+	// avatarNameSize is the amount of random bytes in the avatar file names,
+	// a new name is generated on every upload so caches never serve old avatars
+	avatarNameSize = 8
+)
+
+type Profile interface {
+	// FindByUsername finds the public profile, the disabled accounts are
+	// not found. Possible errors:
+	//   - entities.ErrInvalidUsername
+	//   - repositories.ErrUserNotFound
+	FindByUsername(ctx context.Context, username string) (*entities.User, entities.Error)
+
+	// Update saves the profile fields and, when avatar is not nil, the
+	// avatar image in a single update, so nothing is saved when any of them
+	// is invalid. Possible errors:
+	//   - entities.ErrInvalidUsername
+	//   - entities.ErrInvalidDisplayName
+	//   - entities.ErrInvalidBio
+	//   - ErrInvalidAvatar {images.ErrImageTooLarge, images.ErrUnsupportedFormat, images.ErrInvalidImage}
+	//   - ErrFailedToSaveAvatar
+	//   - repositories.ErrFailedToUpdateUserProfile {repositories.ErrDuplicateUsernameNotAllowed}
+	Update(ctx context.Context, user *entities.User, input entities.ProfileUpdatable, avatar io.Reader) entities.Error
+}
+
+func NewProfile(repo repositories.User, avatars images.Store, logger *slog.Logger) Profile {
+	return &profileService{
+		Repository: repo,
+		Avatars:    avatars,
+		Logger:     logger,
+	}
+}
+
+type profileService struct {
+	Repository repositories.User
+	Avatars    images.Store
+	Logger     *slog.Logger
+}
+
+func (ps *profileService) FindByUsername(ctx context.Context, username string) (*entities.User, entities.Error) {
+	ctx, span := tracer.Start(ctx, "Profile.FindByUsername")
+	defer span.End()
+
+	var u entities.Username
+	u.Set(username)
+	if !u.IsValid() {
+		return nil, entities.NewClientError("error.user.not_found", entities.ErrInvalidUsername, repositories.ErrUserNotFound)
+	}
+	user, err := ps.Repository.FindByUsername(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsDisabled() {
+		return nil, entities.NewClientError("error.user.not_found", repositories.ErrUserNotFound)
+	}
+	return user, nil
+}
+
+func (ps *profileService) Update(
+	ctx context.Context,
+	user *entities.User,
+	input entities.ProfileUpdatable,
+	avatar io.Reader) entities.Error {
+	/***************************************************/
+	ctx, span := tracer.Start(ctx, "Profile.Update")
+	defer span.End()
+
+	profile := user.Profile
+	profile.Username = input.Username
+	profile.DisplayName = input.DisplayName
+	profile.Bio = input.Bio
+	if err := entities.ValidateProfile(&profile); err != nil {
+		return err
+	}
+
+	if avatar != nil {
+		name, err := ps.saveAvatar(user.ID, avatar)
+		if err != nil {
+			return err
+		}
+		profile.Avatar = name
+	}
+
+	previous := user.Profile.Avatar
+	updated := *user
+	updated.Profile = profile
+	if err := ps.Repository.UpdateProfile(ctx, &updated); err != nil {
+		if profile.Avatar != previous {
+			ps.removeAvatar(profile.Avatar, "Failed to remove unused avatar")
+		}
+		return err
+	}
+
+	*user = updated
+	if profile.Avatar != previous && previous != "" {
+		ps.removeAvatar(previous, "Failed to remove previous avatar")
+	}
+	return nil
+}
+
+// saveAvatar processes the avatar image and stores it with a new name.
+// Possible errors:
+//   - ErrInvalidAvatar {images.ErrImageTooLarge, images.ErrUnsupportedFormat, images.ErrInvalidImage}
+//   - ErrFailedToSaveAvatar
+func (ps *profileService) saveAvatar(userID uint64, avatar io.Reader) (string, entities.Error) {
+	img, err := images.Process(avatar, images.AvatarOptions)
+	if errors.Is(err, images.ErrImageTooLarge) {
+		return "", entities.NewClientError("error.avatar.too_large", ErrInvalidAvatar, err)
+	} else if err != nil {
+		return "", entities.NewClientError("error.avatar.invalid", ErrInvalidAvatar, err)
+	}
+
+	suffix, err := rand.Bytes(avatarNameSize)
+	if err != nil {
+		return "", entities.NewError(ErrFailedToSaveAvatar, err)
+	}
+	name := fmt.Sprintf("%d-%s%s", userID, hex.EncodeToString(suffix), img.Ext)
+	if err := ps.Avatars.Save(name, img.Data); err != nil {
+		return "", entities.NewError(ErrFailedToSaveAvatar, err)
+	}
+	return name, nil
+}
+
+func (ps *profileService) removeAvatar(name, msg string) {
+	if err := ps.Avatars.Remove(name); err != nil {
+		ps.Logger.Error(msg, "avatar", name, "error", err)
+	}
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twsm000/lenslocked/models/entities"
+	"github.com/twsm000/lenslocked/models/repositories"
+	"github.com/twsm000/lenslocked/models/repositories/memoryrepo"
+	"github.com/twsm000/lenslocked/pkg/images"
+)
+
+func createUser(t *testing.T, users repositories.User, email string) *entities.User {
+	t.Helper()
+	var input entities.UserCreatable
+	input.Email.Set(email)
+	input.Password.Set("secret-password")
+	user, err := entities.NewCreatableUser(input)
+	require.NoError(t, err)
+	require.NoError(t, users.Create(context.Background(), user))
+	return user
+}
+
+func TestProfileUpdate(t *testing.T) {
+	ctx := context.Background()
+	users := memoryrepo.NewUserRepository(memoryrepo.NewStore())
+	avatars, err := images.NewDirStore(t.TempDir())
+	require.NoError(t, err)
+	service := NewProfile(users, avatars, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	user := createUser(t, users, "alice@example.com")
+
+	var input entities.ProfileUpdatable
+	input.Username.Set("alice")
+	input.DisplayName = "Alice"
+
+	// an invalid avatar saves nothing
+	updateErr := service.Update(ctx, user, input, strings.NewReader("not an image"))
+	require.Error(t, updateErr)
+	assert.True(t, updateErr.Is(ErrInvalidAvatar))
+	stored, findErr := users.FindByID(ctx, user.ID)
+	require.NoError(t, findErr)
+	assert.Empty(t, stored.Profile.DisplayName)
+	assert.Empty(t, user.Profile.DisplayName)
+
+	var avatar bytes.Buffer
+	require.NoError(t, png.Encode(&avatar, image.NewRGBA(image.Rect(0, 0, 64, 64))))
+	require.NoError(t, service.Update(ctx, user, input, &avatar))
+	stored, findErr = users.FindByID(ctx, user.ID)
+	require.NoError(t, findErr)
+	assert.Equal(t, "Alice", stored.Profile.DisplayName)
+	assert.NotEmpty(t, stored.Profile.Avatar)
+	assert.Equal(t, stored.Profile, user.Profile)
+
+	// without an avatar, the current one is kept
+	input.Bio = "Hi"
+	require.NoError(t, service.Update(ctx, user, input, nil))
+	assert.Equal(t, stored.Profile.Avatar, user.Profile.Avatar)
+	_, statErr := avatars.Open(user.Profile.Avatar)
+	assert.NoError(t, statErr)
+}
+
+func TestProfileFindByUsername(t *testing.T) {
+	ctx := context.Background()
+	users := memoryrepo.NewUserRepository(memoryrepo.NewStore())
+	service := NewProfile(users, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	user := createUser(t, users, "alice@example.com")
+	var input entities.ProfileUpdatable
+	input.Username.Set("alice")
+	require.NoError(t, service.Update(ctx, user, input, nil))
+
+	found, findErr := service.FindByUsername(ctx, "alice")
+	require.NoError(t, findErr)
+	assert.Equal(t, user.ID, found.ID)
+
+	// the accounts disabled by an admin are not public
+	now := time.Now()
+	user.DisabledAt = &now
+	require.NoError(t, users.UpdateDisabledAt(ctx, user))
+	_, findErr = service.FindByUsername(ctx, "alice")
+	require.Error(t, findErr)
+	assert.True(t, findErr.Is(repositories.ErrUserNotFound))
+}
@@ -1,6 +1,8 @@
 package services
 
 import (
+	"context"
+
 	"github.com/twsm000/lenslocked/models/entities"
 	"github.com/twsm000/lenslocked/models/repositories"
 )
@@ -11,9 +13,9 @@ type Session interface {
 	//   - rand.ErrInvalidSizeUnexpected
 	//   - ErrTokenSizeBelowMinRequired
 	//   - repositories.ErrFailedToCreateSession {ErrFixedTokenSizeRequired, ErrUserNotFound}
-	Create(userID uint64) (*entities.Session, entities.Error)
-	FindUserByToken(token string) (*entities.User, error)
-	DeleteByToken(token string) error
+	Create(ctx context.Context, userID uint64) (*entities.Session, entities.Error)
+	FindUserByToken(ctx context.Context, token string) (*entities.User, error)
+	DeleteByToken(ctx context.Context, token string) error
 }
 
 func NewSession(bytesPerToken int, repo repositories.Session) Session {
@@ -36,31 +38,40 @@ type sessionService struct {
 //   - rand.ErrInvalidSizeUnexpected
 //   - ErrTokenSizeBelowMinRequired
 //   - repositories.ErrFailedToCreateSession {ErrFixedTokenSizeRequired, ErrUserNotFound}
-func (ss sessionService) Create(userID uint64) (*entities.Session, entities.Error) {
+func (ss sessionService) Create(ctx context.Context, userID uint64) (*entities.Session, entities.Error) {
+	ctx, span := tracer.Start(ctx, "Session.Create")
+	defer span.End()
+
 	session, err := entities.NewCreatableSession(userID, ss.BytesPerToken)
 	if err != nil {
 		return nil, err
 	}
-	if err := ss.Repository.Create(session); err != nil {
+	if err := ss.Repository.Create(ctx, session); err != nil {
 		return nil, err
 	}
 	return session, err
 }
 
-func (ss sessionService) FindUserByToken(token string) (*entities.User, error) {
+func (ss sessionService) FindUserByToken(ctx context.Context, token string) (*entities.User, error) {
+	ctx, span := tracer.Start(ctx, "Session.FindUserByToken")
+	defer span.End()
+
 	var stoken entities.SessionToken
 	err := stoken.SetFromHex(token)
 	if err != nil {
 		return nil, err
 	}
-	return ss.Repository.FindUserByToken(stoken)
+	return ss.Repository.FindUserByToken(ctx, stoken)
 }
 
-func (ss sessionService) DeleteByToken(token string) error {
+func (ss sessionService) DeleteByToken(ctx context.Context, token string) error {
+	ctx, span := tracer.Start(ctx, "Session.DeleteByToken")
+	defer span.End()
+
 	var stoken entities.SessionToken
 	err := stoken.SetFromHex(token)
 	if err != nil {
 		return err
 	}
-	return ss.Repository.DeleteByToken(stoken)
+	return ss.Repository.DeleteByToken(ctx, stoken)
 }
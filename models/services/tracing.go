@@ -0,0 +1,6 @@
+package services
+
+import "go.opentelemetry.io/otel"
+
+// tracer starts a span for every service call, named Service.Method
+var tracer = otel.Tracer("github.com/twsm000/lenslocked/models/services")
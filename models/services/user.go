@@ -1,6 +1,8 @@
 package services
 
 import (
+	"context"
+
 	"github.com/twsm000/lenslocked/models/entities"
 	"github.com/twsm000/lenslocked/models/repositories"
 )
@@ -12,22 +14,29 @@ type User interface {
 	//   - entities.ErrInvalidUserEmail
 	//   - entities.ErrInvalidPassword
 	//   - repositories.ErrFailedToCreateUser
-	Create(input entities.UserCreatable) (*entities.User, entities.Error)
+	Create(ctx context.Context, input entities.UserCreatable) (*entities.User, entities.Error)
 
-	// Authenticate possible errors:
+	// Authenticate records the attempt in the audit log. Possible errors:
 	//   - ErrInvalidAuthCredentials {repositories.ErrUserNotFound, entities.ErrInvalidPassword}
-	Authenticate(input entities.UserAuthenticable) (*entities.User, entities.Error)
-	UpdatePassword(user *entities.User, rawPassword entities.RawPassword) error
+	//   - ErrAccountDisabled
+	//   - repositories.ErrFailedToFindUser
+	Authenticate(ctx context.Context, input entities.UserAuthenticable) (*entities.User, entities.Error)
+
+	// UpdateLocale saves the locale chosen by the user. Possible errors:
+	//   - repositories.ErrFailedToUpdateUser
+	UpdateLocale(ctx context.Context, user *entities.User, locale string) error
 }
 
-func NewUser(repo repositories.User) User {
+func NewUser(repo repositories.User, auditLogger AuditLogger) User {
 	return &userService{
-		Repository: repo,
+		Repository:  repo,
+		AuditLogger: auditLogger,
 	}
 }
 
 type userService struct {
-	Repository repositories.User
+	Repository  repositories.User
+	AuditLogger AuditLogger
 }
 
 // Create possible errors:
@@ -36,13 +45,16 @@ type userService struct {
 //   - entities.ErrInvalidUserEmail
 //   - entities.ErrInvalidPassword
 //   - repositories.ErrFailedToCreateUser
-func (us *userService) Create(input entities.UserCreatable) (*entities.User, entities.Error) {
+func (us *userService) Create(ctx context.Context, input entities.UserCreatable) (*entities.User, entities.Error) {
+	ctx, span := tracer.Start(ctx, "User.Create")
+	defer span.End()
+
 	user, err := entities.NewCreatableUser(input)
 	if err != nil {
 		return nil, err
 	}
 
-	if err = us.Repository.Create(user); err != nil {
+	if err = us.Repository.Create(ctx, user); err != nil {
 		return nil, err
 	}
 
@@ -51,28 +63,67 @@ func (us *userService) Create(input entities.UserCreatable) (*entities.User, ent
 
 // Authenticate possible errors:
 //   - ErrInvalidAuthCredentials {repositories.ErrUserNotFound, entities.ErrInvalidPassword}
-func (us *userService) Authenticate(input entities.UserAuthenticable) (*entities.User, entities.Error) {
-	const invalidCredentialsErrMsg string = "Invalid credentials."
-	user, err := us.Repository.FindByEmail(input.Email)
+//   - ErrAccountDisabled
+//   - repositories.ErrFailedToFindUser
+func (us *userService) Authenticate(ctx context.Context, input entities.UserAuthenticable) (*entities.User, entities.Error) {
+	ctx, span := tracer.Start(ctx, "User.Authenticate")
+	defer span.End()
+
+	const invalidCredentialsErrMsg string = "error.auth.invalid_credentials"
+	user, err := us.Repository.FindByEmail(ctx, input.Email)
 	if err != nil {
+		if !err.Is(repositories.ErrUserNotFound) {
+			return nil, err
+		}
+		// the email is not recorded, the unknown ones are often a password
+		// typed in the wrong field or the addresses of other people
+		us.AuditLogger.TryRecord(ctx, &entities.AuditEvent{
+			Action:  entities.AuditSignInFailed,
+			Source:  input.Source,
+			Details: map[string]string{"reason": "unknown_email"},
+		})
 		return nil, entities.NewClientError(invalidCredentialsErrMsg, ErrInvalidAuthCredentials, err)
 	}
 
 	if err := user.Password.Compare(input.Password); err != nil {
+		event := entities.NewUserAuditEvent(nil, input.Source, entities.AuditSignInFailed, user.ID)
+		event.Details["reason"] = "invalid_password"
+		us.AuditLogger.TryRecord(ctx, event)
 		return nil, entities.NewClientError(invalidCredentialsErrMsg, ErrInvalidAuthCredentials, err)
 	}
 
+	// checked after the password, so the account state is only told to its owner
+	if user.IsDisabled() {
+		event := entities.NewUserAuditEvent(nil, input.Source, entities.AuditSignInFailed, user.ID)
+		event.Details["reason"] = "account_disabled"
+		us.AuditLogger.TryRecord(ctx, event)
+		return nil, entities.NewClientError("error.auth.account_disabled", ErrAccountDisabled)
+	}
+
+	us.AuditLogger.TryRecord(ctx, entities.NewUserAuditEvent(user, input.Source, entities.AuditSignInSucceeded, user.ID))
 	return user, nil
 }
 
-func (us *userService) UpdatePassword(user *entities.User, rawPassword entities.RawPassword) error {
-	if err := user.Password.GenerateFrom(rawPassword); err != nil {
+// UpdateLocale possible errors:
+//   - repositories.ErrFailedToUpdateUser
+func (us *userService) UpdateLocale(ctx context.Context, user *entities.User, locale string) error {
+	ctx, span := tracer.Start(ctx, "User.UpdateLocale")
+	defer span.End()
+
+	previous := user.Locale
+	user.Locale = locale
+	if err := us.Repository.UpdateLocale(ctx, user); err != nil {
+		user.Locale = previous
 		return err
 	}
+	return nil
+}
 
-	if err := entities.ValidateUser(user); err != nil {
+// setPassword hashes the password into the user, once it is validated
+func setPassword(user *entities.User, rawPassword entities.RawPassword) error {
+	if err := user.Password.GenerateFrom(rawPassword); err != nil {
 		return err
 	}
 
-	return us.Repository.UpdatePassword(user)
+	return entities.ValidateUser(user)
 }
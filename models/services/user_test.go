@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twsm000/lenslocked/models/entities"
+	"github.com/twsm000/lenslocked/models/repositories"
+	"github.com/twsm000/lenslocked/models/repositories/memoryrepo"
+)
+
+func TestAuthenticateUnknownEmail(t *testing.T) {
+	ctx := context.Background()
+	store := memoryrepo.NewStore()
+	audit := memoryrepo.NewAuditRepository(store)
+	service := NewUser(memoryrepo.NewUserRepository(store),
+		NewAuditLogger(audit, slog.New(slog.NewTextHandler(io.Discard, nil))))
+
+	var input entities.UserAuthenticable
+	input.Email.Set("nobody@example.com")
+	input.Password.Set("secret-password")
+	_, err := service.Authenticate(ctx, input)
+	require.Error(t, err)
+	assert.True(t, err.Is(ErrInvalidAuthCredentials))
+	assert.True(t, err.IsClientErr())
+
+	events, _, listErr := audit.List(ctx, repositories.AuditFilter{Limit: 10})
+	require.NoError(t, listErr)
+	require.Len(t, events, 1)
+	assert.Equal(t, entities.AuditSignInFailed, events[0].Action)
+	assert.Equal(t, map[string]string{"reason": "unknown_email"}, events[0].Details)
+}
+
+func TestAuthenticateFailedToFindUser(t *testing.T) {
+	store := memoryrepo.NewStore()
+	audit := memoryrepo.NewAuditRepository(store)
+	users := memoryrepo.NewUserRepository(store)
+	service := NewUser(users, NewAuditLogger(audit, slog.New(slog.NewTextHandler(io.Discard, nil))))
+	createUser(t, users, "alice@example.com")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var input entities.UserAuthenticable
+	input.Email.Set("alice@example.com")
+	input.Password.Set("secret-password")
+	_, err := service.Authenticate(ctx, input)
+	require.Error(t, err)
+	assert.True(t, err.Is(repositories.ErrFailedToFindUser))
+	assert.False(t, err.Is(ErrInvalidAuthCredentials))
+	assert.False(t, err.IsClientErr())
+
+	events, _, listErr := audit.List(context.Background(), repositories.AuditFilter{Limit: 10})
+	require.NoError(t, listErr)
+	assert.Empty(t, events)
+}
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- SQLite cannot add UNIQUE columns, the username is unique by its index
+ALTER TABLE users ADD COLUMN username TEXT;
+ALTER TABLE users ADD COLUMN display_name TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN bio TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN avatar TEXT NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_username_key ON users (username);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS users_username_key;
+
+ALTER TABLE users DROP COLUMN avatar;
+ALTER TABLE users DROP COLUMN bio;
+ALTER TABLE users DROP COLUMN display_name;
+ALTER TABLE users DROP COLUMN username;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE audit_events ADD COLUMN ip TEXT NOT NULL DEFAULT '';
+ALTER TABLE audit_events ADD COLUMN user_agent TEXT NOT NULL DEFAULT '';
+ALTER TABLE audit_events ADD COLUMN request_id TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS audit_events_actor_id_idx ON audit_events (actor_id);
+CREATE INDEX IF NOT EXISTS audit_events_target_idx ON audit_events (target_type, target_id);
+
+CREATE TRIGGER IF NOT EXISTS audit_events_append_only_update
+    BEFORE UPDATE ON audit_events
+BEGIN
+    SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_events_append_only_delete
+    BEFORE DELETE ON audit_events
+BEGIN
+    SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS audit_events_append_only_delete;
+DROP TRIGGER IF EXISTS audit_events_append_only_update;
+DROP INDEX IF EXISTS audit_events_target_idx;
+DROP INDEX IF EXISTS audit_events_actor_id_idx;
+
+ALTER TABLE audit_events DROP COLUMN request_id;
+ALTER TABLE audit_events DROP COLUMN user_agent;
+ALTER TABLE audit_events DROP COLUMN ip;
+-- +goose StatementEnd
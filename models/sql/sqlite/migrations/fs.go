@@ -0,0 +1,8 @@
+package migrations
+
+import "embed"
+
+var (
+	//go:embed *.sql
+	FS embed.FS
+)
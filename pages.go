@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/twsm000/lenslocked/controllers"
+	"github.com/twsm000/lenslocked/views"
+)
+
+const (
+	// DefaultLayout composes every page found in templates/pages
+	DefaultLayout = "tailwind"
+)
+
+// The pages found in templates/pages, each declared with the data type it
+// renders. The registry validation fails for pages not declared here.
+var (
+	HomePage              = views.Page[any]("home")
+	ContactPage           = views.Page[any]("contact")
+	FAQPage               = views.Page[[]controllers.Question]("faq")
+	SignUpPage            = views.Page[controllers.SignUpPageData]("signup")
+	SignInPage            = views.Page[controllers.SignInPageData]("signin")
+	ForgotPasswordPage    = views.Page[controllers.ForgotPasswordPageData]("forgot_password")
+	CheckPasswordSentPage = views.Page[controllers.CheckPasswordSentPageData]("check_password_sent")
+	ResetPasswordPage     = views.Page[controllers.ResetPasswordPageData]("reset_password")
+	SecurityActivityPage  = views.Page[controllers.SecurityActivityPageData]("security_activity")
+	ProfileSettingsPage   = views.Page[controllers.ProfileSettingsPageData]("profile_settings")
+	PublicProfilePage     = views.Page[controllers.PublicProfilePageData]("profile")
+	AdminDashboardPage    = views.Page[controllers.AdminDashboardPageData]("admin_dashboard")
+	AdminUsersPage        = views.Page[controllers.AdminUsersPageData]("admin_users")
+	AdminUserPage         = views.Page[controllers.AdminUserPageData]("admin_user")
+	AdminAuditPage        = views.Page[controllers.AdminAuditPageData]("admin_audit")
+)
+
+// RegisterPages registers every page declared
+func RegisterPages(registry *views.Registry) error {
+	return errors.Join(
+		HomePage.Register(registry),
+		ContactPage.Register(registry),
+		FAQPage.Register(registry),
+		SignUpPage.Register(registry),
+		SignInPage.Register(registry),
+		ForgotPasswordPage.Register(registry),
+		CheckPasswordSentPage.Register(registry),
+		ResetPasswordPage.Register(registry),
+		SecurityActivityPage.Register(registry),
+		ProfileSettingsPage.Register(registry),
+		PublicProfilePage.Register(registry),
+		AdminDashboardPage.Register(registry),
+		AdminUsersPage.Register(registry),
+		AdminUserPage.Register(registry),
+		AdminAuditPage.Register(registry),
+	)
+}
+
+// NewPageRegistry returns the registry with every page registered and validated
+func NewPageRegistry(src views.Source) (*views.Registry, error) {
+	registry, err := views.NewRegistry(src, DefaultLayout)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := RegisterPages(registry); err != nil {
+		return nil, err
+	}
+
+	if err := registry.Validate(); err != nil {
+		return nil, err
+	}
+
+	return registry, nil
+}
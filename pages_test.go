@@ -0,0 +1,14 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twsm000/lenslocked/templates"
+	"github.com/twsm000/lenslocked/views"
+)
+
+func TestEmbeddedPagesAreRegisteredAndValid(t *testing.T) {
+	_, err := NewPageRegistry(views.EmbeddedSource(templates.FS))
+	assert.NoError(t, err)
+}
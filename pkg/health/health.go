@@ -0,0 +1,168 @@
+// Package health serves the liveness and readiness endpoints checked by the
+// orchestrators and load balancers.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+
+	// DefaultTimeout bounds all the checks of a readiness request
+	DefaultTimeout = 2 * time.Second
+)
+
+var (
+	ErrShuttingDown = errors.New("shutting down")
+)
+
+// CheckFunc reports whether a dependency is usable, it must honor the
+// context deadline
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name string
+	fn   CheckFunc
+	// optional checks are reported but never fail the readiness
+	optional bool
+}
+
+// CheckResult is the outcome of a single check
+type CheckResult struct {
+	Status   string `json:"status"`
+	Optional bool   `json:"optional,omitempty"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Report is the body of the health responses
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Checker runs the registered checks for the readiness endpoint. Checks
+// must be added before serving requests.
+type Checker struct {
+	// Timeout bounds all the checks of a request, DefaultTimeout when zero
+	Timeout      time.Duration
+	checks       []check
+	shuttingDown atomic.Bool
+}
+
+// Add registers a check required to be ready
+func (c *Checker) Add(name string, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, fn: fn})
+}
+
+// AddOptional registers a check whose failures are reported without
+// failing the readiness
+func (c *Checker) AddOptional(name string, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, fn: fn, optional: true})
+}
+
+// Shutdown fails every readiness check from now on, so the load balancers
+// stop sending traffic while the server drains
+func (c *Checker) Shutdown() {
+	c.shuttingDown.Store(true)
+}
+
+// Check runs every check concurrently and reports the results
+func (c *Checker) Check(ctx context.Context) Report {
+	if c.shuttingDown.Load() {
+		return Report{
+			Status: StatusFail,
+			Checks: map[string]CheckResult{
+				"shutdown": {Status: StatusFail, Duration: "0s", Error: ErrShuttingDown.Error()},
+			},
+		}
+	}
+
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	results := make([]CheckResult, len(c.checks))
+	var wg sync.WaitGroup
+	for i, chk := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = run(ctx, chk)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{
+		Status: StatusOK,
+		Checks: make(map[string]CheckResult, len(c.checks)),
+	}
+	for i, chk := range c.checks {
+		report.Checks[chk.name] = results[i]
+		if results[i].Status == StatusFail && !chk.optional {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// run runs the check, failing it when the context is done first
+func run(ctx context.Context, chk check) CheckResult {
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- chk.fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := CheckResult{
+		Status:   StatusOK,
+		Optional: chk.optional,
+		Duration: time.Since(start).Round(time.Microsecond).String(),
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Liveness reports the process is up, it never checks the dependencies
+func (c *Checker) Liveness(w http.ResponseWriter, r *http.Request) {
+	writeReport(w, http.StatusOK, Report{Status: StatusOK})
+}
+
+// Readiness reports whether every required dependency is usable, with
+// 503 Service Unavailable when any of them is not
+func (c *Checker) Readiness(w http.ResponseWriter, r *http.Request) {
+	report := c.Check(r.Context())
+	status := http.StatusOK
+	if report.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	writeReport(w, status, report)
+}
+
+func writeReport(w http.ResponseWriter, status int, report Report) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
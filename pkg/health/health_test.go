@@ -0,0 +1,69 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ready(t *testing.T, c *Checker) (int, Report) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	c.Readiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var report Report
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&report))
+	return rec.Code, report
+}
+
+func TestReadiness(t *testing.T) {
+	var c Checker
+	c.Add("database", func(ctx context.Context) error { return nil })
+	c.AddOptional("smtp", func(ctx context.Context) error { return errors.New("connection refused") })
+
+	status, report := ready(t, &c)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, StatusOK, report.Status)
+	assert.Equal(t, StatusOK, report.Checks["database"].Status)
+	assert.Equal(t, StatusFail, report.Checks["smtp"].Status)
+	assert.Equal(t, "connection refused", report.Checks["smtp"].Error)
+
+	c.Add("storage", func(ctx context.Context) error { return errors.New("read-only file system") })
+	status, report = ready(t, &c)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, StatusFail, report.Status)
+}
+
+func TestReadinessTimeout(t *testing.T) {
+	c := Checker{Timeout: 10 * time.Millisecond}
+	c.Add("slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	start := time.Now()
+	status, report := ready(t, &c)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["slow"].Error)
+}
+
+func TestReadinessShutdown(t *testing.T) {
+	var c Checker
+	c.Add("database", func(ctx context.Context) error { return nil })
+	c.Shutdown()
+
+	status, report := ready(t, &c)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, ErrShuttingDown.Error(), report.Checks["shutdown"].Error)
+
+	rec := httptest.NewRecorder()
+	c.Liveness(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
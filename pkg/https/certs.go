@@ -0,0 +1,127 @@
+package https
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// CertReloader holds the certificate of the server, loaded from the cert
+// and key files and reloaded when they change, so the renewed certificates
+// are served without a restart
+type CertReloader struct {
+	certFile string
+	keyFile  string
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+	// modTime is the latest modification time of the loaded files
+	modTime time.Time
+}
+
+// NewCertReloader loads the certificate. Possible errors:
+//   - ErrInvalidCertificate
+//   - the errors of os.Stat
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate returns the loaded certificate, it is the
+// tls.Config.GetCertificate of the server
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// Reload loads the files again, keeping the previous certificate when they
+// are invalid, like while they are being written. Possible errors:
+//   - ErrInvalidCertificate
+//   - the errors of os.Stat
+func (r *CertReloader) Reload() error {
+	modTime, err := r.filesModTime()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidCertificate, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.modTime = modTime
+	return nil
+}
+
+// Changed reports if the files were modified since they were loaded
+func (r *CertReloader) Changed() (bool, error) {
+	modTime, err := r.filesModTime()
+	if err != nil {
+		return false, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return !modTime.Equal(r.modTime), nil
+}
+
+// Watch reloads the certificate on every signal received, like SIGHUP, and
+// when the files change, checked every interval, until ctx is done. A zero
+// interval reloads on the signals only.
+func (r *CertReloader) Watch(ctx context.Context, interval time.Duration, signals <-chan os.Signal, logger *slog.Logger) {
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-signals:
+			r.reload(logger, "reason", "signal", "signal", sig.String())
+		case <-tick:
+			changed, err := r.Changed()
+			if err != nil {
+				logger.Error("Failed to check the TLS certificate files", "error", err)
+			} else if changed {
+				r.reload(logger, "reason", "files changed")
+			}
+		}
+	}
+}
+
+func (r *CertReloader) reload(logger *slog.Logger, args ...any) {
+	if err := r.Reload(); err != nil {
+		logger.Error("Failed to reload the TLS certificate, keeping the previous one",
+			append(args, "error", err)...)
+		return
+	}
+	logger.Info("TLS certificate reloaded", append(args, "cert_file", r.certFile)...)
+}
+
+// filesModTime returns the latest modification time of the files
+func (r *CertReloader) filesModTime() (time.Time, error) {
+	var latest time.Time
+	for _, path := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
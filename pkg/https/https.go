@@ -0,0 +1,129 @@
+// Package https serves the application over TLS: it reloads the
+// certificates when they change, redirects the plain HTTP requests and
+// tells the browsers to keep using HTTPS.
+package https
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidCertificate = errors.New("invalid TLS certificate")
+)
+
+// Config returns the TLS settings of the server: TLS 1.2 or newer, with
+// forward secrecy and authenticated encryption only. The certificate is
+// returned by getCertificate on every handshake, so it can be reloaded.
+func Config(getCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)) *tls.Config {
+	return &tls.Config{
+		MinVersion:       tls.VersionTLS12,
+		CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256},
+		// the TLS 1.3 suites are not configurable and all of them are safe
+		CipherSuites: []uint16{
+			tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+			tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+			tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+			tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+		},
+		GetCertificate: getCertificate,
+	}
+}
+
+// HSTS sets the Strict-Transport-Security header, so the browsers use only
+// HTTPS on the host for maxAge. A zero maxAge disables it.
+func HSTS(maxAge time.Duration, includeSubdomains bool) func(http.Handler) http.Handler {
+	if maxAge <= 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	value := fmt.Sprintf("max-age=%d", int64(maxAge.Seconds()))
+	if includeSubdomains {
+		value += "; includeSubDomains"
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Strict-Transport-Security", value)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Redirect redirects every request to the same URL on HTTPS, at the port
+// of the httpsAddress, like :8443, omitted when it is 443
+func Redirect(httpsAddress string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddress)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+		// permanent and keeping the method, so the forms posted over HTTP
+		// are posted again over HTTPS
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
+
+// SecureCookies marks every cookie set by the handlers as Secure, so the
+// browsers send them only over HTTPS
+func SecureCookies(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw := &secureCookiesWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+		// the handler wrote nothing, the headers are sent after it returns
+		sw.secureCookies()
+	})
+}
+
+type secureCookiesWriter struct {
+	http.ResponseWriter
+	secured bool
+}
+
+func (w *secureCookiesWriter) WriteHeader(statusCode int) {
+	w.secureCookies()
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *secureCookiesWriter) Write(b []byte) (int, error) {
+	w.secureCookies()
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the original writer
+func (w *secureCookiesWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *secureCookiesWriter) secureCookies() {
+	if w.secured {
+		return
+	}
+	w.secured = true
+	cookies := w.Header()["Set-Cookie"]
+	for i, cookie := range cookies {
+		if !hasSecureAttribute(cookie) {
+			cookies[i] = cookie + "; Secure"
+		}
+	}
+}
+
+func hasSecureAttribute(cookie string) bool {
+	attributes := strings.Split(cookie, ";")
+	for _, attribute := range attributes[1:] {
+		if strings.EqualFold(strings.TrimSpace(attribute), "Secure") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,197 @@
+package https
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCert writes a self-signed certificate for the common name and
+// returns its serial number
+func writeCert(t *testing.T, certFile, keyFile, commonName string, modTime time.Time) *big.Int {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+	return serial
+}
+
+func servedSerial(t *testing.T, r *CertReloader) *big.Int {
+	t.Helper()
+	cert, err := r.GetCertificate(nil)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return leaf.SerialNumber
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	now := time.Now().Truncate(time.Second)
+
+	_, err := NewCertReloader(certFile, keyFile)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	first := writeCert(t, certFile, keyFile, "localhost", now)
+	r, err := NewCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+	assert.Equal(t, first, servedSerial(t, r))
+	changed, err := r.Changed()
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	// a file being written keeps the previous certificate
+	require.NoError(t, os.WriteFile(certFile, []byte("partial"), 0o600))
+	assert.ErrorIs(t, r.Reload(), ErrInvalidCertificate)
+	assert.Equal(t, first, servedSerial(t, r))
+
+	second := writeCert(t, certFile, keyFile, "localhost", now.Add(time.Minute))
+	changed, err = r.Changed()
+	require.NoError(t, err)
+	assert.True(t, changed)
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	signals := make(chan os.Signal)
+	go func() {
+		r.Watch(ctx, 5*time.Millisecond, signals, logger)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool { return servedSerial(t, r).Cmp(second) == 0 },
+		time.Second, 5*time.Millisecond, "reloaded when the files change")
+
+	// the same modification time is only reloaded by the signal
+	third := writeCert(t, certFile, keyFile, "localhost", now.Add(time.Minute))
+	signals <- syscall.SIGHUP
+	assert.Eventually(t, func() bool { return servedSerial(t, r).Cmp(third) == 0 },
+		time.Second, 5*time.Millisecond, "reloaded by the signal")
+
+	cancel()
+	<-done
+}
+
+func TestConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	writeCert(t, certFile, keyFile, "example.com", time.Now())
+	r, err := NewCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = Config(r.GetCertificate)
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	// httptest sets its own certificate, served only without a server name
+	conn, err := tls.Dial("tcp", server.Listener.Addr().String(), &tls.Config{
+		ServerName:         "example.com",
+		InsecureSkipVerify: true,
+		MaxVersion:         tls.VersionTLS12,
+	})
+	require.NoError(t, err)
+	defer conn.Close()
+	state := conn.ConnectionState()
+	assert.Equal(t, "example.com", state.PeerCertificates[0].Subject.CommonName)
+	assert.Contains(t, server.TLS.CipherSuites, state.CipherSuite)
+
+	_, err = tls.Dial("tcp", server.Listener.Addr().String(), &tls.Config{
+		ServerName:         "example.com",
+		InsecureSkipVerify: true,
+		MaxVersion:         tls.VersionTLS11,
+	})
+	assert.Error(t, err, "TLS 1.1 is refused")
+}
+
+func TestHSTS(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	HSTS(365*24*time.Hour, true)(handler).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "max-age=31536000; includeSubDomains", rec.Header().Get("Strict-Transport-Security"))
+
+	rec = httptest.NewRecorder()
+	HSTS(0, true)(handler).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Empty(t, rec.Header().Get("Strict-Transport-Security"))
+}
+
+func TestRedirect(t *testing.T) {
+	for _, tc := range []struct {
+		address, host, want string
+	}{
+		{":443", "example.com", "https://example.com/signin?next=%2F"},
+		{":8443", "example.com:8080", "https://example.com:8443/signin?next=%2F"},
+		{"0.0.0.0:8443", "[::1]:8080", "https://[::1]:8443/signin?next=%2F"},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/signin?next=%2F", nil)
+		req.Host = tc.host
+		rec := httptest.NewRecorder()
+		Redirect(tc.address).ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusPermanentRedirect, rec.Code)
+		assert.Equal(t, tc.want, rec.Header().Get("Location"))
+	}
+}
+
+func TestSecureCookies(t *testing.T) {
+	setCookies := func(write bool) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "token", HttpOnly: true})
+			http.SetCookie(w, &http.Cookie{Name: "csrf", Value: "x", Secure: true})
+			if write {
+				w.Write([]byte("ok"))
+			}
+		})
+	}
+
+	for _, write := range []bool{true, false} {
+		rec := httptest.NewRecorder()
+		SecureCookies(setCookies(write)).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		cookies := rec.Result().Cookies()
+		require.Len(t, cookies, 2)
+		for _, cookie := range cookies {
+			assert.True(t, cookie.Secure, cookie.Name)
+		}
+		assert.Equal(t, "session=token; HttpOnly; Secure", rec.Header().Values("Set-Cookie")[0])
+		assert.Equal(t, "csrf=x; Secure", rec.Header().Values("Set-Cookie")[1])
+	}
+}
@@ -0,0 +1,273 @@
+package i18n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+var (
+	ErrInvalidCatalog     = errors.New("invalid catalog")
+	ErrFallbackNotFound   = errors.New("fallback locale catalog not found")
+	ErrUnsupportedLocale  = errors.New("unsupported locale")
+	ErrInvalidPluralForms = errors.New("invalid plural forms")
+)
+
+// Message is a translated text. Messages with count have a text for each
+// plural form of the language, the "other" form is mandatory.
+type Message struct {
+	Text  string
+	Forms map[plural.Form]string
+}
+
+var pluralForms = map[string]plural.Form{
+	"zero":  plural.Zero,
+	"one":   plural.One,
+	"two":   plural.Two,
+	"few":   plural.Few,
+	"many":  plural.Many,
+	"other": plural.Other,
+}
+
+// UnmarshalJSON accepts a string or an object with the plural forms
+func (m *Message) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &m.Text); err == nil {
+		return nil
+	}
+
+	var forms map[string]string
+	if err := json.Unmarshal(data, &forms); err != nil {
+		return err
+	}
+
+	m.Forms = make(map[plural.Form]string, len(forms))
+	for name, text := range forms {
+		form, ok := pluralForms[name]
+		if !ok {
+			return fmt.Errorf("%w: unknown form %q", ErrInvalidPluralForms, name)
+		}
+		m.Forms[form] = text
+	}
+	if _, ok := m.Forms[plural.Other]; !ok {
+		return fmt.Errorf("%w: missing form \"other\"", ErrInvalidPluralForms)
+	}
+	return nil
+}
+
+// Catalog maps the message keys to the messages of a single locale
+type Catalog map[string]Message
+
+// Bundle holds the catalogs of every supported locale
+type Bundle struct {
+	fallback language.Tag
+	tags     []language.Tag
+	catalogs map[language.Tag]Catalog
+	matcher  language.Matcher
+}
+
+// LoadFS loads every "<locale>.json" catalog in the root of fsys.
+// Messages missing in a catalog are looked up in the fallback locale.
+func LoadFS(fsys fs.FS, fallback string) (*Bundle, error) {
+	fallbackTag, err := language.Parse(fallback)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := Bundle{
+		fallback: fallbackTag,
+		catalogs: make(map[language.Tag]Catalog, len(names)),
+	}
+	// the fallback goes first, so the matcher uses it when nothing matches
+	bundle.tags = append(bundle.tags, fallbackTag)
+	for _, name := range names {
+		tag, err := language.Parse(strings.TrimSuffix(path.Base(name), ".json"))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidCatalog, name, err)
+		}
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		var catalog Catalog
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidCatalog, name, err)
+		}
+
+		bundle.catalogs[tag] = catalog
+		if tag != fallbackTag {
+			bundle.tags = append(bundle.tags, tag)
+		}
+	}
+
+	if _, ok := bundle.catalogs[fallbackTag]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrFallbackNotFound, fallback)
+	}
+
+	bundle.matcher = language.NewMatcher(bundle.tags)
+	return &bundle, nil
+}
+
+// Locales returns the supported locales, the fallback first
+func (b *Bundle) Locales() []string {
+	locales := make([]string, 0, len(b.tags))
+	for _, tag := range b.tags {
+		locales = append(locales, tag.String())
+	}
+	return locales
+}
+
+// Catalog returns the catalog of the locale
+func (b *Bundle) Catalog(locale string) (Catalog, error) {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog, ok := b.catalogs[tag]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedLocale, locale)
+	}
+	return catalog, nil
+}
+
+// Match returns the supported locale that best matches the preferred
+// locale or, when it is empty or unsupported, the Accept-Language header.
+func (b *Bundle) Match(preferred, acceptLanguage string) string {
+	if tag, err := language.Parse(preferred); err == nil {
+		if _, ok := b.catalogs[tag]; ok {
+			return tag.String()
+		}
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return b.fallback.String()
+	}
+
+	_, index, confidence := b.matcher.Match(tags...)
+	if confidence == language.No {
+		return b.fallback.String()
+	}
+	return b.tags[index].String()
+}
+
+// Localizer returns the localizer of the locale, or of the fallback
+// locale when it is not supported.
+func (b *Bundle) Localizer(locale string) *Localizer {
+	tag, err := language.Parse(locale)
+	if _, ok := b.catalogs[tag]; err != nil || !ok {
+		tag = b.fallback
+	}
+
+	return &Localizer{
+		bundle:   b,
+		tag:      tag,
+		catalog:  b.catalogs[tag],
+		fallback: b.catalogs[b.fallback],
+	}
+}
+
+// Localizer translates messages to a single locale. A nil Localizer
+// returns the keys untranslated.
+type Localizer struct {
+	bundle   *Bundle
+	tag      language.Tag
+	catalog  Catalog
+	fallback Catalog
+}
+
+// Locale returns the localizer locale
+func (l *Localizer) Locale() string {
+	if l == nil {
+		return ""
+	}
+	return l.tag.String()
+}
+
+// Locales returns the locales supported by the localizer bundle
+func (l *Localizer) Locales() []string {
+	if l == nil {
+		return nil
+	}
+	return l.bundle.Locales()
+}
+
+// ForLocale returns the localizer of the locale from the same bundle, or of
+// the fallback locale when it is not supported. A nil Localizer returns nil.
+func (l *Localizer) ForLocale(locale string) *Localizer {
+	if l == nil {
+		return nil
+	}
+	return l.bundle.Localizer(locale)
+}
+
+func (l *Localizer) message(key string) (Message, bool) {
+	if l == nil {
+		return Message{}, false
+	}
+	if msg, ok := l.catalog[key]; ok {
+		return msg, true
+	}
+	msg, ok := l.fallback[key]
+	return msg, ok
+}
+
+// T translates the message, formatting it with the args as fmt.Sprintf does.
+// The key itself is returned when the message is not found in any catalog.
+func (l *Localizer) T(key string, args ...any) string {
+	msg, ok := l.message(key)
+	if !ok {
+		return key
+	}
+
+	text := msg.Text
+	if msg.Forms != nil {
+		text = msg.Forms[plural.Other]
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// N translates the message in the plural form matching the count. The count
+// is formatted with the args, as the first one.
+// The key itself is returned when the message is not found in any catalog.
+func (l *Localizer) N(key string, count int, args ...any) string {
+	msg, ok := l.message(key)
+	if !ok {
+		return key
+	}
+
+	text := msg.Text
+	if msg.Forms != nil {
+		form := plural.Cardinal.MatchPlural(l.tag, count, 0, 0, 0, 0)
+		if text, ok = msg.Forms[form]; !ok {
+			text = msg.Forms[plural.Other]
+		}
+	}
+	return fmt.Sprintf(text, append([]any{count}, args...)...)
+}
+
+// TLines translates each line of the text, as the client errors joined
+// by entities.Error.ClientErr.
+func (l *Localizer) TLines(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = l.T(line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,89 @@
+package i18n
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testBundle(t *testing.T) *Bundle {
+	t.Helper()
+	fsys := fstest.MapFS{
+		"en.json": {Data: []byte(`{
+			"greeting": "Hello, %s!",
+			"only.en": "English only",
+			"items": {"one": "%d item", "other": "%d items"}
+		}`)},
+		"pt-BR.json": {Data: []byte(`{
+			"greeting": "Olá, %s!",
+			"items": {"one": "%d item", "other": "%d itens"}
+		}`)},
+	}
+	bundle, err := LoadFS(fsys, "en")
+	require.NoError(t, err)
+	return bundle
+}
+
+func TestLoadFS(t *testing.T) {
+	t.Run("MissingFallback", func(t *testing.T) {
+		_, err := LoadFS(fstest.MapFS{"pt-BR.json": {Data: []byte(`{}`)}}, "en")
+		assert.ErrorIs(t, err, ErrFallbackNotFound)
+	})
+
+	t.Run("MissingOtherForm", func(t *testing.T) {
+		_, err := LoadFS(fstest.MapFS{"en.json": {Data: []byte(`{"items": {"one": "%d item"}}`)}}, "en")
+		assert.ErrorIs(t, err, ErrInvalidPluralForms)
+	})
+
+	t.Run("Locales", func(t *testing.T) {
+		assert.Equal(t, []string{"en", "pt-BR"}, testBundle(t).Locales())
+	})
+}
+
+func TestBundleMatch(t *testing.T) {
+	bundle := testBundle(t)
+	testCases := []struct {
+		desc           string
+		preferred      string
+		acceptLanguage string
+		expected       string
+	}{
+		{desc: "Empty", expected: "en"},
+		{desc: "Preferred", preferred: "pt-BR", acceptLanguage: "en-US", expected: "pt-BR"},
+		{desc: "UnsupportedPreferred", preferred: "fr", acceptLanguage: "pt-BR,pt;q=0.9", expected: "pt-BR"},
+		{desc: "AcceptLanguageRegion", acceptLanguage: "pt", expected: "pt-BR"},
+		{desc: "AcceptLanguageQuality", acceptLanguage: "fr;q=0.9,en;q=0.5,pt-BR;q=0.8", expected: "pt-BR"},
+		{desc: "Unsupported", acceptLanguage: "ja", expected: "en"},
+		{desc: "Invalid", acceptLanguage: "!!", expected: "en"},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			assert.Equal(t, tC.expected, bundle.Match(tC.preferred, tC.acceptLanguage))
+		})
+	}
+}
+
+func TestLocalizer(t *testing.T) {
+	bundle := testBundle(t)
+	pt := bundle.Localizer("pt-BR")
+
+	assert.Equal(t, "pt-BR", pt.Locale())
+	assert.Equal(t, "Olá, Maria!", pt.T("greeting", "Maria"))
+	assert.Equal(t, "English only", pt.T("only.en"), "falls back to the fallback catalog")
+	assert.Equal(t, "missing.key", pt.T("missing.key"))
+	assert.Equal(t, "English only\nmissing.key", pt.TLines("only.en\nmissing.key"))
+
+	assert.Equal(t, "1 item", pt.N("items", 1))
+	assert.Equal(t, "2 itens", pt.N("items", 2))
+	assert.Equal(t, "0 items", bundle.Localizer("en").N("items", 0))
+
+	assert.Equal(t, "en", bundle.Localizer("ja").Locale(), "unsupported locales use the fallback")
+	assert.Equal(t, "en", pt.ForLocale("en").Locale())
+	assert.Equal(t, "en", pt.ForLocale("").Locale())
+
+	var nilLocalizer *Localizer
+	assert.Equal(t, "greeting", nilLocalizer.T("greeting", "Maria"))
+	assert.Empty(t, nilLocalizer.Locales())
+}
@@ -0,0 +1,175 @@
+package images
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"sync/atomic"
+
+	_ "image/gif"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	ErrImageTooLarge     = errors.New("image too large")
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	ErrInvalidImage      = errors.New("invalid image")
+)
+
+// Options configure how Process validates and transforms an image
+type Options struct {
+	// MaxBytes is the maximum size of the uploaded file
+	MaxBytes int64
+	// MaxPixels is the maximum width * height of the uploaded image, checked
+	// before decoding it to refuse decompression bombs
+	MaxPixels int
+	// Width and Height bound the processed image, larger images are scaled
+	// down keeping the aspect ratio. Zero keeps the original dimension.
+	Width  int
+	Height int
+	// Square crops the center square of the image before scaling it
+	Square bool
+	// Quality of the JPEG encoding, from 1 to 100
+	Quality int
+}
+
+// processing counts the images being processed
+var processing atomic.Int64
+
+// Processing returns how many images are being processed at the moment.
+// Images are processed synchronously, so this is the depth of the work
+// waiting on image processing.
+func Processing() int64 {
+	return processing.Load()
+}
+
+var (
+	AvatarOptions = Options{
+		MaxBytes:  5 << 20,
+		MaxPixels: 40_000_000,
+		Width:     256,
+		Height:    256,
+		Square:    true,
+		Quality:   85,
+	}
+)
+
+// Image is a processed image, re-encoded without the metadata of the upload
+type Image struct {
+	Data        []byte
+	ContentType string
+	// Ext is the file extension matching the content type, including the dot
+	Ext    string
+	Width  int
+	Height int
+}
+
+// Process decodes the image, validates its size, crops and scales it and
+// encodes it again. Images with transparency (PNG and GIF) are encoded as
+// PNG, any other as JPEG.
+//
+// Possible errors:
+//   - ErrImageTooLarge
+//   - ErrUnsupportedFormat
+//   - ErrInvalidImage
+func Process(r io.Reader, opts Options) (*Image, error) {
+	processing.Add(1)
+	defer processing.Add(-1)
+
+	data, err := io.ReadAll(io.LimitReader(r, opts.MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > opts.MaxBytes {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrImageTooLarge, opts.MaxBytes)
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if errors.Is(err, image.ErrFormat) {
+		return nil, ErrUnsupportedFormat
+	} else if err != nil {
+		return nil, errors.Join(ErrInvalidImage, err)
+	}
+	if config.Width*config.Height > opts.MaxPixels {
+		return nil, fmt.Errorf("%w: %dx%d pixels", ErrImageTooLarge, config.Width, config.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Join(ErrInvalidImage, err)
+	}
+
+	if opts.Square {
+		src = cropSquare(src)
+	}
+	dst := scale(src, opts.Width, opts.Height)
+
+	var buf bytes.Buffer
+	img := Image{
+		Width:  dst.Bounds().Dx(),
+		Height: dst.Bounds().Dy(),
+	}
+	w := bufio.NewWriter(&buf)
+	switch format {
+	case "png", "gif":
+		img.ContentType, img.Ext = "image/png", ".png"
+		err = png.Encode(w, dst)
+	default:
+		img.ContentType, img.Ext = "image/jpeg", ".jpg"
+		err = jpeg.Encode(w, dst, &jpeg.Options{Quality: opts.Quality})
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	img.Data = buf.Bytes()
+	return &img, nil
+}
+
+// cropSquare returns the largest square in the center of the image
+func cropSquare(src image.Image) image.Image {
+	bounds := src.Bounds()
+	size := min(bounds.Dx(), bounds.Dy())
+	x := bounds.Min.X + (bounds.Dx()-size)/2
+	y := bounds.Min.Y + (bounds.Dy()-size)/2
+	square := image.Rect(x, y, x+size, y+size)
+
+	if sub, ok := src.(interface {
+		SubImage(r image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(square)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Copy(dst, image.Point{}, src, square, draw.Src, nil)
+	return dst
+}
+
+// scale returns the image scaled down to fit into width x height. Images
+// already fitting are copied, dropping the source color model and metadata.
+func scale(src image.Image, width, height int) image.Image {
+	bounds := src.Bounds()
+	ratio := 1.0
+	if width > 0 && bounds.Dx() > width {
+		ratio = min(ratio, float64(width)/float64(bounds.Dx()))
+	}
+	if height > 0 && bounds.Dy() > height {
+		ratio = min(ratio, float64(height)/float64(bounds.Dy()))
+	}
+
+	dstWidth := max(1, int(float64(bounds.Dx())*ratio+0.5))
+	dstHeight := max(1, int(float64(bounds.Dy())*ratio+0.5))
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+	return dst
+}
@@ -0,0 +1,108 @@
+package images
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	img.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func encodeJPEG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height)), nil))
+	return buf.Bytes()
+}
+
+func TestProcess(t *testing.T) {
+	opts := Options{MaxBytes: 1 << 20, MaxPixels: 1_000_000, Width: 100, Height: 100, Square: true, Quality: 80}
+
+	t.Run("CropsAndScalesPNG", func(t *testing.T) {
+		img, err := Process(bytes.NewReader(encodePNG(t, 400, 200)), opts)
+		require.NoError(t, err)
+		assert.Equal(t, "image/png", img.ContentType)
+		assert.Equal(t, ".png", img.Ext)
+		assert.Equal(t, 100, img.Width)
+		assert.Equal(t, 100, img.Height)
+
+		decoded, format, err := image.Decode(bytes.NewReader(img.Data))
+		require.NoError(t, err)
+		assert.Equal(t, "png", format)
+		assert.Equal(t, image.Rect(0, 0, 100, 100), decoded.Bounds())
+	})
+
+	t.Run("KeepsAspectRatioJPEG", func(t *testing.T) {
+		opts := opts
+		opts.Square = false
+		img, err := Process(bytes.NewReader(encodeJPEG(t, 400, 200)), opts)
+		require.NoError(t, err)
+		assert.Equal(t, "image/jpeg", img.ContentType)
+		assert.Equal(t, 100, img.Width)
+		assert.Equal(t, 50, img.Height)
+	})
+
+	t.Run("DoesNotScaleUp", func(t *testing.T) {
+		img, err := Process(bytes.NewReader(encodePNG(t, 20, 30)), opts)
+		require.NoError(t, err)
+		assert.Equal(t, 20, img.Width)
+		assert.Equal(t, 20, img.Height)
+	})
+
+	t.Run("TooManyBytes", func(t *testing.T) {
+		opts := opts
+		opts.MaxBytes = 10
+		_, err := Process(bytes.NewReader(encodePNG(t, 20, 20)), opts)
+		assert.ErrorIs(t, err, ErrImageTooLarge)
+	})
+
+	t.Run("TooManyPixels", func(t *testing.T) {
+		opts := opts
+		opts.MaxPixels = 100
+		_, err := Process(bytes.NewReader(encodePNG(t, 20, 20)), opts)
+		assert.ErrorIs(t, err, ErrImageTooLarge)
+	})
+
+	t.Run("NotAnImage", func(t *testing.T) {
+		_, err := Process(strings.NewReader("<svg></svg>"), opts)
+		assert.ErrorIs(t, err, ErrUnsupportedFormat)
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		data := encodePNG(t, 20, 20)
+		_, err := Process(bytes.NewReader(data[:len(data)/2]), opts)
+		assert.ErrorIs(t, err, ErrInvalidImage)
+	})
+}
+
+func TestDirStore(t *testing.T) {
+	store, err := NewDirStore(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, store.Save("1-avatar.png", []byte("data")))
+	data, err := fs.ReadFile(store, "1-avatar.png")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), data)
+
+	for _, name := range []string{"../escape.png", "dir/file.png", ".hidden", ""} {
+		assert.ErrorIs(t, store.Save(name, nil), ErrInvalidName, name)
+	}
+
+	require.NoError(t, store.Remove("1-avatar.png"))
+	require.NoError(t, store.Remove("1-avatar.png"), "removing a missing image")
+}
@@ -0,0 +1,90 @@
+package images
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+var (
+	ErrInvalidName = errors.New("invalid image name")
+)
+
+// Store keeps the processed images. The images are read through fs.FS.
+type Store interface {
+	fs.FS
+	Save(name string, data []byte) error
+	Remove(name string) error
+}
+
+// DirStore stores the images as files of a single directory
+type DirStore struct {
+	fs.FS
+	dir string
+}
+
+// NewDirStore creates the directory when it does not exist
+func NewDirStore(dir string) (*DirStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DirStore{FS: os.DirFS(dir), dir: dir}, nil
+}
+
+// CheckWritable creates and removes a file in the directory to check the
+// images can be saved
+func (ds *DirStore) CheckWritable() error {
+	probe, err := os.CreateTemp(ds.dir, ".probe-*")
+	if err != nil {
+		return err
+	}
+	return errors.Join(probe.Close(), os.Remove(probe.Name()))
+}
+
+// Save writes the image to a temporary file, renamed to name once
+// complete, so readers never see partial images.
+func (ds *DirStore) Save(name string, data []byte) error {
+	path, err := ds.path(name)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(ds.dir, ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Remove deletes the image, removing a missing image is not an error
+func (ds *DirStore) Remove(name string) error {
+	path, err := ds.path(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (ds *DirStore) path(name string) (string, error) {
+	if !fs.ValidPath(name) || filepath.Base(name) != name || name == "." || name[0] == '.' {
+		return "", ErrInvalidName
+	}
+	return filepath.Join(ds.dir, name), nil
+}
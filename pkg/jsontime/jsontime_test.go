@@ -0,0 +1,27 @@
+package jsontime
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDurationJSON(t *testing.T) {
+	var config struct {
+		Timeout    Duration `json:"timeout"`
+		DrainDelay Duration `json:"drain_delay"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"timeout": "1.5s", "drain_delay": "300ms"}`), &config))
+	assert.Equal(t, 1500*time.Millisecond, time.Duration(config.Timeout))
+	assert.Equal(t, 300*time.Millisecond, time.Duration(config.DrainDelay))
+
+	data, err := json.Marshal(config.Timeout)
+	require.NoError(t, err)
+	assert.JSONEq(t, `"1.5s"`, string(data))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"timeout": 5}`), &config))
+	assert.Error(t, json.Unmarshal([]byte(`{"timeout": "5 seconds"}`), &config))
+}
@@ -0,0 +1,108 @@
+// Package logging configures the structured loggers of the application.
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/twsm000/lenslocked/pkg/settings"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+
+	// Redacted replaces the secrets in the log records
+	Redacted = "[REDACTED]"
+)
+
+var (
+	ErrInvalidFormat = errors.New("invalid log format")
+	ErrInvalidLevel  = errors.New("invalid log level")
+)
+
+// sensitiveKeys are the attribute keys, case insensitive, whose values are
+// always redacted, whatever they hold
+var sensitiveKeys = map[string]bool{
+	"password":      true,
+	"secret":        true,
+	"token":         true,
+	"key":           true,
+	"previous_keys": true,
+	"authorization": true,
+	"cookie":        true,
+}
+
+// Config selects the format and the minimum level of the log records
+type Config struct {
+	// Format is FormatText or FormatJSON, defaults to FormatText
+	Format string `json:"format"`
+	// Level is debug, info, warn or error, defaults to info
+	Level string `json:"level"`
+}
+
+// Validate possible errors:
+//   - settings.ErrInvalidSettings {ErrInvalidFormat, ErrInvalidLevel}
+func (c Config) Validate() error {
+	var p settings.Problems
+	if c.Format != "" {
+		p.OneOf("format", strings.ToLower(c.Format), ErrInvalidFormat, FormatText, FormatJSON)
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Level)); c.Level != "" && err != nil {
+		p.Addf("level", "%w: %q, expected debug, info, warn or error", ErrInvalidLevel, c.Level)
+	}
+	return p.Err()
+}
+
+// New returns a logger writing to w as configured. Possible errors:
+//   - ErrInvalidFormat
+//   - ErrInvalidLevel
+func New(w io.Writer, cfg Config) (*slog.Logger, error) {
+	var level slog.Level
+	if cfg.Level != "" {
+		if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+			return nil, errors.Join(ErrInvalidLevel, err)
+		}
+	}
+
+	opts := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: RedactAttr,
+	}
+	switch strings.ToLower(cfg.Format) {
+	case "", FormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidFormat, cfg.Format)
+	}
+}
+
+// RedactAttr is a slog.HandlerOptions.ReplaceAttr function that redacts the
+// attributes with sensitive keys, like password or token, in any group
+func RedactAttr(groups []string, a slog.Attr) slog.Attr {
+	if IsSensitive(a.Key) && a.Value.Kind() != slog.KindGroup {
+		return slog.String(a.Key, Redacted)
+	}
+	return a
+}
+
+// IsSensitive reports if the values of the key are always redacted
+func IsSensitive(key string) bool {
+	return sensitiveKeys[strings.ToLower(key)]
+}
+
+// Secret is a string that is never written to the logs
+type Secret string
+
+func (s Secret) LogValue() slog.Value {
+	if s == "" {
+		return slog.StringValue("")
+	}
+	return slog.StringValue(Redacted)
+}
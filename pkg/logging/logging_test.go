@@ -0,0 +1,66 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Config{Format: "JSON", Level: "warn"})
+	require.NoError(t, err)
+
+	logger.Info("ignored")
+	logger.Warn("written", "user_id", 42)
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "written", record["msg"])
+	assert.Equal(t, "WARN", record["level"])
+	assert.EqualValues(t, 42, record["user_id"])
+
+	_, err = New(&buf, Config{Format: "xml"})
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+
+	_, err = New(&buf, Config{Level: "verbose"})
+	assert.ErrorIs(t, err, ErrInvalidLevel)
+}
+
+func TestRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Config{Format: FormatJSON})
+	require.NoError(t, err)
+
+	logger.Info("config",
+		slog.Group("smtp", "host", "smtp.example.com", "Password", "smtp-pass"),
+		"token", "abc",
+		"api_key", Secret("s3cr3t"),
+		"empty", Secret(""),
+	)
+
+	out := buf.String()
+	assert.NotContains(t, out, "smtp-pass")
+	assert.NotContains(t, out, "abc")
+	assert.NotContains(t, out, "s3cr3t")
+
+	var record struct {
+		SMTP struct {
+			Host     string `json:"host"`
+			Password string `json:"Password"`
+		} `json:"smtp"`
+		Token  string `json:"token"`
+		APIKey string `json:"api_key"`
+		Empty  string `json:"empty"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "smtp.example.com", record.SMTP.Host)
+	assert.Equal(t, Redacted, record.SMTP.Password)
+	assert.Equal(t, Redacted, record.Token)
+	assert.Equal(t, Redacted, record.APIKey)
+	assert.Empty(t, record.Empty)
+}
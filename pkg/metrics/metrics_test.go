@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestMiddleware(t *testing.T) {
+	m := New()
+	router := chi.NewRouter()
+	router.Use(m.Middleware)
+	router.Get("/u/{username}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/u/alice", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/u/bob", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	out := scrape(t, m)
+	assert.Contains(t, out, `lenslocked_http_requests_total{method="GET",route="/u/{username}",status="404"} 2`)
+	assert.Contains(t, out, `lenslocked_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, out, `lenslocked_http_request_duration_seconds_count{method="GET",route="/u/{username}"} 2`)
+	assert.NotContains(t, out, "alice")
+}
+
+func TestEmailsAndGauges(t *testing.T) {
+	m := New()
+	m.EmailSent(nil)
+	m.EmailSent(errors.New("smtp down"))
+	m.EmailSent(nil)
+	require.NoError(t, m.RegisterGauge("sessions", "Active sessions.", func() float64 { return 3 }))
+
+	out := scrape(t, m)
+	assert.Contains(t, out, `lenslocked_email_sent_total{result="success"} 2`)
+	assert.Contains(t, out, `lenslocked_email_sent_total{result="failure"} 1`)
+	assert.Contains(t, out, "lenslocked_sessions 3")
+}
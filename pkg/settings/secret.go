@@ -0,0 +1,103 @@
+package settings
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+const (
+	// FileSuffix names the fields holding the path of the file with the
+	// value of their sibling field, like password_file for password
+	FileSuffix = "_file"
+
+	Base64Prefix = "base64:"
+	HexPrefix    = "hex:"
+)
+
+var (
+	ErrConflictingSettings = errors.New("conflicting settings")
+	ErrInvalidKey          = errors.New("invalid key encoding")
+)
+
+// DecodeKey returns the bytes of a key written as text, or encoded with a
+// prefix, like base64:c2VjcmV0 or hex:736563726574, so any byte fits in it.
+// Possible errors:
+//   - ErrInvalidKey
+func DecodeKey(key string) ([]byte, error) {
+	var decoded []byte
+	var err error
+	switch {
+	case strings.HasPrefix(key, Base64Prefix):
+		encoded := strings.TrimPrefix(key, Base64Prefix)
+		decoded, err = base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			decoded, err = base64.RawURLEncoding.DecodeString(strings.TrimRight(encoded, "="))
+		}
+	case strings.HasPrefix(key, HexPrefix):
+		decoded, err = hex.DecodeString(strings.TrimPrefix(key, HexPrefix))
+	default:
+		return []byte(key), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+	}
+	return decoded, nil
+}
+
+// ResolveFiles sets every string field of v, a pointer to a struct, that
+// has a sibling field with the FileSuffix, to the content of the file, like
+// the secrets mounted by the container orchestrators. The trailing line
+// break of the file is removed. Possible errors:
+//   - ErrConflictingSettings, when both fields are set
+//   - the errors of os.ReadFile
+func ResolveFiles(v any) error {
+	return resolveFiles(reflect.ValueOf(v).Elem(), nil)
+}
+
+func resolveFiles(value reflect.Value, path []string) error {
+	fields := map[string]reflect.Value{}
+	var names []string
+	var errs []error
+	t := value.Type()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		name := jsonName(sf)
+		if name == "" {
+			continue
+		}
+		fields[name] = value.Field(i)
+		names = append(names, name)
+		if !isLeaf(sf.Type) {
+			errs = append(errs, resolveFiles(value.Field(i), append(path[:len(path):len(path)], name)))
+		}
+	}
+
+	for _, name := range names {
+		fileField := fields[name]
+		target, ok := fields[strings.TrimSuffix(name, FileSuffix)]
+		if !strings.HasSuffix(name, FileSuffix) || !ok ||
+			fileField.Kind() != reflect.String || target.Kind() != reflect.String ||
+			fileField.String() == "" {
+			continue
+		}
+
+		fieldPath := strings.Join(append(path[:len(path):len(path)], name), ".")
+		if target.String() != "" {
+			errs = append(errs, fmt.Errorf("%w: %s and %s are both set",
+				ErrConflictingSettings, strings.TrimSuffix(fieldPath, FileSuffix), fieldPath))
+			continue
+		}
+		data, err := os.ReadFile(fileField.String())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", fieldPath, err))
+			continue
+		}
+		target.SetString(strings.TrimRight(string(data), "\r\n"))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,68 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeKey(t *testing.T) {
+	for key, want := range map[string]string{
+		"plain text":            "plain text",
+		"base64:c2VjcmV0":       "secret",
+		"base64:c2VjcmV0Pz8-":   "secret??>",
+		"base64:c2VjcmV0Pz8_":   "secret???",
+		"hex:736563726574":      "secret",
+		"hex:":                  "",
+		"base64:c2VjcmV0Pz8-==": "secret??>",
+	} {
+		decoded, err := DecodeKey(key)
+		require.NoError(t, err, key)
+		assert.Equal(t, want, string(decoded), key)
+	}
+
+	_, err := DecodeKey("hex:zz")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	_, err = DecodeKey("base64:!!")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+type secrets struct {
+	Password     string `json:"password"`
+	PasswordFile string `json:"password_file"`
+	Token        string `json:"token"`
+	TokenFile    string `json:"token_file"`
+	Nested       struct {
+		Key     string `json:"key"`
+		KeyFile string `json:"key_file"`
+	} `json:"nested"`
+}
+
+func TestResolveFiles(t *testing.T) {
+	dir := t.TempDir()
+	passwordFile := filepath.Join(dir, "password")
+	keyFile := filepath.Join(dir, "key")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("s3cret\n"), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, []byte("hex:6b6579\r\n"), 0o600))
+
+	var s secrets
+	s.PasswordFile = passwordFile
+	s.Token = "inline"
+	s.Nested.KeyFile = keyFile
+	require.NoError(t, ResolveFiles(&s))
+	assert.Equal(t, "s3cret", s.Password)
+	assert.Equal(t, "inline", s.Token)
+	assert.Equal(t, "hex:6b6579", s.Nested.Key)
+
+	s.TokenFile = passwordFile
+	s.Nested.KeyFile = filepath.Join(dir, "missing")
+	s.Nested.Key = ""
+	err := ResolveFiles(&s)
+	assert.ErrorIs(t, err, ErrConflictingSettings)
+	assert.ErrorContains(t, err, "token and token_file are both set")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.ErrorContains(t, err, "nested.key_file")
+}
@@ -0,0 +1,260 @@
+// Package settings layers the application settings read from a JSON file,
+// which may have comments, the environment variables and the command line
+// on the same struct, addressing the fields by their JSON names.
+package settings
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+var (
+	ErrUnknownSetting = errors.New("unknown setting")
+	ErrInvalidValue   = errors.New("invalid setting value")
+)
+
+// LoadFile decodes the JSON file at path on v, keeping the fields missing
+// in the file. The file may have comments and trailing commas, rejected
+// by encoding/json, and must not have fields unknown by v.
+func LoadFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(StripJSONC(data)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// StripJSONC turns JSON with comments into plain JSON, replacing the // and
+// /* */ comments by spaces, so the decoder errors keep their offsets, and
+// removing the trailing commas before a } or ].
+func StripJSONC(data []byte) []byte {
+	out := bytes.Clone(data)
+	inString := false
+	for i := 0; i < len(out); i++ {
+		c := out[i]
+		switch {
+		case inString:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '/' && i+1 < len(out) && out[i+1] == '/':
+			for ; i < len(out) && out[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '*':
+			end := bytes.Index(out[i+2:], []byte("*/"))
+			if end < 0 {
+				return out // unterminated, left for the decoder to reject
+			}
+			for j := i; j < i+2+end+2; j++ {
+				if out[j] != '\n' {
+					out[j] = ' '
+				}
+			}
+			i += 2 + end + 1
+		}
+	}
+
+	// the comments are blank now, so a trailing comma is followed by spaces
+	inString = false
+	for i := 0; i < len(out); i++ {
+		c := out[i]
+		switch {
+		case inString:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == ',':
+			next := bytes.TrimLeft(out[i+1:], " \t\r\n")
+			if len(next) > 0 && (next[0] == '}' || next[0] == ']') {
+				out[i] = ' '
+			}
+		}
+	}
+	return out
+}
+
+// ApplyEnv sets every field of v, a pointer to a struct, that has an
+// environment variable. The variable is named by the prefix and the JSON
+// names of the field path in upper case, joined by underscores, like
+// LENSLOCKED_DATABASE_PASSWORD for the database.password field.
+//
+// Possible errors:
+//   - ErrInvalidValue
+func ApplyEnv(v any, prefix string, lookupEnv func(key string) (string, bool)) error {
+	var errs []error
+	walk(reflect.ValueOf(v).Elem(), nil, func(path []string, field reflect.Value) {
+		name := EnvName(prefix, path)
+		if value, ok := lookupEnv(name); ok {
+			if err := setValue(field, value); err != nil {
+				errs = append(errs, fmt.Errorf("%w: %s: %w", ErrInvalidValue, name, err))
+			}
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// EnvName returns the environment variable of the field path
+func EnvName(prefix string, path []string) string {
+	return prefix + "_" + strings.ToUpper(strings.Join(path, "_"))
+}
+
+// Override sets the field of v, a pointer to a struct, from an assignment
+// like database.port=5433, addressing the field by the JSON names of its
+// path joined by dots.
+//
+// Possible errors:
+//   - ErrUnknownSetting
+//   - ErrInvalidValue
+func Override(v any, assignment string) error {
+	path, value, ok := strings.Cut(assignment, "=")
+	if !ok {
+		return fmt.Errorf("%w: %q, expected path=value", ErrInvalidValue, assignment)
+	}
+
+	var found bool
+	var err error
+	walk(reflect.ValueOf(v).Elem(), nil, func(fieldPath []string, field reflect.Value) {
+		if strings.Join(fieldPath, ".") == path {
+			found = true
+			if setErr := setValue(field, value); setErr != nil {
+				err = fmt.Errorf("%w: %s: %w", ErrInvalidValue, path, setErr)
+			}
+		}
+	})
+	if !found {
+		return fmt.Errorf("%w: %q", ErrUnknownSetting, path)
+	}
+	return err
+}
+
+// Masked returns v as a JSON object with the non empty values of the
+// sensitive keys, or of the lists they hold, replaced by mask, to show
+// the effective settings
+func Masked(v any, isSensitive func(key string) bool, mask string) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var object map[string]any
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, err
+	}
+	maskObject(object, isSensitive, mask)
+	return object, nil
+}
+
+func maskObject(object map[string]any, isSensitive func(key string) bool, mask string) {
+	for key, value := range object {
+		switch value := value.(type) {
+		case map[string]any:
+			maskObject(value, isSensitive, mask)
+		case []any:
+			if isSensitive(key) {
+				for i, item := range value {
+					if item != "" {
+						value[i] = mask
+					}
+				}
+			}
+		case string:
+			if value != "" && isSensitive(key) {
+				object[key] = mask
+			}
+		}
+	}
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// isLeaf reports if the value is set as a whole, instead of by its fields
+func isLeaf(t reflect.Type) bool {
+	ptr := reflect.PointerTo(t)
+	return t.Kind() != reflect.Struct ||
+		ptr.Implements(jsonUnmarshalerType) ||
+		ptr.Implements(textUnmarshalerType)
+}
+
+// walk calls fn with every leaf field of the struct and its path of JSON
+// names, skipping the unexported fields and the ones tagged with "-"
+func walk(value reflect.Value, path []string, fn func(path []string, field reflect.Value)) {
+	t := value.Type()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		name := jsonName(sf)
+		if name == "" {
+			continue
+		}
+
+		fieldPath := append(path[:len(path):len(path)], name)
+		if isLeaf(sf.Type) {
+			fn(fieldPath, value.Field(i))
+		} else {
+			walk(value.Field(i), fieldPath, fn)
+		}
+	}
+}
+
+// jsonName returns the JSON name of the field, empty when it is not
+// exported or is tagged with "-"
+func jsonName(sf reflect.StructField) string {
+	if !sf.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return strings.ToLower(sf.Name)
+	default:
+		return name
+	}
+}
+
+// setValue sets the strings as they are and decodes any other value as
+// JSON, quoting it when it is not valid JSON, so "5s" and 5s are the same.
+// A list of strings may be separated by commas instead, like a,b.
+func setValue(field reflect.Value, value string) error {
+	if field.Kind() == reflect.String && !reflect.PointerTo(field.Type()).Implements(jsonUnmarshalerType) {
+		field.SetString(value)
+		return nil
+	}
+
+	ptr := field.Addr().Interface()
+	if err := json.Unmarshal([]byte(value), ptr); err == nil {
+		return nil
+	}
+	if field.Type() == reflect.TypeFor[[]string]() {
+		field.Set(reflect.ValueOf(strings.Split(value, ",")))
+		return nil
+	}
+	quoted, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(quoted, ptr)
+}
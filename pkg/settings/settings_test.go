@@ -0,0 +1,133 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twsm000/lenslocked/pkg/jsontime"
+)
+
+type database struct {
+	Host     string            `json:"host"`
+	Port     uint16            `json:"port"`
+	Password string            `json:"password"`
+	Timeout  jsontime.Duration `json:"query_timeout"`
+}
+
+type config struct {
+	Dev      bool     `json:"dev"`
+	Database database `json:"database"`
+	Tags     []string `json:"tags"`
+	internal string
+}
+
+func TestStripJSONC(t *testing.T) {
+	input := `{
+  // a comment
+  "url": "http://example.com/a//b", /* inline */ "quote": "a \"// b\"",
+  "list": [1, 2,],
+  /* multi
+     line */
+  "last": true,
+}`
+	stripped := StripJSONC([]byte(input))
+	assert.Len(t, stripped, len(input))
+	assert.Equal(t, strings.Count(input, "\n"), strings.Count(string(stripped), "\n"))
+	assert.JSONEq(t, `{
+		"url": "http://example.com/a//b",
+		"quote": "a \"// b\"",
+		"list": [1, 2],
+		"last": true
+	}`, string(stripped))
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "env.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"database": {
+			"host": "db", // the host
+			"query_timeout": "3s",
+		},
+	}`), 0o600))
+
+	cfg := config{Database: database{Port: 5432}}
+	require.NoError(t, LoadFile(path, &cfg))
+	assert.Equal(t, "db", cfg.Database.Host)
+	assert.Equal(t, uint16(5432), cfg.Database.Port, "the defaults are kept")
+	assert.Equal(t, jsontime.Duration(3*time.Second), cfg.Database.Timeout)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"unknown": 1}`), 0o600))
+	assert.ErrorContains(t, LoadFile(path, &cfg), "unknown")
+}
+
+func TestApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"APP_DEV":                    "true",
+		"APP_DATABASE_HOST":          "db.internal",
+		"APP_DATABASE_PASSWORD":      "s3cret",
+		"APP_DATABASE_PORT":          "5433",
+		"APP_DATABASE_QUERY_TIMEOUT": "250ms",
+		"APP_TAGS":                   `["a","b"]`,
+		"APP_INTERNAL":               "ignored",
+	}
+	lookup := func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+
+	var cfg config
+	require.NoError(t, ApplyEnv(&cfg, "APP", lookup))
+	assert.Equal(t, config{
+		Dev: true,
+		Database: database{
+			Host:     "db.internal",
+			Port:     5433,
+			Password: "s3cret",
+			Timeout:  jsontime.Duration(250 * time.Millisecond),
+		},
+		Tags: []string{"a", "b"},
+	}, cfg)
+
+	env = map[string]string{"APP_DATABASE_PORT": "70000", "APP_DEV": "maybe"}
+	err := ApplyEnv(&cfg, "APP", lookup)
+	assert.ErrorIs(t, err, ErrInvalidValue)
+	assert.ErrorContains(t, err, "APP_DATABASE_PORT")
+	assert.ErrorContains(t, err, "APP_DEV")
+}
+
+func TestOverride(t *testing.T) {
+	var cfg config
+	require.NoError(t, Override(&cfg, "database.host=a=b"))
+	require.NoError(t, Override(&cfg, `database.query_timeout="1m"`))
+	assert.Equal(t, "a=b", cfg.Database.Host)
+	assert.Equal(t, jsontime.Duration(time.Minute), cfg.Database.Timeout)
+	require.NoError(t, Override(&cfg, "tags=a,b"))
+	assert.Equal(t, []string{"a", "b"}, cfg.Tags)
+
+	assert.ErrorIs(t, Override(&cfg, "database.name=x"), ErrUnknownSetting)
+	assert.ErrorIs(t, Override(&cfg, "database"), ErrInvalidValue)
+	assert.ErrorIs(t, Override(&cfg, "database.port=x"), ErrInvalidValue)
+}
+
+func TestMasked(t *testing.T) {
+	cfg := config{Database: database{Host: "db", Password: "s3cret"}}
+	masked, err := Masked(&cfg, func(key string) bool { return key == "password" }, "***")
+	require.NoError(t, err)
+	assert.Equal(t, "***", masked["database"].(map[string]any)["password"])
+	assert.Equal(t, "db", masked["database"].(map[string]any)["host"])
+	assert.Equal(t, "s3cret", cfg.Database.Password)
+
+	cfg.Tags = []string{"a", ""}
+	masked, err = Masked(&cfg, func(key string) bool { return key == "tags" }, "***")
+	require.NoError(t, err)
+	assert.Equal(t, []any{"***", ""}, masked["tags"])
+
+	masked, err = Masked(&config{}, func(key string) bool { return key == "password" }, "***")
+	require.NoError(t, err)
+	assert.Equal(t, "", masked["database"].(map[string]any)["password"], "empty secrets are shown")
+}
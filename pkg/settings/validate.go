@@ -0,0 +1,137 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidSettings = errors.New("invalid settings")
+	ErrRequired        = errors.New("required")
+	ErrInvalidAddress  = errors.New("invalid address")
+	ErrInvalidPort     = errors.New("invalid port")
+)
+
+// FieldError is a problem with a setting, identified by the JSON names of
+// its path joined by dots
+type FieldError struct {
+	Path string
+	Err  error
+}
+
+func (e *FieldError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError holds every problem found in the settings, so all of them
+// are fixed at once. It matches ErrInvalidSettings and the errors of its
+// problems.
+type ValidationError struct {
+	Problems []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	var sb strings.Builder
+	sb.WriteString(ErrInvalidSettings.Error())
+	sb.WriteString(":")
+	for _, problem := range e.Problems {
+		sb.WriteString("\n  - ")
+		sb.WriteString(problem.Error())
+	}
+	return sb.String()
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidSettings
+}
+
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Problems))
+	for i, problem := range e.Problems {
+		errs[i] = problem
+	}
+	return errs
+}
+
+// Problems collects the problems found by a Validate method
+type Problems struct {
+	problems []*FieldError
+}
+
+// Add records a problem of the setting at path
+func (p *Problems) Add(path string, err error) {
+	p.problems = append(p.problems, &FieldError{Path: path, Err: err})
+}
+
+// Addf records a problem of the setting at path, formatted like fmt.Errorf
+func (p *Problems) Addf(path string, format string, args ...any) {
+	p.Add(path, fmt.Errorf(format, args...))
+}
+
+// Merge records the problems of a nested struct validated by err, when not
+// nil, prefixing their paths with the name of the struct
+func (p *Problems) Merge(prefix string, err error) {
+	if err == nil {
+		return
+	}
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		p.Add(prefix, err)
+		return
+	}
+	for _, problem := range verr.Problems {
+		p.Add(prefix+"."+problem.Path, problem.Err)
+	}
+}
+
+// Required records ErrRequired when value is empty
+func (p *Problems) Required(path, value string) {
+	if value == "" {
+		p.Add(path, ErrRequired)
+	}
+}
+
+// Address records ErrInvalidAddress when value is not a host:port address,
+// the host may be empty to listen on every interface
+func (p *Problems) Address(path, value string) {
+	_, port, err := net.SplitHostPort(value)
+	if err != nil {
+		p.Addf(path, "%w: %q, expected host:port like :8080", ErrInvalidAddress, value)
+		return
+	}
+	if n, err := strconv.ParseUint(port, 10, 16); err != nil || n == 0 {
+		p.Addf(path, "%w: %q, expected a port from 1 to 65535", ErrInvalidAddress, value)
+	}
+}
+
+// Port records ErrInvalidPort when value is not from 1 to 65535
+func (p *Problems) Port(path string, value int) {
+	if value < 1 || value > 65535 {
+		p.Addf(path, "%w: %d, expected 1 to 65535", ErrInvalidPort, value)
+	}
+}
+
+// OneOf records err when value is not one of the allowed values
+func (p *Problems) OneOf(path, value string, err error, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	p.Addf(path, "%w: %q, expected one of %s", err, value, strings.Join(allowed, ", "))
+}
+
+// Err returns a *ValidationError with the problems, nil without problems
+func (p *Problems) Err() error {
+	if len(p.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: p.problems}
+}
@@ -0,0 +1,67 @@
+package settings
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errInvalidColor = errors.New("invalid color")
+
+type section struct {
+	Address string
+	Port    int
+}
+
+func (s section) Validate() error {
+	var p Problems
+	p.Address("address", s.Address)
+	p.Port("port", s.Port)
+	return p.Err()
+}
+
+func TestProblems(t *testing.T) {
+	var p Problems
+	assert.NoError(t, p.Err())
+
+	p.Required("name", "")
+	p.Required("title", "set")
+	p.OneOf("color", "pink", errInvalidColor, "red", "blue")
+	p.Merge("server", section{Address: "localhost", Port: 0}.Validate())
+	p.Merge("admin", section{Address: "127.0.0.1:9090", Port: 9090}.Validate())
+	p.Merge("other", errors.New("broken"))
+
+	err := p.Err()
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrInvalidSettings)
+	assert.ErrorIs(t, err, ErrRequired)
+	assert.ErrorIs(t, err, errInvalidColor)
+	assert.ErrorIs(t, err, ErrInvalidAddress)
+	assert.ErrorIs(t, err, ErrInvalidPort)
+	assert.Equal(t, `invalid settings:
+  - name: required
+  - color: invalid color: "pink", expected one of red, blue
+  - server.address: invalid address: "localhost", expected host:port like :8080
+  - server.port: invalid port: 0, expected 1 to 65535
+  - other: broken`, err.Error())
+}
+
+func TestAddress(t *testing.T) {
+	for address, valid := range map[string]bool{
+		":8080":          true,
+		"127.0.0.1:9090": true,
+		"[::1]:443":      true,
+		"example.com:80": true,
+		"8080":           false,
+		":0":             false,
+		":http":          false,
+		":70000":         false,
+		"":               false,
+	} {
+		var p Problems
+		p.Address("address", address)
+		assert.Equal(t, valid, p.Err() == nil, address)
+	}
+}
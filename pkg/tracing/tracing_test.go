@@ -0,0 +1,97 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans installs a tracer provider keeping the ended spans in memory
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestSetup(t *testing.T) {
+	_, err := Setup(context.Background(), Config{Exporter: "zipkin"}, nil)
+	assert.ErrorIs(t, err, ErrInvalidExporter)
+
+	shutdown, err := Setup(context.Background(), Config{}, nil)
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	var out bytes.Buffer
+	shutdown, err = Setup(context.Background(), Config{Exporter: ExporterStdout}, &out)
+	require.NoError(t, err)
+	_, span := otel.Tracer("test").Start(context.Background(), "stdout-span")
+	span.End()
+	require.NoError(t, shutdown(context.Background()))
+	assert.Contains(t, out.String(), "stdout-span")
+	assert.Contains(t, out.String(), ServiceName)
+}
+
+func TestMiddleware(t *testing.T) {
+	recorder := recordSpans(t)
+	// installs the propagator only
+	_, err := Setup(context.Background(), Config{}, nil)
+	require.NoError(t, err)
+
+	var handlerSpan trace.SpanContext
+	router := chi.NewRouter()
+	router.Use(Middleware)
+	router.Get("/u/{username}", func(w http.ResponseWriter, r *http.Request) {
+		handlerSpan = trace.SpanContextFromContext(r.Context())
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/u/alice", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	routed := spans[0]
+	assert.Equal(t, "GET /u/{username}", routed.Name())
+	assert.Equal(t, trace.SpanKindServer, routed.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", routed.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", routed.Parent().SpanID().String())
+	assert.Equal(t, routed.SpanContext(), handlerSpan)
+	assert.Equal(t, codes.Error, routed.Status().Code)
+	attrs := attributes(routed)
+	assert.Equal(t, "/u/{username}", attrs[semconv.HTTPRouteKey].AsString())
+	assert.Equal(t, int64(http.StatusInternalServerError), attrs[semconv.HTTPResponseStatusCodeKey].AsInt64())
+
+	unmatched := spans[1]
+	assert.Equal(t, "GET", unmatched.Name())
+	assert.False(t, unmatched.Parent().IsValid())
+	assert.Equal(t, int64(http.StatusNotFound), attributes(unmatched)[semconv.HTTPResponseStatusCodeKey].AsInt64())
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/twsm000/lenslocked/models/database"
+	"github.com/twsm000/lenslocked/models/entities"
+	"github.com/twsm000/lenslocked/models/repositories"
+	"github.com/twsm000/lenslocked/models/repositories/postgresrepo"
+	"github.com/twsm000/lenslocked/models/repositories/sqliterepo"
+	"github.com/twsm000/lenslocked/models/services"
+)
+
+// Repositories are the repositories on the database of the configured driver
+type Repositories struct {
+	Users          repositories.User
+	Sessions       repositories.Session
+	PasswordResets repositories.PasswordReset
+	Audit          repositories.Audit
+	Stats          repositories.Stats
+	UnitOfWork     repositories.UnitOfWork
+}
+
+// NewRepositories prepares the statements of the repositories of the driver
+// selected by config
+func NewRepositories(db *sql.DB, config database.Config, logger *slog.Logger) (*Repositories, error) {
+	if config.IsSQLite() {
+		return newSQLiteRepositories(&sqliterepo.DB{
+			DB:           db,
+			QueryTimeout: time.Duration(config.QueryTimeout),
+		}, logger)
+	}
+	return newPostgresRepositories(&postgresrepo.DB{
+		DB:           db,
+		QueryTimeout: time.Duration(config.QueryTimeout),
+	}, logger)
+}
+
+func newPostgresRepositories(db *postgresrepo.DB, logger *slog.Logger) (*Repositories, error) {
+	var repos Repositories
+	var err error
+	if repos.Users, err = postgresrepo.NewUserRepository(db); err != nil {
+		return nil, err
+	}
+	if repos.Sessions, err = postgresrepo.NewSessionRepository(db, logger); err != nil {
+		return nil, err
+	}
+	if repos.PasswordResets, err = postgresrepo.NewPasswordResetRepository(db, logger); err != nil {
+		return nil, err
+	}
+	if repos.Audit, err = postgresrepo.NewAuditRepository(db); err != nil {
+		return nil, err
+	}
+	if repos.Stats, err = postgresrepo.NewStatsRepository(db); err != nil {
+		return nil, err
+	}
+	if repos.UnitOfWork, err = postgresrepo.NewUnitOfWork(db, repos.Users, repos.Sessions, repos.PasswordResets, repos.Audit); err != nil {
+		return nil, err
+	}
+	return &repos, nil
+}
+
+func newSQLiteRepositories(db *sqliterepo.DB, logger *slog.Logger) (*Repositories, error) {
+	var repos Repositories
+	var err error
+	if repos.Users, err = sqliterepo.NewUserRepository(db); err != nil {
+		return nil, err
+	}
+	if repos.Sessions, err = sqliterepo.NewSessionRepository(db, logger); err != nil {
+		return nil, err
+	}
+	if repos.PasswordResets, err = sqliterepo.NewPasswordResetRepository(db, logger); err != nil {
+		return nil, err
+	}
+	if repos.Audit, err = sqliterepo.NewAuditRepository(db); err != nil {
+		return nil, err
+	}
+	if repos.Stats, err = sqliterepo.NewStatsRepository(db); err != nil {
+		return nil, err
+	}
+	if repos.UnitOfWork, err = sqliterepo.NewUnitOfWork(db, repos.Users, repos.Sessions, repos.PasswordResets, repos.Audit); err != nil {
+		return nil, err
+	}
+	return &repos, nil
+}
+
+func (r *Repositories) Close() error {
+	return errors.Join(
+		r.Users.Close(),
+		r.Sessions.Close(),
+		r.PasswordResets.Close(),
+		r.Audit.Close(),
+		r.Stats.Close(),
+	)
+}
+
+func newPasswordResetService(env *EnvConfig, repos *Repositories, logger *slog.Logger) services.PasswordReset {
+	return services.NewPasswordReset(
+		env.Session.TokenSize,
+		entities.DefaultPasswordResetDuration, // TODO: load this value from env file
+		repos.PasswordResets,
+		repos.Users,
+		repos.UnitOfWork,
+		logger,
+	)
+}
+
+// newAdminService is shared by the server and the CLI commands
+func newAdminService(env *EnvConfig, repos *Repositories, logger *slog.Logger) services.Admin {
+	return services.NewAdmin(
+		repos.Users,
+		repos.Sessions,
+		repos.Stats,
+		services.NewAuditLogger(repos.Audit, logger),
+		newPasswordResetService(env, repos, logger),
+		repos.UnitOfWork,
+	)
+}
@@ -2,5 +2,9 @@ package templates
 
 import "embed"
 
+// Dir is the templates directory relative to the project root, used to
+// load the templates from disk in development mode.
+const Dir = "templates"
+
 //go:embed *
 var FS embed.FS
@@ -1,61 +1,409 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
-	"os"
+	"errors"
+	"fmt"
+	"log/slog"
 	"path/filepath"
+	"time"
 
-	"github.com/twsm000/lenslocked/models/database/postgres"
+	"github.com/twsm000/lenslocked/models/database"
+	"github.com/twsm000/lenslocked/models/entities"
 	"github.com/twsm000/lenslocked/models/services"
+	"github.com/twsm000/lenslocked/pkg/health"
+	"github.com/twsm000/lenslocked/pkg/jsontime"
+	"github.com/twsm000/lenslocked/pkg/logging"
+	"github.com/twsm000/lenslocked/pkg/settings"
+	"github.com/twsm000/lenslocked/pkg/tracing"
+)
+
+var (
+	ErrInvalidCSRFKey   = errors.New("invalid CSRF key")
+	ErrInvalidTokenSize = errors.New("invalid token size")
+	ErrInvalidLimit     = errors.New("invalid limit")
 )
 
 type EnvConfig struct {
+	// Dev enables the development mode, reloading the templates from disk
+	Dev        bool                `json:"dev"`
 	CSRF       CSRF                `json:"csrf"`
-	DBConfig   postgres.Config     `json:"database"`
+	DBConfig   database.Config     `json:"database"`
+	Health     Health              `json:"health"`
+	Log        logging.Config      `json:"log"`
 	Server     Server              `json:"server"`
 	Session    Session             `json:"session"`
 	SMTPConfig services.SMTPConfig `json:"smtp"`
+	Storage    Storage             `json:"storage"`
+	Tracing    tracing.Config      `json:"tracing"`
+}
+
+// LogValue logs the settings with every secret redacted
+func (env *EnvConfig) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Bool("dev", env.Dev),
+		slog.Any("csrf", env.CSRF),
+		slog.Any("database", env.DBConfig),
+		slog.Group("health",
+			"timeout", env.Health.Timeout.String(),
+			"check_smtp", env.Health.CheckSMTP,
+			"drain_delay", env.Health.DrainDelay.String(),
+		),
+		slog.Group("log", "format", env.Log.Format, "level", env.Log.Level),
+		slog.Any("server", env.Server),
+		slog.Group("session", "token_size", env.Session.TokenSize),
+		slog.Any("smtp", env.SMTPConfig),
+		slog.Group("storage", "avatars_dir", env.Storage.AvatarsDir),
+		slog.Any("tracing", env.Tracing),
+	)
 }
 
-func LoadEnvSettings(fpath, dbDriver string) (*EnvConfig, error) {
-	env := EnvConfig{
-		DBConfig: postgres.Config{
-			Driver: dbDriver,
+// Validate checks every section, returning all the problems at once in a
+// *settings.ValidationError. Possible errors:
+//   - settings.ErrInvalidSettings
+func (env *EnvConfig) Validate() error {
+	var p settings.Problems
+	p.Merge("csrf", env.CSRF.Validate())
+	p.Merge("database", env.DBConfig.Validate())
+	p.Merge("health", env.Health.Validate())
+	p.Merge("log", env.Log.Validate())
+	p.Merge("server", env.Server.Validate())
+	p.Merge("session", env.Session.Validate())
+	p.Merge("smtp", env.SMTPConfig.Validate())
+	p.Required("storage.avatars_dir", env.Storage.AvatarsDir)
+	p.Merge("tracing", env.Tracing.Validate())
+	return p.Err()
+}
+
+// EnvPrefix names the environment variables of the settings, like
+// LENSLOCKED_DATABASE_PASSWORD
+const EnvPrefix = "LENSLOCKED"
+
+// DefaultEnvConfig returns the settings used when nothing overrides them
+func DefaultEnvConfig() EnvConfig {
+	return EnvConfig{
+		DBConfig: database.Config{
+			Driver:       database.DriverPostgres,
+			Port:         5432,
+			Path:         filepath.Join("data", "lenslocked.db"),
+			QueryTimeout: jsontime.Duration(5 * time.Second),
+		},
+		Health: Health{
+			Timeout: jsontime.Duration(health.DefaultTimeout),
+		},
+		Log: logging.Config{
+			Format: logging.FormatText,
+			Level:  "info",
+		},
+		Server: Server{
+			Address:           ":8080",
+			ReadHeaderTimeout: jsontime.Duration(5 * time.Second),
+			ReadTimeout:       jsontime.Duration(30 * time.Second),
+			WriteTimeout:      jsontime.Duration(60 * time.Second),
+			IdleTimeout:       jsontime.Duration(2 * time.Minute),
+			ShutdownTimeout:   jsontime.Duration(10 * time.Second),
+			MaxHeaderBytes:    64 << 10,
+			MaxBodyBytes:      64 << 10,
+			MaxUploadBytes:    8 << 20,
+			TLS: TLS{
+				ReloadInterval: jsontime.Duration(time.Minute),
+				HSTSMaxAge:     jsontime.Duration(365 * 24 * time.Hour),
+			},
+		},
+		Session: Session{
+			TokenSize: 64,
+		},
+		SMTPConfig: services.SMTPConfig{
+			Port: 587,
+		},
+		Storage: Storage{
+			AvatarsDir: filepath.Join("data", "avatars"),
+		},
+		Tracing: tracing.Config{
+			Exporter: tracing.ExporterNone,
 		},
 	}
+}
 
-	fpath, err := filepath.Abs(fpath)
-	if err != nil {
-		return nil, err
+// LoadEnvSettings layers the settings, each layer overriding the previous:
+// the defaults, the JSON file at fpath, which may have comments and is
+// skipped when fpath is empty, the LENSLOCKED_* environment variables and
+// the overrides, like database.port=5433. Then the secrets of the *_file
+// settings, like database.password_file, are read.
+func LoadEnvSettings(
+	fpath string,
+	lookupEnv func(key string) (string, bool),
+	overrides ...string) (*EnvConfig, error) {
+	/***************************************************/
+	env := DefaultEnvConfig()
+
+	if fpath != "" {
+		fpath, err := filepath.Abs(fpath)
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("Loading settings", "path", fpath)
+		if err := settings.LoadFile(fpath, &env); err != nil {
+			return nil, err
+		}
 	}
-	logInfo.Println("EnvSettingsFilePath:", fpath)
 
-	data, err := os.ReadFile(fpath)
-	if err != nil {
+	if err := settings.ApplyEnv(&env, EnvPrefix, lookupEnv); err != nil {
 		return nil, err
 	}
 
-	envData := bytes.NewBuffer(data)
-	decoder := json.NewDecoder(envData)
-	decoder.DisallowUnknownFields()
-	err = decoder.Decode(&env)
-	if err != nil {
+	for _, override := range overrides {
+		if err := settings.Override(&env, override); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := settings.ResolveFiles(&env); err != nil {
 		return nil, err
 	}
 
 	return &env, nil
 }
 
+// MaskedSettings returns the effective settings as a JSON object, with
+// the secrets masked like in the logs
+func MaskedSettings(env *EnvConfig) (map[string]any, error) {
+	return settings.Masked(env, logging.IsSensitive, logging.Redacted)
+}
+
+// CSRFKeySize is the size of the keys signing the CSRF cookie
+const CSRFKeySize = 32
+
 type CSRF struct {
-	Key    string `json:"key"`
-	Secure bool   `json:"secure"`
+	// Key signs the CSRF cookie. It is 32 bytes of text, or encoded like
+	// base64:... or hex:...
+	Key string `json:"key"`
+	// KeyFile is read into the Key, like a mounted secret
+	KeyFile string `json:"key_file"`
+	// PreviousKeys are the keys replaced by the Key, the cookies they signed
+	// are still accepted so a key rotation keeps the open forms working
+	PreviousKeys []string `json:"previous_keys"`
+	Secure       bool     `json:"secure"`
+}
+
+// Keys returns the decoded keys, the Key first. Possible errors:
+//   - settings.ErrInvalidKey
+func (c CSRF) Keys() ([][]byte, error) {
+	keys := make([][]byte, 0, 1+len(c.PreviousKeys))
+	for _, key := range append([]string{c.Key}, c.PreviousKeys...) {
+		decoded, err := settings.DecodeKey(key)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, decoded)
+	}
+	return keys, nil
+}
+
+// Validate possible errors:
+//   - settings.ErrInvalidSettings {ErrInvalidCSRFKey, settings.ErrInvalidKey}
+func (c CSRF) Validate() error {
+	var p settings.Problems
+	checkKey := func(path, key string) {
+		decoded, err := settings.DecodeKey(key)
+		if err != nil {
+			p.Add(path, err)
+		} else if len(decoded) != CSRFKeySize {
+			p.Addf(path, "%w: %d bytes, expected %d", ErrInvalidCSRFKey, len(decoded), CSRFKeySize)
+		}
+	}
+	checkKey("key", c.Key)
+	for i, key := range c.PreviousKeys {
+		checkKey(fmt.Sprintf("previous_keys[%d]", i), key)
+	}
+	return p.Err()
+}
+
+func (c CSRF) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Any("key", logging.Secret(c.Key)),
+		slog.String("key_file", c.KeyFile),
+		slog.Int("previous_keys_count", len(c.PreviousKeys)),
+		slog.Bool("secure", c.Secure),
+	)
+}
+
+type Health struct {
+	// Timeout bounds the dependency checks of the readiness endpoint and the
+	// database reads of the metrics
+	Timeout jsontime.Duration `json:"timeout"`
+	// CheckSMTP reports the SMTP server reachability on the readiness
+	// endpoint, without failing it
+	CheckSMTP bool `json:"check_smtp"`
+	// DrainDelay is how long the readiness fails before the servers stop
+	// accepting connections on shutdown, so the load balancers drain them
+	DrainDelay jsontime.Duration `json:"drain_delay"`
+}
+
+// Validate possible errors:
+//   - settings.ErrInvalidSettings
+func (h Health) Validate() error {
+	var p settings.Problems
+	if h.Timeout < 0 {
+		p.Addf("timeout", "must not be negative")
+	}
+	if h.DrainDelay < 0 {
+		p.Addf("drain_delay", "must not be negative")
+	}
+	return p.Err()
 }
 
 type Server struct {
 	Address string `json:"address"`
+	// AdminAddress serves the operational endpoints, like the metrics, and
+	// must not be publicly reachable. Empty disables the admin server.
+	AdminAddress string `json:"admin_address"`
+	// ReadHeaderTimeout, ReadTimeout, WriteTimeout and IdleTimeout are the
+	// http.Server timeouts, so the slow clients cannot hold the connections
+	ReadHeaderTimeout jsontime.Duration `json:"read_header_timeout"`
+	ReadTimeout       jsontime.Duration `json:"read_timeout"`
+	WriteTimeout      jsontime.Duration `json:"write_timeout"`
+	IdleTimeout       jsontime.Duration `json:"idle_timeout"`
+	// ShutdownTimeout is how long the requests in flight are waited for
+	// when the process is interrupted
+	ShutdownTimeout jsontime.Duration `json:"shutdown_timeout"`
+	MaxHeaderBytes  int               `json:"max_header_bytes"`
+	// MaxBodyBytes limits the request bodies, like the forms and the JSON
+	// of the API, and MaxUploadBytes the ones carrying files, like the
+	// avatar upload
+	MaxBodyBytes   int64 `json:"max_body_bytes"`
+	MaxUploadBytes int64 `json:"max_upload_bytes"`
+	TLS            TLS   `json:"tls"`
+}
+
+func (s Server) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("address", s.Address),
+		slog.String("admin_address", s.AdminAddress),
+		slog.String("read_header_timeout", s.ReadHeaderTimeout.String()),
+		slog.String("read_timeout", s.ReadTimeout.String()),
+		slog.String("write_timeout", s.WriteTimeout.String()),
+		slog.String("idle_timeout", s.IdleTimeout.String()),
+		slog.String("shutdown_timeout", s.ShutdownTimeout.String()),
+		slog.Int("max_header_bytes", s.MaxHeaderBytes),
+		slog.Int64("max_body_bytes", s.MaxBodyBytes),
+		slog.Int64("max_upload_bytes", s.MaxUploadBytes),
+		slog.Any("tls", s.TLS),
+	)
+}
+
+// TLS serves the Address over HTTPS when the certificate files are set
+type TLS struct {
+	// CertFile and KeyFile are PEM encoded, they are reloaded on SIGHUP
+	// and when they change
+	CertFile string `json:"cert_file"`
+	KeyFile  string `json:"key_file"`
+	// ReloadInterval is how often the files are checked for changes, zero
+	// reloads them on SIGHUP only
+	ReloadInterval jsontime.Duration `json:"reload_interval"`
+	// RedirectAddress serves plain HTTP redirecting to HTTPS, like :80.
+	// Empty disables it.
+	RedirectAddress string `json:"redirect_address"`
+	// HSTSMaxAge is how long the browsers use only HTTPS on the host, zero
+	// disables the Strict-Transport-Security header
+	HSTSMaxAge            jsontime.Duration `json:"hsts_max_age"`
+	HSTSIncludeSubdomains bool              `json:"hsts_include_subdomains"`
+}
+
+// Enabled reports if the server is served over HTTPS
+func (t TLS) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != ""
+}
+
+func (t TLS) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("cert_file", t.CertFile),
+		slog.String("key_file", t.KeyFile),
+		slog.String("reload_interval", t.ReloadInterval.String()),
+		slog.String("redirect_address", t.RedirectAddress),
+		slog.String("hsts_max_age", t.HSTSMaxAge.String()),
+		slog.Bool("hsts_include_subdomains", t.HSTSIncludeSubdomains),
+	)
+}
+
+// Validate checks that both files are set, or none of them. Possible errors:
+//   - settings.ErrInvalidSettings {settings.ErrRequired, settings.ErrInvalidAddress}
+func (t TLS) Validate() error {
+	var p settings.Problems
+	if t.Enabled() {
+		p.Required("cert_file", t.CertFile)
+		p.Required("key_file", t.KeyFile)
+	} else if t.RedirectAddress != "" {
+		p.Addf("redirect_address", "requires the cert_file and the key_file")
+	}
+	if t.RedirectAddress != "" {
+		p.Address("redirect_address", t.RedirectAddress)
+	}
+	if t.ReloadInterval < 0 {
+		p.Addf("reload_interval", "must not be negative, 0s reloads on SIGHUP only")
+	}
+	if t.HSTSMaxAge < 0 {
+		p.Addf("hsts_max_age", "must not be negative, 0s disables it")
+	}
+	return p.Err()
+}
+
+// Validate checks the addresses, the admin address is optional and must
+// not be the public one, the timeouts and the limits. Possible errors:
+//   - settings.ErrInvalidSettings {settings.ErrInvalidAddress, ErrInvalidLimit}
+func (s Server) Validate() error {
+	var p settings.Problems
+	p.Address("address", s.Address)
+	if s.AdminAddress != "" {
+		p.Address("admin_address", s.AdminAddress)
+		if s.AdminAddress == s.Address {
+			p.Addf("admin_address", "%w: %q, must not be the public address", settings.ErrInvalidAddress, s.AdminAddress)
+		}
+	}
+	for _, timeout := range []struct {
+		path  string
+		value jsontime.Duration
+	}{
+		{"read_header_timeout", s.ReadHeaderTimeout},
+		{"read_timeout", s.ReadTimeout},
+		{"write_timeout", s.WriteTimeout},
+		{"idle_timeout", s.IdleTimeout},
+		{"shutdown_timeout", s.ShutdownTimeout},
+	} {
+		if timeout.value <= 0 {
+			p.Addf(timeout.path, "%w: %s, must be positive", ErrInvalidLimit, timeout.value)
+		}
+	}
+	if s.MaxHeaderBytes <= 0 {
+		p.Addf("max_header_bytes", "%w: %d, must be positive", ErrInvalidLimit, s.MaxHeaderBytes)
+	}
+	if s.MaxBodyBytes <= 0 {
+		p.Addf("max_body_bytes", "%w: %d, must be positive", ErrInvalidLimit, s.MaxBodyBytes)
+	}
+	if s.MaxUploadBytes < s.MaxBodyBytes {
+		p.Addf("max_upload_bytes", "%w: %d, must be at least the max_body_bytes", ErrInvalidLimit, s.MaxUploadBytes)
+	}
+	p.Merge("tls", s.TLS.Validate())
+	if s.TLS.RedirectAddress != "" && s.TLS.RedirectAddress == s.Address {
+		p.Addf("tls.redirect_address", "%w: %q, must not be the public address", settings.ErrInvalidAddress, s.TLS.RedirectAddress)
+	}
+	return p.Err()
 }
 
 type Session struct {
 	TokenSize int `json:"token_size"`
 }
+
+// Validate possible errors:
+//   - settings.ErrInvalidSettings {ErrInvalidTokenSize}
+func (s Session) Validate() error {
+	var p settings.Problems
+	if s.TokenSize < entities.MinBytesPerToken {
+		p.Addf("token_size", "%w: %d, expected at least %d bytes", ErrInvalidTokenSize, s.TokenSize, entities.MinBytesPerToken)
+	}
+	return p.Err()
+}
+
+type Storage struct {
+	// AvatarsDir is the directory the processed avatar images are stored
+	AvatarsDir string `json:"avatars_dir"`
+}
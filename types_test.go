@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twsm000/lenslocked/pkg/jsontime"
+	"github.com/twsm000/lenslocked/pkg/logging"
+	"github.com/twsm000/lenslocked/pkg/settings"
+)
+
+func lookupEnv(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+func TestEnvTemplateLoads(t *testing.T) {
+	env, err := LoadEnvSettings("env.json.template", lookupEnv(nil))
+	require.NoError(t, err)
+	assert.Equal(t, "sandbox.smtp.mailtrap.io", env.SMTPConfig.Host)
+	assert.Equal(t, "127.0.0.1:9090", env.Server.AdminAddress)
+}
+
+func TestLoadEnvSettingsLayers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "env.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"database": {"host": "file-host", "user": "file-user"}, // comment
+		"server": {"address": ":9000"}
+	}`), 0o600))
+
+	env, err := LoadEnvSettings(path, lookupEnv(map[string]string{
+		"LENSLOCKED_DATABASE_USER":     "env-user",
+		"LENSLOCKED_DATABASE_PASSWORD": "env-secret",
+		"LENSLOCKED_SERVER_ADDRESS":    ":9001",
+	}), "server.address=:9002")
+	require.NoError(t, err)
+
+	assert.Equal(t, uint16(5432), env.DBConfig.Port, "default")
+	assert.Equal(t, jsontime.Duration(5*time.Second), env.DBConfig.QueryTimeout, "default")
+	assert.Equal(t, "file-host", env.DBConfig.Host, "file")
+	assert.Equal(t, "env-user", env.DBConfig.User, "environment")
+	assert.Equal(t, "env-secret", env.DBConfig.Password, "environment")
+	assert.Equal(t, ":9002", env.Server.Address, "override")
+
+	env, err = LoadEnvSettings("", lookupEnv(nil))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultEnvConfig(), *env)
+
+	_, err = LoadEnvSettings("", lookupEnv(map[string]string{"LENSLOCKED_SESSION_TOKEN_SIZE": "big"}))
+	assert.ErrorIs(t, err, settings.ErrInvalidValue)
+	_, err = LoadEnvSettings("", lookupEnv(nil), "session.size=1")
+	assert.ErrorIs(t, err, settings.ErrUnknownSetting)
+}
+
+func TestMaskedSettings(t *testing.T) {
+	env := DefaultEnvConfig()
+	env.CSRF.Key = "0123456789abcdef0123456789abcdef"
+	env.DBConfig.Password = "db-secret"
+	env.SMTPConfig.Username = "mailer"
+
+	masked, err := MaskedSettings(&env)
+	require.NoError(t, err)
+	assert.Equal(t, logging.Redacted, masked["csrf"].(map[string]any)["key"])
+	assert.Equal(t, logging.Redacted, masked["database"].(map[string]any)["password"])
+	assert.Equal(t, "", masked["smtp"].(map[string]any)["password"])
+	assert.Equal(t, "mailer", masked["smtp"].(map[string]any)["username"])
+	assert.Equal(t, "5s", masked["database"].(map[string]any)["query_timeout"])
+}
+
+func TestEnvConfigValidate(t *testing.T) {
+	env := DefaultEnvConfig()
+	env.CSRF.Key = "0123456789abcdef0123456789abcdef"
+	env.DBConfig.Host = "localhost"
+	env.DBConfig.User = "lenslocked"
+	env.DBConfig.Database = "lenslocked"
+	env.SMTPConfig.Host = "localhost"
+	require.NoError(t, env.Validate())
+
+	env.CSRF.Key = ""
+	env.DBConfig.SSLMode = "strict"
+	env.Log.Level = "verbose"
+	env.Server.AdminAddress = env.Server.Address
+	env.Session.TokenSize = 16
+	env.SMTPConfig.Username = "mailer"
+	env.Tracing.Exporter = "zipkin"
+
+	err := env.Validate()
+	assert.ErrorIs(t, err, settings.ErrInvalidSettings)
+	assert.ErrorIs(t, err, ErrInvalidCSRFKey)
+	assert.ErrorIs(t, err, ErrInvalidTokenSize)
+	var verr *settings.ValidationError
+	require.ErrorAs(t, err, &verr)
+	paths := []string{}
+	for _, problem := range verr.Problems {
+		paths = append(paths, problem.Path)
+	}
+	assert.Equal(t, []string{
+		"csrf.key",
+		"database.ssl_mode",
+		"log.level",
+		"server.admin_address",
+		"session.token_size",
+		"smtp.password",
+		"tracing.exporter",
+	}, paths)
+}
+
+func TestCSRFKeys(t *testing.T) {
+	csrf := CSRF{
+		Key:          "base64:" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0xff}, 32)),
+		PreviousKeys: []string{"hex:" + strings.Repeat("00", 32), strings.Repeat("k", 32)},
+	}
+	require.NoError(t, csrf.Validate())
+	keys, err := csrf.Keys()
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{
+		bytes.Repeat([]byte{0xff}, 32),
+		make([]byte, 32),
+		bytes.Repeat([]byte("k"), 32),
+	}, keys)
+
+	csrf.PreviousKeys = []string{"hex:00", "hex:zz"}
+	err = csrf.Validate()
+	assert.ErrorIs(t, err, ErrInvalidCSRFKey)
+	assert.ErrorIs(t, err, settings.ErrInvalidKey)
+	assert.ErrorContains(t, err, "previous_keys[0]: invalid CSRF key: 1 bytes, expected 32")
+}
+
+func TestLoadEnvSettingsSecretFiles(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "csrf_key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("hex:"+strings.Repeat("ab", 32)+"\n"), 0o600))
+	passwordFile := filepath.Join(dir, "db_password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("db-secret\n"), 0o600))
+
+	env, err := LoadEnvSettings("", lookupEnv(map[string]string{
+		"LENSLOCKED_CSRF_KEY_FILE":          keyFile,
+		"LENSLOCKED_CSRF_PREVIOUS_KEYS":     strings.Repeat("a", 32) + "," + strings.Repeat("b", 32),
+		"LENSLOCKED_DATABASE_PASSWORD_FILE": passwordFile,
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "db-secret", env.DBConfig.Password)
+	assert.Len(t, env.CSRF.PreviousKeys, 2)
+	keys, err := env.CSRF.Keys()
+	require.NoError(t, err)
+	assert.Equal(t, bytes.Repeat([]byte{0xab}, 32), keys[0])
+
+	masked, err := MaskedSettings(env)
+	require.NoError(t, err)
+	assert.Equal(t, []any{logging.Redacted, logging.Redacted}, masked["csrf"].(map[string]any)["previous_keys"])
+	assert.Equal(t, keyFile, masked["csrf"].(map[string]any)["key_file"])
+
+	_, err = LoadEnvSettings("", lookupEnv(map[string]string{
+		"LENSLOCKED_SMTP_PASSWORD":      "inline",
+		"LENSLOCKED_SMTP_PASSWORD_FILE": passwordFile,
+	}))
+	assert.ErrorIs(t, err, settings.ErrConflictingSettings)
+}
+
+func TestTLSValidate(t *testing.T) {
+	server := DefaultEnvConfig().Server
+	require.NoError(t, server.Validate())
+	assert.False(t, server.TLS.Enabled())
+
+	server.TLS.RedirectAddress = ":80"
+	assert.ErrorContains(t, server.Validate(), "tls.redirect_address: requires the cert_file and the key_file")
+
+	server.TLS.CertFile = "cert.pem"
+	err := server.Validate()
+	assert.ErrorIs(t, err, settings.ErrRequired)
+	assert.ErrorContains(t, err, "tls.key_file: required")
+
+	server.TLS.KeyFile = "key.pem"
+	require.NoError(t, server.Validate())
+	assert.True(t, server.TLS.Enabled())
+
+	server.TLS.RedirectAddress = server.Address
+	assert.ErrorIs(t, server.Validate(), settings.ErrInvalidAddress)
+}
+
+func TestServerValidate(t *testing.T) {
+	server := DefaultEnvConfig().Server
+	require.NoError(t, server.Validate())
+
+	server.ReadHeaderTimeout = 0
+	server.ShutdownTimeout = jsontime.Duration(-time.Second)
+	server.MaxHeaderBytes = 0
+	server.MaxUploadBytes = server.MaxBodyBytes - 1
+	err := server.Validate()
+	assert.ErrorIs(t, err, ErrInvalidLimit)
+	assert.ErrorContains(t, err, "read_header_timeout: invalid limit: 0s, must be positive")
+	assert.ErrorContains(t, err, "shutdown_timeout")
+	assert.ErrorContains(t, err, "max_header_bytes")
+	assert.ErrorContains(t, err, "max_upload_bytes")
+}
@@ -0,0 +1,91 @@
+package views
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/twsm000/lenslocked/models/contextutil"
+	"github.com/twsm000/lenslocked/models/httpll"
+	"github.com/twsm000/lenslocked/pkg/result"
+)
+
+const (
+	HeaderRequestID = "X-Request-Id"
+)
+
+type ErrorPageData struct {
+	StatusCode int    `json:"status"`
+	Title      string `json:"title"`
+	Message    string `json:"message"`
+	RequestID  string `json:"request_id,omitempty"`
+}
+
+// NewErrorPageData returns the texts displayed to the client for the status
+// code, translated to the client locale
+func NewErrorPageData(r *http.Request, status int) ErrorPageData {
+	localizer := result.ExtractValue(contextutil.GetLocalizer(r.Context()))
+	data := ErrorPageData{
+		StatusCode: status,
+		RequestID:  middleware.GetReqID(r.Context()),
+	}
+	title, message, ok := httpll.StatusMessageKeys(status)
+	if ok {
+		data.Title = localizer.T(title)
+	} else {
+		data.Title = title
+	}
+	data.Message = localizer.T(message)
+	return data
+}
+
+// ErrorPage renders error responses in place with the right status code,
+// as HTML for browsers or as JSON when the client accepts it.
+type ErrorPage struct {
+	tmpl *Template[ErrorPageData]
+}
+
+func (ep *ErrorPage) Render(w http.ResponseWriter, r *http.Request, status int) {
+	data := NewErrorPageData(r, status)
+	if data.RequestID != "" {
+		w.Header().Set(HeaderRequestID, data.RequestID)
+	}
+
+	if httpll.WantsJSON(r) {
+		ep.renderJSON(w, r, data)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := ep.tmpl.execute(&buf, r, data); err != nil {
+		contextutil.Logger(r.Context()).Error("Failed to execute error page template", "error", err)
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if _, err := io.Copy(w, &buf); err != nil {
+		contextutil.Logger(r.Context()).Error("Failed to send data to ResponseWriter", "error", err)
+	}
+}
+
+func (ep *ErrorPage) renderJSON(w http.ResponseWriter, r *http.Request, data ErrorPageData) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(data.StatusCode)
+	err := json.NewEncoder(w).Encode(struct {
+		Error ErrorPageData `json:"error"`
+	}{data})
+	if err != nil {
+		contextutil.Logger(r.Context()).Error("Failed to send data to ResponseWriter", "error", err)
+	}
+}
+
+// Handler returns an http.HandlerFunc that always renders the status code
+func (ep *ErrorPage) Handler(status int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ep.Render(w, r, status)
+	}
+}
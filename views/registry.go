@@ -0,0 +1,199 @@
+package views
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	LayoutsDir  = "layouts"
+	PartialsDir = "partials"
+	PagesDir    = "pages"
+
+	// ErrorPageName is the page used to render the error responses
+	ErrorPageName = "error"
+
+	templateExt = ".html"
+)
+
+var (
+	ErrPageNotFound        = errors.New("page not found")
+	ErrPageNotRegistered   = errors.New("page not registered")
+	ErrPageAlreadyExists   = errors.New("page already registered")
+	ErrUnexpectedPageType  = errors.New("unexpected page data type")
+	ErrPageValidationFails = errors.New("page validation fails")
+)
+
+// Registry composes the pages found in the Source by convention: every
+// "pages/<name>.html" is parsed together with the registry layout from
+// "layouts/<layout>.html" and all the "partials/*.html".
+//
+// Each page must be registered with the type of data it renders, so
+// Validate can check at startup that all of them execute with zero values.
+type Registry struct {
+	source    Source
+	layout    string
+	pages     map[string]bool
+	templates map[string]registeredPage
+	errorPage *ErrorPage
+}
+
+type registeredPage struct {
+	template any
+	validate func() error
+}
+
+// NewRegistry discovers the pages in the source and parses the error page,
+// used when any of the registered pages fails to execute.
+func NewRegistry(src Source, layout string) (*Registry, error) {
+	names, err := fs.Glob(src.FS, path.Join(PagesDir, "*"+templateExt))
+	if err != nil {
+		return nil, err
+	}
+
+	reg := Registry{
+		source:    src,
+		layout:    layout,
+		pages:     make(map[string]bool, len(names)),
+		templates: make(map[string]registeredPage, len(names)),
+	}
+	for _, name := range names {
+		reg.pages[strings.TrimSuffix(path.Base(name), templateExt)] = true
+	}
+
+	if !reg.pages[ErrorPageName] {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, ErrorPageName)
+	}
+	errorTmpl, err := register[ErrorPageData](&reg, ErrorPageName)
+	if err != nil {
+		return nil, err
+	}
+	reg.errorPage = &ErrorPage{
+		tmpl: errorTmpl,
+	}
+
+	return &reg, nil
+}
+
+// ErrorPage returns the page rendering the error responses
+func (reg *Registry) ErrorPage() *ErrorPage {
+	return reg.errorPage
+}
+
+// patterns returns the files composing the page, the layout first
+// because it is the template executed.
+func (reg *Registry) patterns(name string) []string {
+	return []string{
+		path.Join(LayoutsDir, reg.layout+templateExt),
+		path.Join(PartialsDir, "*"+templateExt),
+		path.Join(PagesDir, name+templateExt),
+	}
+}
+
+// Register declares the data type T rendered by the page and parses it.
+func Register[T any](reg *Registry, name string) error {
+	_, err := register[T](reg, name)
+	return err
+}
+
+func register[T any](reg *Registry, name string) (*Template[T], error) {
+	if !reg.pages[name] {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotFound, name)
+	}
+	if _, ok := reg.templates[name]; ok {
+		return nil, fmt.Errorf("%w: %s", ErrPageAlreadyExists, name)
+	}
+
+	tmpl, err := ParseFSTemplate[T](reg.errorPage, reg.source, reg.patterns(name)...)
+	if err != nil {
+		return nil, fmt.Errorf("page %s: %w", name, err)
+	}
+
+	reg.templates[name] = registeredPage{
+		template: tmpl,
+		validate: func() error {
+			var zero T
+			return tmpl.execute(io.Discard, newValidationRequest(), zero)
+		},
+	}
+	return tmpl, nil
+}
+
+// Lookup returns the page registered with the data type T
+func Lookup[T any](reg *Registry, name string) (*Template[T], error) {
+	page, ok := reg.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrPageNotRegistered, name)
+	}
+
+	tmpl, ok := page.template.(*Template[T])
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is %T, not %T", ErrUnexpectedPageType, name, page.template, tmpl)
+	}
+	return tmpl, nil
+}
+
+// MustLookup is like Lookup but panics if the page is not found
+func MustLookup[T any](reg *Registry, name string) *Template[T] {
+	tmpl, err := Lookup[T](reg, name)
+	if err != nil {
+		panic(err)
+	}
+	return tmpl
+}
+
+// Page is the name of a page declared with the data type T it renders, so
+// the page is registered and looked up with the same type.
+type Page[T any] string
+
+// Register declares the data type of the page and parses it
+func (p Page[T]) Register(reg *Registry) error {
+	return Register[T](reg, string(p))
+}
+
+// MustLookup returns the page registered, panicking if it is not
+func (p Page[T]) MustLookup(reg *Registry) *Template[T] {
+	return MustLookup[T](reg, string(p))
+}
+
+// Validate returns all the pages that are not registered or fail to
+// execute with the zero value of the registered data type.
+func (reg *Registry) Validate() error {
+	names := make([]string, 0, len(reg.pages))
+	for name := range reg.pages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		page, ok := reg.templates[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrPageNotRegistered, name))
+			continue
+		}
+		if err := page.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s: %w", ErrPageValidationFails, name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// newValidationRequest returns the request used to execute the pages
+// without any user, flash or CSRF token in its context.
+func newValidationRequest() *http.Request {
+	r := &http.Request{
+		Method: http.MethodGet,
+		URL:    &url.URL{Path: "/"},
+		Header: make(http.Header),
+	}
+	return r.WithContext(context.Background())
+}
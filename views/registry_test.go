@@ -0,0 +1,99 @@
+package views
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testPageData struct {
+	Email string
+}
+
+func newTestRegistry(t *testing.T, pages map[string]string) *Registry {
+	fsys := fstest.MapFS{
+		"layouts/main.html":   {Data: []byte(`<main>{{template "inner-body-page" .}}</main>`)},
+		"partials/title.html": {Data: []byte(`{{define "title"}}Title{{end}}`)},
+		"pages/error.html":    {Data: []byte(`{{define "inner-body-page"}}{{.Data.StatusCode}}{{end}}`)},
+	}
+	for name, content := range pages {
+		fsys["pages/"+name+".html"] = &fstest.MapFile{Data: []byte(content)}
+	}
+
+	reg, err := NewRegistry(EmbeddedSource(fsys), "main")
+	require.NoError(t, err)
+	return reg
+}
+
+func TestRegistryValidate(t *testing.T) {
+	t.Run("AllPagesRegisteredAndValid", func(t *testing.T) {
+		reg := newTestRegistry(t, map[string]string{
+			"home": `{{define "inner-body-page"}}{{template "title"}}{{end}}`,
+		})
+		require.NoError(t, Register[any](reg, "home"))
+		assert.NoError(t, reg.Validate())
+	})
+
+	t.Run("PageNotRegistered", func(t *testing.T) {
+		reg := newTestRegistry(t, map[string]string{
+			"home": `{{define "inner-body-page"}}Home{{end}}`,
+		})
+		assert.ErrorIs(t, reg.Validate(), ErrPageNotRegistered)
+	})
+
+	t.Run("PageFailsWithZeroValue", func(t *testing.T) {
+		reg := newTestRegistry(t, map[string]string{
+			"signin": `{{define "inner-body-page"}}{{.Data.Email}}{{end}}`,
+		})
+		require.NoError(t, Register[any](reg, "signin"))
+		assert.ErrorIs(t, reg.Validate(), ErrPageValidationFails)
+	})
+
+	t.Run("PageWithTypedData", func(t *testing.T) {
+		reg := newTestRegistry(t, map[string]string{
+			"signin": `{{define "inner-body-page"}}{{.Data.Email}}{{end}}`,
+		})
+		require.NoError(t, Register[testPageData](reg, "signin"))
+		assert.NoError(t, reg.Validate())
+	})
+}
+
+func TestRegistryRegister(t *testing.T) {
+	reg := newTestRegistry(t, map[string]string{
+		"home": `{{define "inner-body-page"}}Home{{end}}`,
+	})
+
+	assert.ErrorIs(t, Register[any](reg, "missing"), ErrPageNotFound)
+	require.NoError(t, Register[any](reg, "home"))
+	assert.ErrorIs(t, Register[any](reg, "home"), ErrPageAlreadyExists)
+}
+
+func TestRegistryLookup(t *testing.T) {
+	reg := newTestRegistry(t, map[string]string{
+		"signin": `{{define "inner-body-page"}}{{.Data.Email}}{{end}}`,
+	})
+	require.NoError(t, Register[testPageData](reg, "signin"))
+
+	tmpl, err := Lookup[testPageData](reg, "signin")
+	assert.NoError(t, err)
+	assert.NotNil(t, tmpl)
+
+	_, err = Lookup[any](reg, "signin")
+	assert.ErrorIs(t, err, ErrUnexpectedPageType)
+
+	_, err = Lookup[any](reg, "home")
+	assert.ErrorIs(t, err, ErrPageNotRegistered)
+}
+
+func TestPage(t *testing.T) {
+	reg := newTestRegistry(t, map[string]string{
+		"signin": `{{define "inner-body-page"}}{{.Data.Email}}{{end}}`,
+	})
+	page := Page[testPageData]("signin")
+	require.NoError(t, page.Register(reg))
+	assert.NoError(t, reg.Validate())
+	assert.NotNil(t, page.MustLookup(reg))
+	assert.Panics(t, func() { Page[any]("signin").MustLookup(reg) })
+}
@@ -0,0 +1,59 @@
+package views
+
+import (
+	"io/fs"
+	"os"
+	"time"
+)
+
+// Source is where the template files are read from.
+type Source struct {
+	FS fs.FS
+
+	// Reload parses the templates again whenever one of their files
+	// changes. It is meant for development only, since every execution
+	// checks the files modification time.
+	Reload bool
+}
+
+// EmbeddedSource returns a Source for templates embedded in the binary
+func EmbeddedSource(fsys fs.FS) Source {
+	return Source{FS: fsys}
+}
+
+// DirSource returns a Source reading the templates from the directory on
+// disk and reloading them when the files change.
+func DirSource(dir string) Source {
+	return Source{FS: os.DirFS(dir), Reload: true}
+}
+
+// modTimes returns the modification time of every file matched by the patterns
+func (s Source) modTimes(patterns ...string) (map[string]time.Time, error) {
+	times := make(map[string]time.Time)
+	for _, pattern := range patterns {
+		matches, err := fs.Glob(s.FS, pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			info, err := fs.Stat(s.FS, match)
+			if err != nil {
+				return nil, err
+			}
+			times[match] = info.ModTime()
+		}
+	}
+	return times, nil
+}
+
+func changed(before, after map[string]time.Time) bool {
+	if len(before) != len(after) {
+		return true
+	}
+	for name, modTime := range after {
+		if !before[name].Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
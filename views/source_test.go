@@ -0,0 +1,48 @@
+package views
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirSourceReloadsChangedTemplates(t *testing.T) {
+	dir := t.TempDir()
+	page := filepath.Join(dir, "page.html")
+	require.NoError(t, os.WriteFile(page, []byte(`Hello {{.Data}}`), 0o644))
+
+	tmpl, err := ParseFSTemplate[string](nil, DirSource(dir), "page.html")
+	require.NoError(t, err)
+
+	render := func() string {
+		w := httptest.NewRecorder()
+		tmpl.Execute(w, httptest.NewRequest(http.MethodGet, "/", nil), "world")
+		return w.Body.String()
+	}
+	assert.Equal(t, "Hello world", render())
+
+	require.NoError(t, os.WriteFile(page, []byte(`Bye {{.Data}}`), 0o644))
+	modTime := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(page, modTime, modTime))
+	assert.Equal(t, "Bye world", render())
+}
+
+func TestEmbeddedSourceDoesNotReload(t *testing.T) {
+	dir := t.TempDir()
+	page := filepath.Join(dir, "page.html")
+	require.NoError(t, os.WriteFile(page, []byte(`Hello {{.Data}}`), 0o644))
+
+	tmpl, err := ParseFSTemplate[string](nil, EmbeddedSource(os.DirFS(dir)), "page.html")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(page, []byte(`Bye {{.Data}}`), 0o644))
+
+	w := httptest.NewRecorder()
+	tmpl.Execute(w, httptest.NewRequest(http.MethodGet, "/", nil), "world")
+	assert.Equal(t, "Hello world", w.Body.String())
+}
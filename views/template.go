@@ -2,18 +2,21 @@ package views
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"html/template"
 	"io"
-	"io/fs"
-	"log"
 	"net/http"
+	"path"
+	"sync"
+	"time"
 
 	"github.com/gorilla/csrf"
 	"github.com/twsm000/lenslocked/models/contextutil"
 	"github.com/twsm000/lenslocked/models/entities"
 	"github.com/twsm000/lenslocked/models/httpll"
+	"github.com/twsm000/lenslocked/pkg/i18n"
 	"github.com/twsm000/lenslocked/pkg/result"
 )
 
@@ -21,16 +24,33 @@ var (
 	ErrNotImplemented = errors.New("not implemented")
 )
 
-func ParseFSTemplate[T any](logError *log.Logger, fs fs.FS, pattern ...string) (*Template[T], error) {
-	tmpl := template.New(pattern[0])
-	tmpl, err := tmpl.ParseFS(fs, pattern...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse fs template: %w", err)
+// ParseFSTemplate parses the template files matched by the patterns. When the
+// template fails to execute, errorPage is used to render the internal server
+// error response.
+func ParseFSTemplate[T any](errorPage *ErrorPage, src Source, pattern ...string) (*Template[T], error) {
+	t := Template[T]{
+		errorPage: errorPage,
+		source:    src,
+		patterns:  pattern,
+	}
+	if err := t.parse(); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// placeholderFuncs are available while parsing the templates, each execution
+// replaces them by the client localizer functions (see localizedFuncs).
+var placeholderFuncs = template.FuncMap{
+	"T": func(key string, args ...any) string { return key },
+	"N": func(key string, count int, args ...any) string { return key },
+}
+
+func localizedFuncs(l *i18n.Localizer) template.FuncMap {
+	return template.FuncMap{
+		"T": l.T,
+		"N": l.N,
 	}
-	return &Template[T]{
-		logError: logError,
-		htmlTmpl: tmpl,
-	}, nil
 }
 
 type templateData[T any] struct {
@@ -38,39 +58,178 @@ type templateData[T any] struct {
 	User      *entities.User
 	Data      T
 	Errors    []string
+	Flashes   []httpll.Flash
+	Locale    string
+	Locales   []string
 }
 type Template[T any] struct {
-	htmlTmpl *template.Template
-	logError *log.Logger
+	mu        sync.RWMutex
+	htmlTmpl  *template.Template
+	modTimes  map[string]time.Time
+	source    Source
+	patterns  []string
+	errorPage *ErrorPage
+}
+
+func (t *Template[T]) parse() error {
+	var modTimes map[string]time.Time
+	if t.source.Reload {
+		var err error
+		if modTimes, err = t.source.modTimes(t.patterns...); err != nil {
+			return fmt.Errorf("failed to stat fs template: %w", err)
+		}
+	}
+
+	// ParseFS names the templates after the file base name
+	tmpl := template.New(path.Base(t.patterns[0])).Funcs(placeholderFuncs)
+	tmpl, err := tmpl.ParseFS(t.source.FS, t.patterns...)
+	if err != nil {
+		return fmt.Errorf("failed to parse fs template: %w", err)
+	}
+
+	t.htmlTmpl = tmpl
+	t.modTimes = modTimes
+	return nil
 }
 
+// template returns the parsed template, parsing it again when the
+// source is reloadable and any of its files changed.
+func (t *Template[T]) template() (*template.Template, error) {
+	if !t.source.Reload {
+		return t.htmlTmpl, nil
+	}
+
+	modTimes, err := t.source.modTimes(t.patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat fs template: %w", err)
+	}
+
+	t.mu.RLock()
+	tmpl, reload := t.htmlTmpl, changed(t.modTimes, modTimes)
+	t.mu.RUnlock()
+	if !reload {
+		return tmpl, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if changed(t.modTimes, modTimes) {
+		if err := t.parse(); err != nil {
+			return nil, err
+		}
+	}
+	return t.htmlTmpl, nil
+}
+
+// Execute renders the template as HTML, or the data and errors as JSON when
+// the client asks for it (see httpll.WantsJSON).
 func (t *Template[T]) Execute(w http.ResponseWriter, r *http.Request, data T, errors ...entities.ClientError) {
+	w.Header().Add("Vary", "Accept")
+	if httpll.WantsJSON(r) {
+		t.executeJSON(w, r, data, errors...)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := t.execute(&buf, r, data, errors...); err != nil {
+		contextutil.Logger(r.Context()).Error("Failed to execute template", "error", err)
+		t.renderInternalServerError(w, r)
+		return
+	}
+
+	_, err := io.Copy(w, &buf)
+	if err != nil {
+		contextutil.Logger(r.Context()).Error("Failed to send data to ResponseWriter", "error", err)
+	}
+}
+
+func (t *Template[T]) execute(w io.Writer, r *http.Request, data T, errors ...entities.ClientError) error {
+	localizer := result.ExtractValue(contextutil.GetLocalizer(r.Context()))
 	tmplData := templateData[T]{
 		CSRFField: csrf.TemplateField(r),
 		Data:      data,
 		User:      result.ExtractValue(contextutil.GetUser(r.Context())),
-		Errors:    toStringSlice(errors),
+		Errors:    toStringSlice(localizer, errors),
+		Flashes:   localizeFlashes(localizer, result.ExtractValue(contextutil.GetFlashes(r.Context()))),
+		Locale:    localizer.Locale(),
+		Locales:   localizer.Locales(),
+	}
+	tmpl, err := t.template()
+	if err != nil {
+		return err
 	}
 
-	var buf bytes.Buffer
-	if err := t.htmlTmpl.Execute(&buf, tmplData); err != nil {
-		t.logError.Println("Failed to execute template:", err)
-		httpll.Redirect500Page(w, r)
+	// the parsed template is never executed, so it can be cloned to bind
+	// the functions to the client localizer
+	tmpl, err = tmpl.Clone()
+	if err != nil {
+		return err
+	}
+	return tmpl.Funcs(localizedFuncs(localizer)).Execute(w, tmplData)
+}
+
+func (t *Template[T]) renderInternalServerError(w http.ResponseWriter, r *http.Request) {
+	if t.errorPage == nil {
+		httpll.SendStatusInternalServerError(w, r)
 		return
 	}
+	t.errorPage.Render(w, r, http.StatusInternalServerError)
+}
 
-	_, err := io.Copy(w, &buf)
+type jsonTemplateData[T any] struct {
+	Data      T              `json:"data"`
+	Errors    []string       `json:"errors,omitempty"`
+	Flashes   []httpll.Flash `json:"flashes,omitempty"`
+	CSRFToken string         `json:"csrf_token,omitempty"`
+}
+
+// executeJSON responds with the same data given to the HTML template. When
+// there are errors the status code is 422 Unprocessable Entity.
+func (t *Template[T]) executeJSON(w http.ResponseWriter, r *http.Request, data T, errors ...entities.ClientError) {
+	localizer := result.ExtractValue(contextutil.GetLocalizer(r.Context()))
+	body, err := json.Marshal(jsonTemplateData[T]{
+		Data:      data,
+		Errors:    toStringSlice(localizer, errors),
+		Flashes:   localizeFlashes(localizer, result.ExtractValue(contextutil.GetFlashes(r.Context()))),
+		CSRFToken: csrf.Token(r),
+	})
 	if err != nil {
-		t.logError.Println("Failed to send data to ResponseWriter:", err)
+		contextutil.Logger(r.Context()).Error("Failed to encode template data as JSON", "error", err)
+		t.renderInternalServerError(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if len(errors) > 0 {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	if _, err := w.Write(body); err != nil {
+		contextutil.Logger(r.Context()).Error("Failed to send data to ResponseWriter", "error", err)
 	}
 }
 
-func toStringSlice(errors []entities.ClientError) []string {
+// toStringSlice translates the client errors, each line of an error is
+// a message key.
+func toStringSlice(l *i18n.Localizer, errors []entities.ClientError) []string {
 	var result []string
 	if len(errors) > 0 {
 		result = make([]string, 0, len(errors))
 		for _, err := range errors {
-			result = append(result, err.ClientErr())
+			result = append(result, l.TLines(err.ClientErr()))
+		}
+	}
+	return result
+}
+
+// localizeFlashes translates the flash messages, stored as message keys.
+// Each line is a key, as in the client errors flashed.
+func localizeFlashes(l *i18n.Localizer, flashes []httpll.Flash) []httpll.Flash {
+	var result []httpll.Flash
+	if len(flashes) > 0 {
+		result = make([]httpll.Flash, 0, len(flashes))
+		for _, flash := range flashes {
+			flash.Message = l.TLines(flash.Message)
+			result = append(result, flash)
 		}
 	}
 	return result
@@ -0,0 +1,24 @@
+package views
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twsm000/lenslocked/locales"
+	"github.com/twsm000/lenslocked/models/httpll"
+	"github.com/twsm000/lenslocked/pkg/i18n"
+)
+
+func TestLocalizeFlashesTranslatesEachLine(t *testing.T) {
+	bundle, err := i18n.LoadFS(locales.FS, locales.Fallback)
+	require.NoError(t, err)
+	l := bundle.Localizer("en")
+
+	flashes := localizeFlashes(l, []httpll.Flash{
+		{Level: httpll.FlashError, Message: "error.user.not_found\nerror.user.email_taken"},
+	})
+	require.Len(t, flashes, 1)
+	assert.Equal(t, l.T("error.user.not_found")+"\n"+l.T("error.user.email_taken"), flashes[0].Message)
+	assert.NotContains(t, flashes[0].Message, "error.user")
+}